@@ -2,8 +2,10 @@ package add
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strconv"
 
 	"github.com/ClickHouse/ch-go/proto"
 	ice "github.com/agnosticeng/icepq/internal/iceberg"
@@ -12,7 +14,41 @@ import (
 )
 
 func Flags() []cli.Flag {
-	return []cli.Flag{}
+	return []cli.Flag{
+		&cli.BoolFlag{Name: "with-options", Usage: "read an additional options Map(String, String) argument"},
+	}
+}
+
+func parseOptions(opts map[string]string) (ice.CreateOrAddFilesConfig, error) {
+	var (
+		conf ice.CreateOrAddFilesConfig
+		err  error
+	)
+
+	for k, v := range opts {
+		switch k {
+		case "partition_by":
+			conf.PartitionBy = v
+		case "sort_by":
+			conf.SortBy = v
+		case "branch":
+			conf.Branch = v
+		case "wap_id":
+			conf.WapId = v
+		case "evolve_schema":
+			if conf.EvolveSchema, err = strconv.ParseBool(v); err != nil {
+				return conf, fmt.Errorf("invalid %s option: %w", k, err)
+			}
+		case "union_schema":
+			if conf.UnionSchema, err = strconv.ParseBool(v); err != nil {
+				return conf, fmt.Errorf("invalid %s option: %w", k, err)
+			}
+		default:
+			return conf, fmt.Errorf("unknown option: %s", k)
+		}
+	}
+
+	return conf, nil
 }
 
 func Command() *cli.Command {
@@ -24,6 +60,7 @@ func Command() *cli.Command {
 				buf                   proto.Buffer
 				inputTableLocationCol = new(proto.ColStr)
 				inputFilesCol         = new(proto.ColStr).Array()
+				inputOptionsCol       = proto.NewMap[string, string](new(proto.ColStr), new(proto.ColStr))
 				outputErrorCol        = new(proto.ColStr)
 
 				input = proto.Results{
@@ -36,6 +73,10 @@ func Command() *cli.Command {
 				}
 			)
 
+			if ctx.Bool("with-options") {
+				input = append(input, proto.ResultColumn{Name: "options", Data: inputOptionsCol})
+			}
+
 			for {
 				var (
 					inputBlock proto.Block
@@ -55,13 +96,27 @@ func Command() *cli.Command {
 				}
 
 				for i := 0; i < input.Rows(); i++ {
-					var err = ice.DoCommit(
+					var opts map[string]string
+
+					if ctx.Bool("with-options") {
+						opts = inputOptionsCol.Row(i)
+					}
+
+					conf, err := parseOptions(opts)
+
+					if err != nil {
+						return err
+					}
+
+					err = ice.DoCommit(
+						ctx.Context,
 						func() error {
 							return ice.CreateOrAddFiles(
 								ctx.Context,
 								inputTableLocationCol.Row(i),
 								inputFilesCol.Row(i),
 								iceberg.Properties{},
+								conf,
 							)
 						},
 					)
@@ -90,6 +145,7 @@ func Command() *cli.Command {
 					&buf,
 					inputTableLocationCol,
 					inputFilesCol,
+					inputOptionsCol,
 					outputErrorCol,
 				)
 			}
@@ -0,0 +1,96 @@
+package column_stats
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/ClickHouse/ch-go/proto"
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v2"
+)
+
+func Flags() []cli.Flag {
+	return []cli.Flag{}
+}
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "column-stats",
+		Flags: Flags(),
+		Action: func(ctx *cli.Context) error {
+			var (
+				buf                   proto.Buffer
+				inputTableLocationCol = new(proto.ColStr)
+				outputResultCol       = new(proto.ColBytes)
+
+				input = proto.Results{
+					{Name: "table_location", Data: inputTableLocationCol},
+				}
+
+				output = proto.Input{
+					{Name: "result", Data: outputResultCol},
+				}
+			)
+
+			for {
+				var (
+					inputBlock proto.Block
+					err        = inputBlock.DecodeRawBlock(
+						proto.NewReader(os.Stdin),
+						54451,
+						input,
+					)
+				)
+
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+
+				if err != nil {
+					return err
+				}
+
+				for i := 0; i < input.Rows(); i++ {
+					report, err := ice.ColumnStats(
+						ctx.Context,
+						inputTableLocationCol.Row(i),
+						ice.ColumnStatsConfig{},
+					)
+
+					if err != nil {
+						outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+							"error": err.Error(),
+						})))
+						continue
+					}
+
+					outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+						"value": report,
+					})))
+				}
+
+				var outputblock = proto.Block{
+					Columns: 1,
+					Rows:    input.Rows(),
+				}
+
+				if err := outputblock.EncodeRawBlock(&buf, 54451, output); err != nil {
+					return err
+				}
+
+				if _, err := os.Stdout.Write(buf.Buf); err != nil {
+					return err
+				}
+
+				proto.Reset(
+					&buf,
+					inputTableLocationCol,
+					outputResultCol,
+				)
+			}
+		},
+	}
+}
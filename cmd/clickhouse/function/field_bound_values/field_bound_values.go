@@ -76,6 +76,7 @@ func Command() *cli.Command {
 
 					res, err := iter.MapErr(values, func(item *ice.FieldBoundValuesItem) (json.RawMessage, error) {
 						js, err := json.Marshal(item)
+
 						if err != nil {
 							return nil, err
 						}
@@ -2,8 +2,16 @@ package function
 
 import (
 	"github.com/agnosticeng/icepq/cmd/clickhouse/function/add"
+	"github.com/agnosticeng/icepq/cmd/clickhouse/function/column_stats"
 	"github.com/agnosticeng/icepq/cmd/clickhouse/function/field_bound_values"
+	"github.com/agnosticeng/icepq/cmd/clickhouse/function/field_range_summary"
+	"github.com/agnosticeng/icepq/cmd/clickhouse/function/inspect"
+	"github.com/agnosticeng/icepq/cmd/clickhouse/function/plan_compaction"
+	"github.com/agnosticeng/icepq/cmd/clickhouse/function/plan_files"
+	"github.com/agnosticeng/icepq/cmd/clickhouse/function/publish"
 	"github.com/agnosticeng/icepq/cmd/clickhouse/function/replace"
+	"github.com/agnosticeng/icepq/cmd/clickhouse/function/rollback"
+	"github.com/agnosticeng/icepq/cmd/clickhouse/function/set_current_snapshot"
 	"github.com/urfave/cli/v2"
 )
 
@@ -14,6 +22,14 @@ func Command() *cli.Command {
 			add.Command(),
 			replace.Command(),
 			field_bound_values.Command(),
+			field_range_summary.Command(),
+			plan_files.Command(),
+			column_stats.Command(),
+			plan_compaction.Command(),
+			rollback.Command(),
+			set_current_snapshot.Command(),
+			publish.Command(),
+			inspect.Command(),
 		},
 	}
 }
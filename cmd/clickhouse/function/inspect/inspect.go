@@ -0,0 +1,146 @@
+package inspect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/ClickHouse/ch-go/proto"
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v2"
+)
+
+func Flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{Name: "with-options", Usage: "read an additional options Map(String, String) argument"},
+	}
+}
+
+func parseOptions(opts map[string]string) (ice.InspectConfig, error) {
+	var (
+		conf ice.InspectConfig
+		err  error
+	)
+
+	for k, v := range opts {
+		switch k {
+		case "snapshot_id":
+			if conf.SnapshotId, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return conf, fmt.Errorf("invalid %s option: %w", k, err)
+			}
+		case "ref":
+			conf.Ref = v
+		default:
+			return conf, fmt.Errorf("unknown option: %s", k)
+		}
+	}
+
+	if conf.SnapshotId != 0 && len(conf.Ref) > 0 {
+		return conf, fmt.Errorf("snapshot_id and ref options cannot be set together")
+	}
+
+	return conf, nil
+}
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "inspect",
+		Flags: Flags(),
+		Action: func(ctx *cli.Context) error {
+			var (
+				buf                   proto.Buffer
+				inputTableLocationCol = new(proto.ColStr)
+				inputMetadataTableCol = new(proto.ColStr)
+				inputOptionsCol       = proto.NewMap[string, string](new(proto.ColStr), new(proto.ColStr))
+				outputResultCol       = new(proto.ColBytes)
+
+				input = proto.Results{
+					{Name: "table_location", Data: inputTableLocationCol},
+					{Name: "metadata_table", Data: inputMetadataTableCol},
+				}
+
+				output = proto.Input{
+					{Name: "result", Data: outputResultCol},
+				}
+			)
+
+			if ctx.Bool("with-options") {
+				input = append(input, proto.ResultColumn{Name: "options", Data: inputOptionsCol})
+			}
+
+			for {
+				var (
+					inputBlock proto.Block
+					err        = inputBlock.DecodeRawBlock(
+						proto.NewReader(os.Stdin),
+						54451,
+						input,
+					)
+				)
+
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+
+				if err != nil {
+					return err
+				}
+
+				for i := 0; i < input.Rows(); i++ {
+					var opts map[string]string
+
+					if ctx.Bool("with-options") {
+						opts = inputOptionsCol.Row(i)
+					}
+
+					conf, err := parseOptions(opts)
+
+					if err != nil {
+						outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+							"error": err.Error(),
+						})))
+						continue
+					}
+
+					rows, err := ice.Inspect(ctx.Context, inputTableLocationCol.Row(i), inputMetadataTableCol.Row(i), conf)
+
+					if err != nil {
+						outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+							"error": err.Error(),
+						})))
+						continue
+					}
+
+					outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+						"value": rows,
+					})))
+				}
+
+				var outputblock = proto.Block{
+					Columns: 1,
+					Rows:    input.Rows(),
+				}
+
+				if err := outputblock.EncodeRawBlock(&buf, 54451, output); err != nil {
+					return err
+				}
+
+				if _, err := os.Stdout.Write(buf.Buf); err != nil {
+					return err
+				}
+
+				proto.Reset(
+					&buf,
+					inputTableLocationCol,
+					inputMetadataTableCol,
+					inputOptionsCol,
+					outputResultCol,
+				)
+			}
+		},
+	}
+}
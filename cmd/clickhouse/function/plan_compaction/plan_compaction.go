@@ -0,0 +1,155 @@
+package plan_compaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/ClickHouse/ch-go/proto"
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v2"
+)
+
+func Flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{Name: "with-options", Usage: "read an additional options Map(String, String) argument"},
+	}
+}
+
+func parseOptions(opts map[string]string) (ice.PlanCompactionConfig, error) {
+	var (
+		conf ice.PlanCompactionConfig
+		err  error
+	)
+
+	for k, v := range opts {
+		switch k {
+		case "target_file_size_bytes":
+			if conf.TargetFileSizeBytes, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return conf, fmt.Errorf("invalid %s option: %w", k, err)
+			}
+		case "min_file_size_bytes":
+			if conf.MinFileSizeBytes, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return conf, fmt.Errorf("invalid %s option: %w", k, err)
+			}
+		case "min_input_files":
+			if conf.MinInputFiles, err = strconv.Atoi(v); err != nil {
+				return conf, fmt.Errorf("invalid %s option: %w", k, err)
+			}
+		case "respect_partitions":
+			respect, err := strconv.ParseBool(v)
+
+			if err != nil {
+				return conf, fmt.Errorf("invalid %s option: %w", k, err)
+			}
+
+			conf.IgnorePartitions = !respect
+		case "sort_field":
+			conf.SortField = v
+		default:
+			return conf, fmt.Errorf("unknown option: %s", k)
+		}
+	}
+
+	return conf, nil
+}
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "plan-compaction",
+		Flags: Flags(),
+		Action: func(ctx *cli.Context) error {
+			var (
+				buf                   proto.Buffer
+				inputTableLocationCol = new(proto.ColStr)
+				inputOptionsCol       = proto.NewMap[string, string](new(proto.ColStr), new(proto.ColStr))
+				outputResultCol       = new(proto.ColBytes)
+
+				input = proto.Results{
+					{Name: "table_location", Data: inputTableLocationCol},
+				}
+
+				output = proto.Input{
+					{Name: "result", Data: outputResultCol},
+				}
+			)
+
+			if ctx.Bool("with-options") {
+				input = append(input, proto.ResultColumn{Name: "options", Data: inputOptionsCol})
+			}
+
+			for {
+				var (
+					inputBlock proto.Block
+					err        = inputBlock.DecodeRawBlock(
+						proto.NewReader(os.Stdin),
+						54451,
+						input,
+					)
+				)
+
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+
+				if err != nil {
+					return err
+				}
+
+				for i := 0; i < input.Rows(); i++ {
+					var opts map[string]string
+
+					if ctx.Bool("with-options") {
+						opts = inputOptionsCol.Row(i)
+					}
+
+					conf, err := parseOptions(opts)
+
+					if err != nil {
+						outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+							"error": err.Error(),
+						})))
+						continue
+					}
+
+					groups, err := ice.PlanCompaction(ctx.Context, inputTableLocationCol.Row(i), conf)
+
+					if err != nil {
+						outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+							"error": err.Error(),
+						})))
+						continue
+					}
+
+					outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+						"value": groups,
+					})))
+				}
+
+				var outputblock = proto.Block{
+					Columns: 1,
+					Rows:    input.Rows(),
+				}
+
+				if err := outputblock.EncodeRawBlock(&buf, 54451, output); err != nil {
+					return err
+				}
+
+				if _, err := os.Stdout.Write(buf.Buf); err != nil {
+					return err
+				}
+
+				proto.Reset(
+					&buf,
+					inputTableLocationCol,
+					inputOptionsCol,
+					outputResultCol,
+				)
+			}
+		},
+	}
+}
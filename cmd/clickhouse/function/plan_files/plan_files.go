@@ -0,0 +1,119 @@
+package plan_files
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/ClickHouse/ch-go/proto"
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/iter"
+	"github.com/urfave/cli/v2"
+)
+
+func Flags() []cli.Flag {
+	return []cli.Flag{}
+}
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "plan-files",
+		Flags: Flags(),
+		Action: func(ctx *cli.Context) error {
+			var (
+				buf                   proto.Buffer
+				inputTableLocationCol = new(proto.ColStr)
+				inputFilterCol        = new(proto.ColStr)
+				outputResultCol       = new(proto.ColBytes)
+
+				input = proto.Results{
+					{Name: "table_location", Data: inputTableLocationCol},
+					{Name: "filter", Data: inputFilterCol},
+				}
+
+				output = proto.Input{
+					{Name: "result", Data: outputResultCol},
+				}
+			)
+
+			for {
+				var (
+					inputBlock proto.Block
+					err        = inputBlock.DecodeRawBlock(
+						proto.NewReader(os.Stdin),
+						54451,
+						input,
+					)
+				)
+
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+
+				if err != nil {
+					return err
+				}
+
+				for i := 0; i < input.Rows(); i++ {
+					values, err := ice.PlanFiles(
+						ctx.Context,
+						inputTableLocationCol.Row(i),
+						ice.PlanFilesConfig{
+							Filter:            inputFilterCol.Row(i),
+							FailOnDeleteFiles: true,
+						},
+					)
+
+					if err != nil {
+						outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+							"error": err.Error(),
+						})))
+						continue
+					}
+
+					res, err := iter.MapErr(values, func(item *ice.PlanFilesItem) (json.RawMessage, error) {
+						js, err := json.Marshal(item)
+
+						if err != nil {
+							return nil, err
+						}
+						return js, nil
+					})
+
+					if err != nil {
+						outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+							"error": err.Error(),
+						})))
+						continue
+					}
+
+					outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+						"value": res,
+					})))
+				}
+
+				var outputblock = proto.Block{
+					Columns: 1,
+					Rows:    input.Rows(),
+				}
+
+				if err := outputblock.EncodeRawBlock(&buf, 54451, output); err != nil {
+					return err
+				}
+
+				if _, err := os.Stdout.Write(buf.Buf); err != nil {
+					return err
+				}
+
+				proto.Reset(
+					&buf,
+					inputTableLocationCol,
+					inputFilterCol,
+					outputResultCol,
+				)
+			}
+		},
+	}
+}
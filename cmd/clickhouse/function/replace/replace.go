@@ -57,13 +57,14 @@ func Command() *cli.Command {
 				}
 
 				for i := 0; i < input.Rows(); i++ {
-					var err = ice.DoCommit(func() error {
+					var err = ice.DoCommit(ctx.Context, func() error {
 						return ice.ReplaceFiles(
 							ctx.Context,
 							inputTableLocationCol.Row(i),
 							inputInputFilesCol.Row(i),
 							inputOutputFilesCol.Row(i),
 							iceberg.Properties{},
+							ice.ReplaceFilesConfig{},
 						)
 					})
 
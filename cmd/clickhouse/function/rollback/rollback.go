@@ -0,0 +1,119 @@
+package rollback
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/ClickHouse/ch-go/proto"
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v2"
+)
+
+func Flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{Name: "to-timestamp", Usage: "read a DateTime64 timestamp argument instead of a snapshot ID"},
+	}
+}
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "rollback",
+		Flags: Flags(),
+		Action: func(ctx *cli.Context) error {
+			var (
+				buf                   proto.Buffer
+				inputTableLocationCol = new(proto.ColStr)
+				inputSnapshotIDCol    = new(proto.ColInt64)
+				inputTimestampCol     = new(proto.ColDateTime64)
+				outputResultCol       = new(proto.ColBytes)
+				toTimestamp           = ctx.Bool("to-timestamp")
+
+				input = proto.Results{
+					{Name: "table_location", Data: inputTableLocationCol},
+				}
+
+				output = proto.Input{
+					{Name: "result", Data: outputResultCol},
+				}
+			)
+
+			if toTimestamp {
+				input = append(input, proto.ResultColumn{Name: "timestamp", Data: inputTimestampCol})
+			} else {
+				input = append(input, proto.ResultColumn{Name: "snapshot_id", Data: inputSnapshotIDCol})
+			}
+
+			for {
+				var (
+					inputBlock proto.Block
+					err        = inputBlock.DecodeRawBlock(
+						proto.NewReader(os.Stdin),
+						54451,
+						input,
+					)
+				)
+
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+
+				if err != nil {
+					return err
+				}
+
+				for i := 0; i < input.Rows(); i++ {
+					var conf ice.RollbackConfig
+
+					if toTimestamp {
+						conf.Timestamp = inputTimestampCol.Row(i)
+					} else {
+						conf.SnapshotId = inputSnapshotIDCol.Row(i)
+					}
+
+					var change *ice.CurrentSnapshotChange
+
+					err := ice.DoCommit(ctx.Context, func() error {
+						var err error
+						change, err = ice.Rollback(ctx.Context, inputTableLocationCol.Row(i), conf)
+						return err
+					})
+
+					if err != nil {
+						outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+							"error": err.Error(),
+						})))
+						continue
+					}
+
+					outputResultCol.Append(lo.Must(json.Marshal(map[string]any{
+						"value": change,
+					})))
+				}
+
+				var outputblock = proto.Block{
+					Columns: 1,
+					Rows:    input.Rows(),
+				}
+
+				if err := outputblock.EncodeRawBlock(&buf, 54451, output); err != nil {
+					return err
+				}
+
+				if _, err := os.Stdout.Write(buf.Buf); err != nil {
+					return err
+				}
+
+				proto.Reset(
+					&buf,
+					inputTableLocationCol,
+					inputSnapshotIDCol,
+					inputTimestampCol,
+					outputResultCol,
+				)
+			}
+		},
+	}
+}
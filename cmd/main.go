@@ -12,6 +12,7 @@ import (
 	"github.com/agnosticeng/icepq/cmd/clickhouse"
 	"github.com/agnosticeng/icepq/cmd/schema"
 	"github.com/agnosticeng/icepq/cmd/table"
+	icecli "github.com/agnosticeng/icepq/internal/iceberg/cli"
 	objstrcli "github.com/agnosticeng/objstr/cli"
 	"github.com/agnosticeng/panicsafe"
 	"github.com/agnosticeng/slogcli"
@@ -25,6 +26,7 @@ func main() {
 		Before: cliutils.CombineBeforeFuncs(
 			slogcli.SlogBefore,
 			objstrcli.ObjStrBefore(cnf.WithProvider(env.NewEnvProvider("OBJSTR"))),
+			icecli.IcebergBefore(cnf.WithProvider(env.NewEnvProvider("ICEPQ"))),
 		),
 		After: cliutils.CombineAfterFuncs(
 			objstrcli.ObjStrAfter,
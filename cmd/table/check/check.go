@@ -0,0 +1,56 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+// exitCodeIssues is the exit code when the check completes and finds broken files; other
+// failures exit with 1.
+const exitCodeIssues = 2
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "check",
+		Usage: "<location>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "all-snapshots", Usage: "check the files of every snapshot instead of the current one"},
+			&cli.BoolFlag{Name: "validate-parquet", Usage: "read the footer of Parquet data files and check their record count"},
+			&cli.IntFlag{Name: "concurrency", Value: ice.CheckTableConcurrencyDefault, Usage: "maximum number of files checked concurrently"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.CheckTableConfig{
+					AllSnapshots:    ctx.Bool("all-snapshots"),
+					ValidateParquet: ctx.Bool("validate-parquet"),
+					Concurrency:     ctx.Int("concurrency"),
+				}
+			)
+
+			report, err := ice.CheckTable(ctx.Context, location, conf)
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(report)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+
+			if len(report.Issues) > 0 {
+				return cli.Exit(fmt.Sprintf("%d broken files found", len(report.Issues)), exitCodeIssues)
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package column_stats
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "column-stats",
+		Usage: "<location> [column...]",
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.ColumnStatsConfig{Columns: ctx.Args().Tail()}
+			)
+
+			report, err := ice.ColumnStats(ctx.Context, location, conf)
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(report)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
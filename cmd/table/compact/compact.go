@@ -0,0 +1,61 @@
+package compact
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "compact",
+		Usage: "<location> [file...]",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "target-file-size-bytes", Usage: "defaults to the write.target-file-size-bytes table property"},
+			&cli.Int64Flag{Name: "min-file-size-bytes", Usage: "files at least this big are not compacted (defaults to 75% of the target size)"},
+			&cli.IntFlag{Name: "min-input-files", Value: 2},
+			&cli.BoolFlag{Name: "respect-partitions", Value: true},
+			&cli.StringFlag{Name: "sort-field", Usage: "group files by adjacent ranges of this field"},
+			&cli.BoolFlag{Name: "sort", Usage: "sort rows by the table sort order"},
+			&cli.StringSliceFlag{Name: "prop"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.CompactConfig{
+					Files: ctx.Args().Tail(),
+					Plan: ice.PlanCompactionConfig{
+						TargetFileSizeBytes: ctx.Int64("target-file-size-bytes"),
+						MinFileSizeBytes:    ctx.Int64("min-file-size-bytes"),
+						MinInputFiles:       ctx.Int("min-input-files"),
+						IgnorePartitions:    !ctx.Bool("respect-partitions"),
+						SortField:           ctx.String("sort-field"),
+					},
+					Sort:       ctx.Bool("sort"),
+					Properties: ice.ParseProperties(ctx.StringSlice("prop")),
+				}
+			)
+
+			res, err := ice.Compact(ctx.Context, location, conf)
+
+			if err != nil {
+				return err
+			}
+
+			for _, r := range res {
+				js, err := json.Marshal(r)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(js))
+			}
+
+			return nil
+		},
+	}
+}
@@ -11,6 +11,12 @@ func Command() *cli.Command {
 		Usage: "<location> <file1> [<file2> ...]",
 		Flags: []cli.Flag{
 			&cli.StringSliceFlag{Name: "prop"},
+			&cli.StringFlag{Name: "partition-by", Usage: "partition spec used when creating the table, e.g. \"day(ts), bucket(16, id)\""},
+			&cli.StringFlag{Name: "sort-by", Usage: "sort order used when creating the table, e.g. \"block_number ASC NULLS LAST\""},
+			&cli.BoolFlag{Name: "evolve-schema", Usage: "add new columns and widen existing ones to match the added files"},
+			&cli.BoolFlag{Name: "union-schema", Usage: "create the table with the union of the schemas of the files"},
+			&cli.StringFlag{Name: "branch", Usage: "branch to commit to, main by default"},
+			&cli.StringFlag{Name: "wap-id", Usage: "stage the snapshot with this wap.id instead of committing it, see publish"},
 		},
 		Action: func(ctx *cli.Context) error {
 			var (
@@ -23,12 +29,20 @@ func Command() *cli.Command {
 				return nil
 			}
 
-			return ice.DoCommit(func() error {
+			return ice.DoCommit(ctx.Context, func() error {
 				return ice.CreateOrAddFiles(
 					ctx.Context,
 					location,
 					files,
 					props,
+					ice.CreateOrAddFilesConfig{
+						PartitionBy:  ctx.String("partition-by"),
+						SortBy:       ctx.String("sort-by"),
+						EvolveSchema: ctx.Bool("evolve-schema"),
+						UnionSchema:  ctx.Bool("union-schema"),
+						Branch:       ctx.String("branch"),
+						WapId:        ctx.String("wap-id"),
+					},
 				)
 			})
 		},
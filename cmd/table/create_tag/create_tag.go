@@ -0,0 +1,51 @@
+package create_tag
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "create-tag",
+		Usage: "<location> <name>",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "snapshot-id", Usage: "snapshot the tag points to, the current snapshot by default"},
+			&cli.StringFlag{Name: "wap-id", Usage: "point the tag to the snapshot staged with this wap.id"},
+			&cli.Int64Flag{Name: "max-ref-age-ms", Usage: "age after which expire-snapshots drops the tag"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				name     = ctx.Args().Get(1)
+				change   *ice.RefChange
+			)
+
+			err := ice.DoCommit(ctx.Context, func() error {
+				var err error
+				change, err = ice.CreateTag(ctx.Context, location, name, ice.RefConfig{
+					SnapshotId:  ctx.Int64("snapshot-id"),
+					WapId:       ctx.String("wap-id"),
+					MaxRefAgeMs: ctx.Int64("max-ref-age-ms"),
+				})
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(change)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "diff",
+		Usage: "<location> <from-snapshot> <to-snapshot>",
+		Action: func(ctx *cli.Context) error {
+			var location = ctx.Args().Get(0)
+
+			from, err := strconv.ParseInt(ctx.Args().Get(1), 10, 64)
+
+			if err != nil {
+				return fmt.Errorf("invalid from snapshot ID: %w", err)
+			}
+
+			to, err := strconv.ParseInt(ctx.Args().Get(2), 10, 64)
+
+			if err != nil {
+				return fmt.Errorf("invalid to snapshot ID: %w", err)
+			}
+
+			diff, err := ice.DiffSnapshots(ctx.Context, location, from, to)
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(diff)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package drop_ref
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "drop-ref",
+		Usage: "<location> <name>",
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				name     = ctx.Args().Get(1)
+				change   *ice.RefChange
+			)
+
+			err := ice.DoCommit(ctx.Context, func() error {
+				var err error
+				change, err = ice.DropRef(ctx.Context, location, name)
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(change)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
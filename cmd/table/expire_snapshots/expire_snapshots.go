@@ -1,11 +1,12 @@
 package expire_snapshots
 
 import (
-	"net/url"
+	"encoding/json"
+	"fmt"
 
 	ice "github.com/agnosticeng/icepq/internal/iceberg"
-	"github.com/apache/iceberg-go/table"
 	"github.com/urfave/cli/v2"
+	_ "gocloud.dev/blob/s3blob"
 )
 
 func Command() *cli.Command {
@@ -13,53 +14,41 @@ func Command() *cli.Command {
 		Name:  "expire-snapshots",
 		Usage: "<location>",
 		Flags: []cli.Flag{
-			&cli.StringSliceFlag{Name: "prop"},
-			&cli.IntFlag{Name: "retain-last"},
-			&cli.DurationFlag{Name: "older-than"},
+			&cli.IntFlag{Name: "retain-last", Usage: "overrides the history.expire.min-snapshots-to-keep table property"},
+			&cli.DurationFlag{Name: "older-than", Usage: "overrides the history.expire.max-snapshot-age-ms table property"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "only report the snapshots to expire and the files to delete"},
+			&cli.IntFlag{Name: "concurrency", Value: ice.ExpireSnapshotsConcurrencyDefault, Usage: "maximum number of concurrent file deletions"},
 		},
 		Action: func(ctx *cli.Context) error {
 			var (
-				props         = ice.ParseProperties(ctx.StringSlice("prop"))
-				location, err = url.Parse(ctx.Args().Get(0))
-				olderThan     = ctx.Duration("older-than")
-				retainLast    = ctx.Int("retain-last")
+				location = ctx.Args().Get(0)
+				conf     = ice.ExpireSnapshotsConfig{
+					OlderThan:   ctx.Duration("older-than"),
+					RetainLast:  ctx.Int("retain-last"),
+					DryRun:      ctx.Bool("dry-run"),
+					Concurrency: ctx.Int("concurrency"),
+				}
+				res *ice.ExpireSnapshotsResult
 			)
 
-			if err != nil {
+			err := ice.DoCommit(ctx.Context, func() error {
+				var err error
+				res, err = ice.ExpireSnapshots(ctx.Context, location, conf)
 				return err
-			}
-
-			cat, err := ice.NewVersionHintCatalog(location.String())
+			})
 
 			if err != nil {
 				return err
 			}
 
-			t, err := cat.LoadTable(ctx.Context, nil, props)
+			js, err := json.Marshal(res)
 
 			if err != nil {
 				return err
 			}
 
-			var (
-				tx   = t.NewTransaction()
-				opts []table.ExpireSnapshotsOpt
-			)
-
-			if olderThan > 0 {
-				opts = append(opts, table.WithOlderThan(olderThan))
-			}
-
-			if retainLast > 0 {
-				opts = append(opts, table.WithRetainLast(retainLast))
-			}
-
-			if err := tx.ExpireSnapshots(opts...); err != nil {
-				return err
-			}
-
-			_, err = tx.Commit(ctx.Context)
-			return err
+			fmt.Println(string(js))
+			return nil
 		},
 	}
 }
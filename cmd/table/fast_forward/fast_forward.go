@@ -0,0 +1,43 @@
+package fast_forward
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "fast-forward",
+		Usage: "<location> <branch> <to>",
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				branch   = ctx.Args().Get(1)
+				to       = ctx.Args().Get(2)
+				change   *ice.RefChange
+			)
+
+			err := ice.DoCommit(ctx.Context, func() error {
+				var err error
+				change, err = ice.FastForward(ctx.Context, location, branch, to)
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(change)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
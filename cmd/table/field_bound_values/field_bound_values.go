@@ -16,24 +16,46 @@ func Command() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.BoolFlag{Name: "fail-on-delete-files"},
 			&cli.BoolFlag{Name: "fail-on-missing-values"},
+			&cli.Int64Flag{Name: "snapshot-id"},
+			&cli.BoolFlag{Name: "summary", Usage: "output the global bounds, gaps and overlaps of the file ranges"},
 		},
 		Action: func(ctx *cli.Context) error {
 			var (
 				location  = ctx.Args().Get(0)
 				fieldName = ctx.Args().Get(1)
 				conf      = ice.FieldBoundValuesConfig{
+					SnapshotId:          ctx.Int64("snapshot-id"),
 					FailOnDeleteFiles:   ctx.Bool("fail-on-delete-files"),
 					FailOnMissingValues: ctx.Bool("fail-on-missing-values"),
 				}
 			)
 
+			if ctx.Bool("summary") {
+				report, err := ice.FieldRangeSummary(ctx.Context, location, fieldName, conf)
+
+				if err != nil {
+					return err
+				}
+
+				js, err := json.Marshal(report)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(js))
+				return nil
+			}
+
 			items, err := ice.FieldBoundValues(ctx.Context, location, fieldName, conf)
+
 			if err != nil {
 				return err
 			}
 
 			for _, item := range items {
 				js, err := json.Marshal(item)
+
 				if err != nil {
 					return err
 				}
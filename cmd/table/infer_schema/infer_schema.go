@@ -0,0 +1,55 @@
+package infer_schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/apache/iceberg-go"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "infer-schema",
+		Usage: "<location> <file1> [<file2> ...]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "union", Usage: "infer the union of the schemas of the files instead of requiring them to be equal"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				files = ctx.Args().Slice()[1:]
+				res   struct {
+					Schema *iceberg.Schema      `json:"schema"`
+					Files  []ice.FileSchemaDiff `json:"files,omitempty"`
+				}
+			)
+
+			location, err := url.Parse(ctx.Args().Get(0))
+
+			if err != nil {
+				return err
+			}
+
+			if ctx.Bool("union") {
+				res.Schema, res.Files, err = ice.UnionSchemaFromParquetDataFiles(ctx.Context, location, files)
+			} else {
+				res.Schema, err = ice.SchemaFromParquetDataFiles(ctx.Context, location, files)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(res)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,115 @@
+package inspect
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+func writeJSON(w io.Writer, rows []any) error {
+	for _, row := range rows {
+		js, err := json.Marshal(row)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, string(js))
+	}
+
+	return nil
+}
+
+func writeCSV(w io.Writer, rows []any) error {
+	var cw = csv.NewWriter(w)
+
+	if err := writeRecords(rows, cw.Write); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTable(w io.Writer, rows []any) error {
+	var tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	err := writeRecords(rows, func(record []string) error {
+		_, err := fmt.Fprintln(tw, strings.Join(record, "\t"))
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tw.Flush()
+}
+
+// writeRecords writes a header with the JSON names of the fields of the rows, then a
+// record per row. Strings are written as is and other values, nested ones included, as
+// JSON; null values are written as empty strings.
+func writeRecords(rows []any, write func([]string) error) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var (
+		typ    = reflect.TypeOf(rows[0])
+		header []string
+	)
+
+	for i := 0; i < typ.NumField(); i++ {
+		header = append(header, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+	}
+
+	if err := write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var (
+			v      = reflect.ValueOf(row)
+			record []string
+		)
+
+		for i := 0; i < v.NumField(); i++ {
+			s, err := formatValue(v.Field(i).Interface())
+
+			if err != nil {
+				return err
+			}
+
+			record = append(record, s)
+		}
+
+		if err := write(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatValue(v any) (string, error) {
+	js, err := json.Marshal(v)
+
+	if err != nil {
+		return "", err
+	}
+
+	var s string
+
+	switch {
+	case string(js) == "null":
+		return "", nil
+	case json.Unmarshal(js, &s) == nil:
+		return s, nil
+	default:
+		return string(js), nil
+	}
+}
@@ -0,0 +1,60 @@
+package inspect
+
+import (
+	"fmt"
+	"os"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v2"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "inspect",
+		Usage: "print the rows of a metadata table",
+		Subcommands: lo.Map(ice.InspectTables, func(name string, _ int) *cli.Command {
+			return tableCommand(name)
+		}),
+	}
+}
+
+func tableCommand(name string) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "<location>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Value: "json", Usage: "output format: json (one object per line), csv or table"},
+			&cli.Int64Flag{Name: "snapshot-id", Usage: "snapshot to read, instead of the current snapshot"},
+			&cli.StringFlag{Name: "ref", Usage: "branch or tag to read, instead of the current snapshot"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.InspectConfig{SnapshotId: ctx.Int64("snapshot-id"), Ref: ctx.String("ref")}
+			)
+
+			if conf.SnapshotId != 0 && len(conf.Ref) > 0 {
+				return fmt.Errorf("--snapshot-id and --ref cannot be set together")
+			}
+
+			rows, err := ice.Inspect(ctx.Context, location, name, conf)
+
+			if err != nil {
+				return err
+			}
+
+			switch ctx.String("format") {
+			case "json":
+				return writeJSON(os.Stdout, rows)
+			case "csv":
+				return writeCSV(os.Stdout, rows)
+			case "table":
+				return writeTable(os.Stdout, rows)
+			default:
+				return fmt.Errorf("unknown format %s, must be one of json, csv, table", ctx.String("format"))
+			}
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package plan_files
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "plan-files",
+		Usage: "<location> [filter]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "fail-on-delete-files"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.PlanFilesConfig{
+					Filter:            ctx.Args().Get(1),
+					FailOnDeleteFiles: ctx.Bool("fail-on-delete-files"),
+				}
+			)
+
+			items, err := ice.PlanFiles(ctx.Context, location, conf)
+
+			if err != nil {
+				return err
+			}
+
+			for _, item := range items {
+				js, err := json.Marshal(item)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(js))
+			}
+
+			return nil
+		},
+	}
+}
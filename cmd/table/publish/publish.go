@@ -0,0 +1,50 @@
+package publish
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "publish",
+		Usage: "<location>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "wap-id", Usage: "wap.id of the staged snapshot to publish"},
+			&cli.Int64Flag{Name: "snapshot-id", Usage: "ID of the staged snapshot to publish (cherry-pick)"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.PublishConfig{WapId: ctx.String("wap-id"), SnapshotId: ctx.Int64("snapshot-id")}
+				change   *ice.CurrentSnapshotChange
+			)
+
+			if (len(conf.WapId) == 0) == (conf.SnapshotId == 0) {
+				return fmt.Errorf("exactly one of --wap-id and --snapshot-id must be set")
+			}
+
+			err := ice.DoCommit(ctx.Context, func() error {
+				var err error
+				change, err = ice.Publish(ctx.Context, location, conf)
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(change)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package remove_orphan_files
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "remove-orphan-files",
+		Usage: "<location>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "older-than", Value: "3d", Usage: "only remove files older than this, e.g. 3d or 12h, to keep files of in-flight writes"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "only report orphan files"},
+			&cli.IntFlag{Name: "concurrency", Value: ice.RemoveOrphanFilesConcurrencyDefault, Usage: "maximum number of concurrent deletions"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var location = ctx.Args().Get(0)
+
+			olderThan, err := ice.ParseDuration(ctx.String("older-than"))
+
+			if err != nil {
+				return err
+			}
+
+			orphans, err := ice.RemoveOrphanFiles(ctx.Context, location, ice.RemoveOrphanFilesConfig{
+				OlderThan:   olderThan,
+				DryRun:      ctx.Bool("dry-run"),
+				Concurrency: ctx.Int("concurrency"),
+			})
+
+			if err != nil {
+				return err
+			}
+
+			for _, orphan := range orphans {
+				js, err := json.Marshal(orphan)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(js))
+			}
+
+			return nil
+		},
+	}
+}
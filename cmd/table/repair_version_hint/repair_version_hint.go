@@ -0,0 +1,44 @@
+package repair_version_hint
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "repair-version-hint",
+		Usage: "<location>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "dry-run"},
+			&cli.BoolFlag{Name: "force", Usage: "point the hint to the newest metadata file even when the current one is valid"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.RepairVersionHintConfig{
+					DryRun: ctx.Bool("dry-run"),
+					Force:  ctx.Bool("force"),
+				}
+			)
+
+			report, err := ice.RepairVersionHint(ctx.Context, location, conf)
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(report)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
@@ -16,6 +16,7 @@ func Command() *cli.Command {
 		Usage: "<location>  <input_file_1,input_file_2,...>  <output_file_1,output_file_2>",
 		Flags: []cli.Flag{
 			&cli.StringSliceFlag{Name: "prop"},
+			&cli.StringFlag{Name: "branch", Usage: "branch to commit to, main by default"},
 		},
 		Action: func(ctx *cli.Context) error {
 			var (
@@ -39,13 +40,14 @@ func Command() *cli.Command {
 				return err
 			}
 
-			return ice.DoCommit(func() error {
+			return ice.DoCommit(ctx.Context, func() error {
 				return ice.ReplaceFiles(
 					ctx.Context,
 					location.String(),
 					inputFiles,
 					outputFiles,
 					props,
+					ice.ReplaceFilesConfig{Branch: ctx.String("branch")},
 				)
 			})
 		},
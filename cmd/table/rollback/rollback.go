@@ -0,0 +1,55 @@
+package rollback
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "rollback",
+		Usage: "<location>",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "to-snapshot", Usage: "ID of an ancestor of the current snapshot"},
+			&cli.TimestampFlag{Name: "to-timestamp", Layout: time.RFC3339, Usage: "roll back to the last ancestor of the current snapshot committed at or before this time"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.RollbackConfig{SnapshotId: ctx.Int64("to-snapshot")}
+				change   *ice.CurrentSnapshotChange
+			)
+
+			if ts := ctx.Timestamp("to-timestamp"); ts != nil {
+				conf.Timestamp = *ts
+			}
+
+			if (conf.SnapshotId == 0) == conf.Timestamp.IsZero() {
+				return fmt.Errorf("exactly one of --to-snapshot and --to-timestamp must be set")
+			}
+
+			err := ice.DoCommit(ctx.Context, func() error {
+				var err error
+				change, err = ice.Rollback(ctx.Context, location, conf)
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(change)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
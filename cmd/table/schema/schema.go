@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/apache/iceberg-go"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "schema",
+		Usage: "evolve the schema of a table",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "add-column",
+				Usage: "<location> <path> <type>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "doc"},
+				},
+				Action: func(ctx *cli.Context) error {
+					return updateSchema(ctx, ice.AddColumn(ctx.Args().Get(1), ctx.Args().Get(2), ctx.String("doc")))
+				},
+			},
+			{
+				Name:  "drop-column",
+				Usage: "<location> <path>",
+				Action: func(ctx *cli.Context) error {
+					return updateSchema(ctx, ice.DropColumn(ctx.Args().Get(1)))
+				},
+			},
+			{
+				Name:  "rename-column",
+				Usage: "<location> <path> <new_name>",
+				Action: func(ctx *cli.Context) error {
+					return updateSchema(ctx, ice.RenameColumn(ctx.Args().Get(1), ctx.Args().Get(2)))
+				},
+			},
+			{
+				Name:  "make-optional",
+				Usage: "<location> <path>",
+				Action: func(ctx *cli.Context) error {
+					return updateSchema(ctx, ice.MakeColumnOptional(ctx.Args().Get(1)))
+				},
+			},
+			{
+				Name:  "move-column",
+				Usage: "<location> <path>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "first"},
+					&cli.StringFlag{Name: "before", Usage: "name of the sibling column to move the column before"},
+					&cli.StringFlag{Name: "after", Usage: "name of the sibling column to move the column after"},
+				},
+				Action: func(ctx *cli.Context) error {
+					var path = ctx.Args().Get(1)
+
+					switch {
+					case ctx.Bool("first"):
+						return updateSchema(ctx, ice.MoveColumn(path, ice.ColumnPositionFirst, ""))
+					case ctx.IsSet("before"):
+						return updateSchema(ctx, ice.MoveColumn(path, ice.ColumnPositionBefore, ctx.String("before")))
+					case ctx.IsSet("after"):
+						return updateSchema(ctx, ice.MoveColumn(path, ice.ColumnPositionAfter, ctx.String("after")))
+					default:
+						return fmt.Errorf("one of --first, --before or --after is required")
+					}
+				},
+			},
+			{
+				Name:  "update-doc",
+				Usage: "<location> <path> <doc>",
+				Action: func(ctx *cli.Context) error {
+					return updateSchema(ctx, ice.UpdateColumnDoc(ctx.Args().Get(1), ctx.Args().Get(2)))
+				},
+			},
+		},
+	}
+}
+
+func updateSchema(ctx *cli.Context, change ice.SchemaChange) error {
+	var (
+		location = ctx.Args().Get(0)
+		sch      *iceberg.Schema
+	)
+
+	err := ice.DoCommit(ctx.Context, func() error {
+		var err error
+		sch, err = ice.UpdateSchema(ctx.Context, location, change)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(sch)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(js))
+	return nil
+}
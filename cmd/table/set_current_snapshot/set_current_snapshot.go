@@ -0,0 +1,48 @@
+package set_current_snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "set-current-snapshot",
+		Usage: "<location> <snapshot_id>",
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				change   *ice.CurrentSnapshotChange
+			)
+
+			snapshotID, err := strconv.ParseInt(ctx.Args().Get(1), 10, 64)
+
+			if err != nil {
+				return fmt.Errorf("invalid snapshot ID: %w", err)
+			}
+
+			err = ice.DoCommit(ctx.Context, func() error {
+				var err error
+				change, err = ice.SetCurrentSnapshot(ctx.Context, location, snapshotID)
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(change)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
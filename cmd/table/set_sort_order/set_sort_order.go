@@ -0,0 +1,44 @@
+package set_sort_order
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/apache/iceberg-go/table"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "set-sort-order",
+		Usage: "<location> [sort_order], e.g. \"block_number ASC NULLS LAST, day(ts) DESC\" (the table is unsorted when empty)",
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				s        = strings.Join(ctx.Args().Tail(), " ")
+				order    *table.SortOrder
+			)
+
+			err := ice.DoCommit(ctx.Context, func() error {
+				var err error
+				order, err = ice.SetSortOrder(ctx.Context, location, s)
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(order)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
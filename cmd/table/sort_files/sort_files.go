@@ -0,0 +1,49 @@
+package sort_files
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "sort-files",
+		Usage: "<location> [file...]",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{Name: "target-file-size-bytes", Usage: "defaults to the write.target-file-size-bytes table property"},
+			&cli.StringSliceFlag{Name: "prop"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.SortFilesConfig{
+					Files:               ctx.Args().Tail(),
+					TargetFileSizeBytes: ctx.Int64("target-file-size-bytes"),
+					Properties:          ice.ParseProperties(ctx.StringSlice("prop")),
+				}
+			)
+
+			res, err := ice.SortFiles(ctx.Context, location, conf)
+
+			if err != nil {
+				return err
+			}
+
+			for _, r := range res {
+				js, err := json.Marshal(r)
+
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(js))
+			}
+
+			return nil
+		},
+	}
+}
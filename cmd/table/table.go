@@ -1,11 +1,32 @@
 package table
 
 import (
+	"github.com/agnosticeng/icepq/cmd/table/check"
+	"github.com/agnosticeng/icepq/cmd/table/column_stats"
+	"github.com/agnosticeng/icepq/cmd/table/compact"
+	"github.com/agnosticeng/icepq/cmd/table/create_branch"
 	"github.com/agnosticeng/icepq/cmd/table/create_or_add_files"
+	"github.com/agnosticeng/icepq/cmd/table/create_tag"
+	"github.com/agnosticeng/icepq/cmd/table/diff"
+	"github.com/agnosticeng/icepq/cmd/table/drop_ref"
 	"github.com/agnosticeng/icepq/cmd/table/expire_snapshots"
+	"github.com/agnosticeng/icepq/cmd/table/fast_forward"
 	"github.com/agnosticeng/icepq/cmd/table/field_bound_values"
+	"github.com/agnosticeng/icepq/cmd/table/infer_schema"
+	"github.com/agnosticeng/icepq/cmd/table/inspect"
+	"github.com/agnosticeng/icepq/cmd/table/plan_compaction"
+	"github.com/agnosticeng/icepq/cmd/table/plan_files"
+	"github.com/agnosticeng/icepq/cmd/table/publish"
 	"github.com/agnosticeng/icepq/cmd/table/reachable_files"
+	"github.com/agnosticeng/icepq/cmd/table/remove_orphan_files"
+	"github.com/agnosticeng/icepq/cmd/table/repair_version_hint"
 	"github.com/agnosticeng/icepq/cmd/table/replace_files"
+	"github.com/agnosticeng/icepq/cmd/table/rollback"
+	"github.com/agnosticeng/icepq/cmd/table/schema"
+	"github.com/agnosticeng/icepq/cmd/table/set_current_snapshot"
+	"github.com/agnosticeng/icepq/cmd/table/set_sort_order"
+	"github.com/agnosticeng/icepq/cmd/table/sort_files"
+	"github.com/agnosticeng/icepq/cmd/table/update_partition_spec"
 	"github.com/urfave/cli/v2"
 )
 
@@ -17,7 +38,28 @@ func Command() *cli.Command {
 			replace_files.Command(),
 			reachable_files.Command(),
 			expire_snapshots.Command(),
+			remove_orphan_files.Command(),
 			field_bound_values.Command(),
+			repair_version_hint.Command(),
+			update_partition_spec.Command(),
+			schema.Command(),
+			infer_schema.Command(),
+			plan_files.Command(),
+			column_stats.Command(),
+			plan_compaction.Command(),
+			compact.Command(),
+			set_sort_order.Command(),
+			sort_files.Command(),
+			rollback.Command(),
+			set_current_snapshot.Command(),
+			create_branch.Command(),
+			create_tag.Command(),
+			drop_ref.Command(),
+			fast_forward.Command(),
+			publish.Command(),
+			inspect.Command(),
+			diff.Command(),
+			check.Command(),
 		},
 	}
 }
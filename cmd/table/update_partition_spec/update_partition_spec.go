@@ -0,0 +1,52 @@
+package update_partition_spec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/apache/iceberg-go"
+	"github.com/urfave/cli/v2"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "update-partition-spec",
+		Usage: "<location>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "add", Usage: "partition fields to add, e.g. \"day(ts), bucket(16, id)\""},
+			&cli.StringSliceFlag{Name: "remove", Usage: "name of a partition field to remove"},
+			&cli.StringSliceFlag{Name: "rename", Usage: "<name>=<new_name>"},
+		},
+		Action: func(ctx *cli.Context) error {
+			var (
+				location = ctx.Args().Get(0)
+				conf     = ice.UpdatePartitionSpecConfig{
+					Add:    ctx.String("add"),
+					Remove: ctx.StringSlice("remove"),
+					Rename: ice.ParseProperties(ctx.StringSlice("rename")),
+				}
+				spec *iceberg.PartitionSpec
+			)
+
+			err := ice.DoCommit(ctx.Context, func() error {
+				var err error
+				spec, err = ice.UpdatePartitionSpec(ctx.Context, location, conf)
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+
+			js, err := json.Marshal(spec)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(js))
+			return nil
+		},
+	}
+}
@@ -10,4 +10,5 @@ clickhouse --queries-file ./examples/queries/merge_files_02.sql
 ./bin/icepq table replace s3://test01/table01 4.parquet,5.parquet 11.parquet 
 clickhouse --queries-file ./examples/queries/merge_files_03.sql
 ./bin/icepq table replace s3://test01/table01 6.parquet,7.parquet 12.parquet 
+./bin/icepq table expire-snapshots --dry-run --retain-last=1 --older-than=1ms s3://test01/table01
 ./bin/icepq table expire-snapshots --retain-last=1 --older-than=1ms s3://test01/table01
\ No newline at end of file
@@ -0,0 +1,265 @@
+package iceberg
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	objstrerrs "github.com/agnosticeng/objstr/errors"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/sourcegraph/conc/pool"
+)
+
+const CheckTableConcurrencyDefault = 16
+
+type CheckTableConfig struct {
+	// AllSnapshots checks the files of every snapshot of the table, instead of the files
+	// of the current snapshot only.
+	AllSnapshots bool
+	// ValidateParquet opens the footer of Parquet data files and checks their record count.
+	ValidateParquet bool
+	// Concurrency is the maximum number of files checked concurrently, 16 by default.
+	Concurrency int
+}
+
+// CheckIssue describes a broken file. Content is manifest_list, manifest, data,
+// position_deletes or equality_deletes, and Problem is one of:
+//   - missing: the file does not exist
+//   - unreadable: the file metadata or content cannot be read
+//   - size_mismatch: the file size differs from the size recorded in the metadata
+//   - invalid_parquet: the Parquet footer cannot be read
+//   - record_count_mismatch: the Parquet record count differs from the recorded one
+type CheckIssue struct {
+	Location string `json:"location"`
+	Content  string `json:"content"`
+	Problem  string `json:"problem"`
+	Expected *int64 `json:"expected,omitempty"`
+	Actual   *int64 `json:"actual,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type CheckTableReport struct {
+	MetadataLocation     string       `json:"metadata_location"`
+	SnapshotIds          []int64      `json:"snapshot_ids"`
+	CheckedManifestLists int          `json:"checked_manifest_lists"`
+	CheckedManifests     int          `json:"checked_manifests"`
+	CheckedDataFiles     int          `json:"checked_data_files"`
+	CheckedDeleteFiles   int          `json:"checked_delete_files"`
+	Issues               []CheckIssue `json:"issues"`
+}
+
+// CheckTable verifies that the manifest lists, manifests, data and delete files reachable
+// from the table metadata exist and have the size recorded in the metadata. Manifest
+// lists have no recorded size, so only their existence is checked. The files of a missing
+// or unreadable manifest list or manifest are not checked.
+func CheckTable(ctx context.Context, tableLocation string, conf CheckTableConfig) (*CheckTableReport, error) {
+	if conf.Concurrency <= 0 {
+		conf.Concurrency = CheckTableConcurrencyDefault
+	}
+
+	var io = iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx))
+
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []table.Snapshot
+
+	if conf.AllSnapshots {
+		snapshots = t.Metadata().Snapshots()
+	} else if snap := t.CurrentSnapshot(); snap != nil {
+		snapshots = []table.Snapshot{*snap}
+	}
+
+	var (
+		report = CheckTableReport{
+			MetadataLocation: t.MetadataLocation(),
+			SnapshotIds:      []int64{},
+			Issues:           []CheckIssue{},
+		}
+		mut       sync.Mutex
+		manifests = map[string]iceberg.ManifestFile{}
+	)
+
+	var addIssue = func(issue CheckIssue) {
+		mut.Lock()
+		defer mut.Unlock()
+		report.Issues = append(report.Issues, issue)
+	}
+
+	// manifests are shared by snapshots, so they are deduplicated before being checked
+	for _, snap := range snapshots {
+		report.SnapshotIds = append(report.SnapshotIds, snap.SnapshotID)
+		report.CheckedManifestLists++
+
+		if issue := checkFile(ctx, snap.ManifestList, "manifest_list", -1); issue != nil {
+			addIssue(*issue)
+			continue
+		}
+
+		mans, err := snap.Manifests(io)
+
+		if err != nil {
+			addIssue(CheckIssue{Location: snap.ManifestList, Content: "manifest_list", Problem: "unreadable", Error: err.Error()})
+			continue
+		}
+
+		for _, man := range mans {
+			manifests[man.FilePath()] = man
+		}
+	}
+
+	var (
+		dataFiles = mapset.NewSet[string]()
+		p         = pool.New().WithErrors().WithContext(ctx).WithMaxGoroutines(conf.Concurrency)
+	)
+
+	report.CheckedManifests = len(manifests)
+
+	for _, man := range manifests {
+		p.Go(func(ctx context.Context) error {
+			if issue := checkFile(ctx, man.FilePath(), "manifest", man.Length()); issue != nil {
+				addIssue(*issue)
+				return nil
+			}
+
+			entries, err := man.FetchEntries(io, true)
+
+			if err != nil {
+				addIssue(CheckIssue{Location: man.FilePath(), Content: "manifest", Problem: "unreadable", Error: err.Error()})
+				return nil
+			}
+
+			for _, entry := range entries {
+				var df = entry.DataFile()
+
+				// a data file is referenced by the manifests of each snapshot it belongs to
+				if !dataFiles.Add(df.FilePath()) {
+					continue
+				}
+
+				mut.Lock()
+
+				if df.ContentType() == iceberg.EntryContentData {
+					report.CheckedDataFiles++
+				} else {
+					report.CheckedDeleteFiles++
+				}
+
+				mut.Unlock()
+
+				var content = entryContentName(df.ContentType())
+
+				if issue := checkFile(ctx, df.FilePath(), content, df.FileSizeBytes()); issue != nil {
+					addIssue(*issue)
+					continue
+				}
+
+				if conf.ValidateParquet && df.ContentType() == iceberg.EntryContentData && df.FileFormat() == iceberg.ParquetFile {
+					if issue := checkParquetFile(ctx, df, content); issue != nil {
+						addIssue(*issue)
+					}
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := p.Wait(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(report.Issues, func(a, b CheckIssue) int { return strings.Compare(a.Location, b.Location) })
+	return &report, nil
+}
+
+// checkFile checks that a file exists and, unless expectedSize is negative, that it has
+// the expected size.
+func checkFile(ctx context.Context, location string, content string, expectedSize int64) *CheckIssue {
+	var issue = CheckIssue{Location: location, Content: content}
+
+	u, err := url.Parse(location)
+
+	if err != nil {
+		issue.Problem, issue.Error = "unreadable", err.Error()
+		return &issue
+	}
+
+	md, err := objstr.FromContextOrDefault(ctx).ReadMetadata(ctx, u)
+
+	switch {
+	case errors.Is(err, objstrerrs.ErrObjectNotFound):
+		issue.Problem = "missing"
+		return &issue
+	case err != nil:
+		issue.Problem, issue.Error = "unreadable", err.Error()
+		return &issue
+	}
+
+	var size = int64(md.Size)
+
+	if expectedSize >= 0 && size != expectedSize {
+		issue.Problem, issue.Expected, issue.Actual = "size_mismatch", &expectedSize, &size
+		return &issue
+	}
+
+	return nil
+}
+
+func checkParquetFile(ctx context.Context, df iceberg.DataFile, content string) *CheckIssue {
+	var issue = CheckIssue{Location: df.FilePath(), Content: content}
+
+	u, err := url.Parse(df.FilePath())
+
+	if err != nil {
+		issue.Problem, issue.Error = "unreadable", err.Error()
+		return &issue
+	}
+
+	var numRows int64
+
+	err = withParquetFile(ctx, u, func(pqr *file.Reader, _ int64) error {
+		numRows = pqr.NumRows()
+		return nil
+	})
+
+	if err != nil {
+		issue.Problem, issue.Error = "invalid_parquet", err.Error()
+		return &issue
+	}
+
+	if expected := df.Count(); numRows != expected {
+		issue.Problem, issue.Expected, issue.Actual = "record_count_mismatch", &expected, &numRows
+		return &issue
+	}
+
+	return nil
+}
+
+func entryContentName(content iceberg.ManifestEntryContent) string {
+	switch content {
+	case iceberg.EntryContentPosDeletes:
+		return "position_deletes"
+	case iceberg.EntryContentEqDeletes:
+		return "equality_deletes"
+	default:
+		return "data"
+	}
+}
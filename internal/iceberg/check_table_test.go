@@ -0,0 +1,67 @@
+package iceberg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTable(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	var problems = func(conf CheckTableConfig) map[string]string {
+		report, err := CheckTable(ctx, "file://"+dir, conf)
+		require.NoError(t, err)
+
+		return lo.SliceToMap(report.Issues, func(issue CheckIssue) (string, string) {
+			return filepath.Base(issue.Location), issue.Problem
+		})
+	}
+
+	for _, name := range []string{"1.parquet", "2.parquet", "3.parquet", "4.parquet"} {
+		writeTestParquetFile(t, dir, name, []int64{1, 2}, []string{"a", "b"})
+	}
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet", "3.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, []string{"3.parquet"}, []string{"4.parquet"}, nil, ReplaceFilesConfig{}))
+
+	report, err := CheckTable(ctx, "file://"+dir, CheckTableConfig{ValidateParquet: true, AllSnapshots: true})
+	require.NoError(t, err)
+	require.Empty(t, report.Issues)
+	require.Len(t, report.SnapshotIds, 2)
+	require.Equal(t, 4, report.CheckedDataFiles)
+
+	var data = filepath.Join(dir, "data")
+
+	// a missing file, a truncated file and a corrupted file of the recorded size
+	require.NoError(t, os.Remove(filepath.Join(data, "1.parquet")))
+	require.NoError(t, os.Truncate(filepath.Join(data, "2.parquet"), 10))
+
+	info, err := os.Stat(filepath.Join(data, "3.parquet"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(data, "3.parquet"), make([]byte, info.Size()), 0644))
+
+	require.Equal(t, map[string]string{
+		"1.parquet": "missing",
+		"2.parquet": "size_mismatch",
+	}, problems(CheckTableConfig{ValidateParquet: true}))
+
+	require.Equal(t, map[string]string{
+		"1.parquet": "missing",
+		"2.parquet": "size_mismatch",
+		"3.parquet": "invalid_parquet",
+	}, problems(CheckTableConfig{ValidateParquet: true, AllSnapshots: true}))
+
+	require.Equal(t, map[string]string{
+		"1.parquet": "missing",
+		"2.parquet": "size_mismatch",
+	}, problems(CheckTableConfig{AllSnapshots: true}))
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"github.com/agnosticeng/cnf"
+	ice "github.com/agnosticeng/icepq/internal/iceberg"
+	"github.com/urfave/cli/v2"
+)
+
+func IcebergBefore(opts ...cnf.OptionFunc) func(ctx *cli.Context) error {
+	return func(ctx *cli.Context) error {
+		var cfg ice.Config
+
+		if err := cnf.Load(&cfg, opts...); err != nil {
+			return err
+		}
+
+		store, err := ice.NewVersionHintStore(ctx.Context, cfg.VersionHintStore)
+
+		if err != nil {
+			return err
+		}
+
+		ctx.Context = ice.NewVersionHintStoreContext(ctx.Context, store)
+		ctx.Context = ice.NewCommitRetryConfigContext(ctx.Context, cfg.CommitRetry)
+		return nil
+	}
+}
@@ -0,0 +1,296 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/samber/lo"
+)
+
+// ColumnStatsItem holds the statistics of a column recorded in manifest entries. Counts are
+// nil when the data files do not record them (e.g. with the none or counts metrics mode).
+type ColumnStatsItem struct {
+	FieldName  string `json:"field_name"`
+	FieldId    int    `json:"field_id"`
+	ValueCount *int64 `json:"value_count"`
+	NullCount  *int64 `json:"null_count"`
+	NanCount   *int64 `json:"nan_count"`
+	ColumnSize *int64 `json:"column_size"`
+	Lower      any    `json:"lower"`
+	Upper      any    `json:"upper"`
+}
+
+type ColumnStatsFile struct {
+	FilePath      string            `json:"file_path"`
+	RecordCount   int64             `json:"record_count"`
+	FileSizeBytes int64             `json:"file_size_bytes"`
+	Columns       []ColumnStatsItem `json:"columns"`
+}
+
+// ColumnStatsAggregate sums the counts of a column over the data files, and holds its
+// smallest lower bound and largest upper bound. FileCount is the number of data files
+// recording at least one statistic for the column.
+type ColumnStatsAggregate struct {
+	ColumnStatsItem
+	FileCount int `json:"file_count"`
+}
+
+type ColumnStatsReport struct {
+	SnapshotId    int64                  `json:"snapshot_id"`
+	FileCount     int                    `json:"file_count"`
+	RecordCount   int64                  `json:"record_count"`
+	FileSizeBytes int64                  `json:"file_size_bytes"`
+	Columns       []ColumnStatsAggregate `json:"columns"`
+	Files         []ColumnStatsFile      `json:"files"`
+}
+
+type ColumnStatsConfig struct {
+	// Columns restricts the report to these columns. Nested columns are addressed by their
+	// dot-separated path; all the primitive columns are reported when empty.
+	Columns []string
+}
+
+// ColumnStats reports the statistics of the columns for each data file of the current
+// snapshot, along with table-level aggregates.
+func ColumnStats(ctx context.Context, tableLocation string, conf ColumnStatsConfig) (*ColumnStatsReport, error) {
+	var (
+		os = objstr.FromContextOrDefault(ctx)
+		io = io.NewObjectStoreIO(os)
+	)
+
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := statsFields(t.Schema(), conf.Columns)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		snap   = t.CurrentSnapshot()
+		report = ColumnStatsReport{Files: []ColumnStatsFile{}}
+	)
+
+	if snap == nil {
+		report.Columns = lo.Map(fields, func(field iceberg.NestedField, _ int) ColumnStatsAggregate {
+			return ColumnStatsAggregate{ColumnStatsItem: ColumnStatsItem{FieldName: field.Name, FieldId: field.ID}}
+		})
+
+		return &report, nil
+	}
+
+	report.SnapshotId = snap.SnapshotID
+
+	dataFiles, err := SnapshotDataFiles(io, snap)
+
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(dataFiles, func(a, b iceberg.DataFile) int { return strings.Compare(a.FilePath(), b.FilePath()) })
+
+	var aggs = lo.Map(fields, func(field iceberg.NestedField, _ int) *columnStatsAccumulator {
+		return &columnStatsAccumulator{field: field}
+	})
+
+	for _, df := range dataFiles {
+		var item = ColumnStatsFile{
+			FilePath:      df.FilePath(),
+			RecordCount:   df.Count(),
+			FileSizeBytes: df.FileSizeBytes(),
+		}
+
+		for i, field := range fields {
+			stats, lower, upper, err := dataFileColumnStats(df, field)
+
+			if err != nil {
+				return nil, err
+			}
+
+			item.Columns = append(item.Columns, stats)
+			aggs[i].add(stats, lower, upper)
+		}
+
+		report.FileCount++
+		report.RecordCount += df.Count()
+		report.FileSizeBytes += df.FileSizeBytes()
+		report.Files = append(report.Files, item)
+	}
+
+	report.Columns = lo.Map(aggs, func(acc *columnStatsAccumulator, _ int) ColumnStatsAggregate {
+		return acc.result()
+	})
+
+	return &report, nil
+}
+
+// statsFields returns the primitive columns of the schema, ordered by field ID, or the
+// given columns in order.
+func statsFields(sch *iceberg.Schema, columns []string) ([]iceberg.NestedField, error) {
+	var res []iceberg.NestedField
+
+	if len(columns) == 0 {
+		names, err := iceberg.IndexNameByID(sch)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var ids = lo.Keys(names)
+		slices.Sort(ids)
+
+		for _, id := range ids {
+			var field, _ = sch.FindFieldByID(id)
+
+			if _, ok := field.Type.(iceberg.PrimitiveType); ok {
+				field.Name = names[id]
+				res = append(res, field)
+			}
+		}
+
+		return res, nil
+	}
+
+	for _, col := range columns {
+		field, found := sch.FindFieldByName(col)
+
+		if !found {
+			return nil, fmt.Errorf("field %s not found", col)
+		}
+
+		if _, ok := field.Type.(iceberg.PrimitiveType); !ok {
+			return nil, fmt.Errorf("field %s is not a primitive column", col)
+		}
+
+		field.Name = col
+		res = append(res, field)
+	}
+
+	return res, nil
+}
+
+// dataFileColumnStats returns the statistics of a column for a data file, along with its
+// undecoded bounds.
+func dataFileColumnStats(df iceberg.DataFile, field iceberg.NestedField) (ColumnStatsItem, iceberg.Literal, iceberg.Literal, error) {
+	var (
+		stats = ColumnStatsItem{
+			FieldName:  field.Name,
+			FieldId:    field.ID,
+			ValueCount: statValue(df.ValueCounts(), field.ID),
+			NullCount:  statValue(df.NullValueCounts(), field.ID),
+			NanCount:   statValue(df.NaNValueCounts(), field.ID),
+			ColumnSize: statValue(df.ColumnSizes(), field.ID),
+		}
+		bounds [2]iceberg.Literal
+	)
+
+	for i, values := range []map[int][]byte{df.LowerBoundValues(), df.UpperBoundValues()} {
+		v, found := values[field.ID]
+
+		if !found {
+			continue
+		}
+
+		lit, err := boundLiteralFromBytes(field.Type, v)
+
+		if err != nil {
+			return ColumnStatsItem{}, nil, nil, fmt.Errorf("cannot decode bound value of field %s (%s) in datafile %s: %w", field.Name, field.Type, df.FilePath(), err)
+		}
+
+		bounds[i] = lit
+	}
+
+	if bounds[0] != nil {
+		stats.Lower = jsonLiteralValue(bounds[0], field.Type)
+	}
+
+	if bounds[1] != nil {
+		stats.Upper = jsonLiteralValue(bounds[1], field.Type)
+	}
+
+	return stats, bounds[0], bounds[1], nil
+}
+
+func statValue(values map[int]int64, id int) *int64 {
+	if v, found := values[id]; found {
+		return &v
+	}
+
+	return nil
+}
+
+// addStatValue adds v to the sum, unless v is nil. It reports whether v was added.
+func addStatValue(sum **int64, v *int64) bool {
+	if v == nil {
+		return false
+	}
+
+	if *sum == nil {
+		*sum = new(int64)
+	}
+
+	**sum += *v
+	return true
+}
+
+type columnStatsAccumulator struct {
+	field     iceberg.NestedField
+	res       ColumnStatsAggregate
+	lower     iceberg.Literal
+	upper     iceberg.Literal
+	fileCount int
+}
+
+func (acc *columnStatsAccumulator) add(stats ColumnStatsItem, lower iceberg.Literal, upper iceberg.Literal) {
+	var found = lo.Count([]bool{
+		addStatValue(&acc.res.ValueCount, stats.ValueCount),
+		addStatValue(&acc.res.NullCount, stats.NullCount),
+		addStatValue(&acc.res.NanCount, stats.NanCount),
+		addStatValue(&acc.res.ColumnSize, stats.ColumnSize),
+	}, true) > 0
+
+	if lower != nil && (acc.lower == nil || compareLiterals(lower, acc.lower) < 0) {
+		acc.lower = lower
+	}
+
+	if upper != nil && (acc.upper == nil || compareLiterals(upper, acc.upper) > 0) {
+		acc.upper = upper
+	}
+
+	if found || lower != nil || upper != nil {
+		acc.fileCount++
+	}
+}
+
+func (acc *columnStatsAccumulator) result() ColumnStatsAggregate {
+	var res = acc.res
+
+	res.FieldName = acc.field.Name
+	res.FieldId = acc.field.ID
+	res.FileCount = acc.fileCount
+
+	if acc.lower != nil {
+		res.Lower = jsonLiteralValue(acc.lower, acc.field.Type)
+	}
+
+	if acc.upper != nil {
+		res.Upper = jsonLiteralValue(acc.upper, acc.field.Type)
+	}
+
+	return res
+}
@@ -0,0 +1,65 @@
+package iceberg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnStats(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+		sch = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "score", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		}, nil)
+	)
+
+	writeTestParquetJSON(t, dir, "1.parquet", sch, `[{"id": 1, "score": 1.5}, {"id": 5, "score": null}]`)
+	writeTestParquetJSON(t, dir, "2.parquet", sch, `[{"id": 3, "score": -2}, {"id": 9, "score": 4}, {"id": 7, "score": null}]`)
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	report, err := ColumnStats(ctx, "file://"+dir, ColumnStatsConfig{})
+	require.NoError(t, err)
+
+	require.Equal(t, 2, report.FileCount)
+	require.Equal(t, int64(5), report.RecordCount)
+	require.Equal(t, []string{"1.parquet", "2.parquet"}, lo.Map(report.Files, func(f ColumnStatsFile, _ int) string {
+		return filepath.Base(f.FilePath)
+	}))
+
+	var score = report.Files[1].Columns[1]
+	require.Equal(t, "score", score.FieldName)
+	require.Equal(t, int64(3), *score.ValueCount)
+	require.Equal(t, int64(1), *score.NullCount)
+	require.Equal(t, -2.0, score.Lower)
+	require.Equal(t, 4.0, score.Upper)
+	require.NotNil(t, score.ColumnSize)
+
+	require.Len(t, report.Columns, 2)
+	var id, scoreAgg = report.Columns[0], report.Columns[1]
+	require.Equal(t, "id", id.FieldName)
+	require.Equal(t, int64(1), id.Lower)
+	require.Equal(t, int64(9), id.Upper)
+	require.Equal(t, int64(5), *id.ValueCount)
+	require.Equal(t, int64(0), *id.NullCount)
+	require.Equal(t, 2, id.FileCount)
+	require.Equal(t, int64(2), *scoreAgg.NullCount)
+	require.Equal(t, -2.0, scoreAgg.Lower)
+	require.Equal(t, 4.0, scoreAgg.Upper)
+
+	report, err = ColumnStats(ctx, "file://"+dir, ColumnStatsConfig{Columns: []string{"score"}})
+	require.NoError(t, err)
+	require.Len(t, report.Columns, 1)
+	require.Len(t, report.Files[0].Columns, 1)
+
+	_, err = ColumnStats(ctx, "file://"+dir, ColumnStatsConfig{Columns: []string{"unknown"}})
+	require.ErrorContains(t, err, "field unknown not found")
+}
@@ -0,0 +1,90 @@
+package iceberg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+var ErrCommitConflict = errors.New("commit conflict")
+
+// CommitConflictError is returned when a pending operation can no longer be applied
+// on top of the latest table metadata. Retrying it is pointless.
+type CommitConflictError struct {
+	Operation string
+	Reason    string
+}
+
+func (e *CommitConflictError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrCommitConflict, e.Operation, e.Reason)
+}
+
+func (e *CommitConflictError) Is(target error) bool {
+	return target == ErrCommitConflict
+}
+
+type CommitRetryConfig struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+type commitRetryConfigContextKey struct{}
+
+func NewCommitRetryConfigContext(ctx context.Context, conf CommitRetryConfig) context.Context {
+	return context.WithValue(ctx, commitRetryConfigContextKey{}, conf)
+}
+
+func CommitRetryConfigFromContextOrDefault(ctx context.Context) CommitRetryConfig {
+	conf, _ := ctx.Value(commitRetryConfigContextKey{}).(CommitRetryConfig)
+
+	if conf.MaxAttempts == 0 {
+		conf.MaxAttempts = 10
+	}
+
+	if conf.MinBackoff == 0 {
+		conf.MinBackoff = time.Millisecond * 100
+	}
+
+	if conf.MaxBackoff == 0 {
+		conf.MaxBackoff = time.Second * 10
+	}
+
+	return conf
+}
+
+// DoCommit runs f until it succeeds or fails with something else than ErrConsistencyViolation.
+// f is expected to reload the table and re-validate its operation on each attempt.
+// Attempts are spaced by an exponential backoff with full jitter.
+func DoCommit(ctx context.Context, f func() error) error {
+	var conf = CommitRetryConfigFromContextOrDefault(ctx)
+
+	for attempt := 0; ; attempt++ {
+		var err = f()
+
+		if !errors.Is(err, ErrConsistencyViolation) {
+			return err
+		}
+
+		if attempt+1 >= conf.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt+1, err)
+		}
+
+		if err := sleepContext(ctx, commitBackoff(conf, attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+func commitBackoff(conf CommitRetryConfig, attempt int) time.Duration {
+	var d = conf.MinBackoff
+
+	for i := 0; i < attempt && d < conf.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	d = min(d, conf.MaxBackoff)
+	return time.Duration(rand.Int64N(int64(d) + 1))
+}
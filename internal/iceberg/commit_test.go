@@ -0,0 +1,115 @@
+package iceberg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go/table"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoCommit(t *testing.T) {
+	var ctx = NewCommitRetryConfigContext(context.Background(), CommitRetryConfig{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  time.Millisecond * 5,
+	})
+
+	t.Run("retries consistency violations", func(t *testing.T) {
+		var attempts int
+
+		require.NoError(t, DoCommit(ctx, func() error {
+			if attempts++; attempts < 3 {
+				return ErrConsistencyViolation
+			}
+
+			return nil
+		}))
+
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		var attempts int
+
+		err := DoCommit(ctx, func() error {
+			attempts++
+			return ErrConsistencyViolation
+		})
+
+		require.ErrorIs(t, err, ErrConsistencyViolation)
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("does not retry conflicts", func(t *testing.T) {
+		var attempts int
+
+		err := DoCommit(ctx, func() error {
+			attempts++
+			return &CommitConflictError{Operation: "replace", Reason: "gone"}
+		})
+
+		require.ErrorIs(t, err, ErrCommitConflict)
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("honours context cancellation", func(t *testing.T) {
+		var cancelCtx, cancel = context.WithCancel(ctx)
+		cancel()
+
+		err := DoCommit(cancelCtx, func() error { return ErrConsistencyViolation })
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// failingCatalog rejects every commit with err.
+type failingCatalog struct {
+	*VersionHintCatalog
+	err error
+}
+
+func (cat failingCatalog) CommitTable(context.Context, *table.Table, []table.Requirement, []table.Update) (table.Metadata, string, error) {
+	return nil, "", cat.err
+}
+
+func TestCommitSnapshotCleanup(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	var metadataFiles = func() int {
+		entries, err := os.ReadDir(filepath.Join(dir, "metadata"))
+		require.NoError(t, err)
+		return len(entries)
+	}
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1}, []string{"a"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+
+	var (
+		before = metadataFiles()
+		su     = SnapshotUpdate{Deleted: []string{"file://" + filepath.Join(dir, "data", "1.parquet")}}
+	)
+
+	// rejected commits never reference the written files
+	_, err = CommitSnapshot(ctx, failingCatalog{cat, ErrConsistencyViolation}, tbl, su)
+	require.ErrorIs(t, err, ErrConsistencyViolation)
+	require.Equal(t, before, metadataFiles())
+
+	// the snapshot of an ambiguous failure may have been committed
+	_, err = CommitSnapshot(ctx, failingCatalog{cat, errors.New("timeout")}, tbl, su)
+	require.ErrorContains(t, err, "timeout")
+	require.Greater(t, metadataFiles(), before)
+}
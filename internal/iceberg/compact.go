@@ -0,0 +1,534 @@
+package iceberg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdio "io"
+	"math"
+	"net/url"
+	"path"
+	"strings"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+)
+
+type CompactConfig struct {
+	// Files are the data files to compact, relative to the data directory of the table.
+	// They are grouped by partition. When empty, the groups are planned by PlanCompaction.
+	Files []string
+	Plan  PlanCompactionConfig
+	// Sort sorts the rows of each group by the table sort order. Rows are sorted in memory
+	// in runs of about the target file size, spilled to temporary files when a group does
+	// not fit in a run, then merged.
+	Sort bool
+	// Properties are added to the snapshot summary.
+	Properties iceberg.Properties
+}
+
+type CompactResult struct {
+	InputFiles  []string `json:"input_files"`
+	OutputFiles []string `json:"output_files"`
+	RecordCount int64    `json:"record_count"`
+}
+
+// Compact merges data files into Parquet files of about the target file size, written
+// under the data directory with the current table schema and field IDs, then replaces
+// them in a single snapshot. Rows of files written with an older schema are projected to
+// the current one.
+func Compact(ctx context.Context, tableLocation string, conf CompactConfig) ([]CompactResult, error) {
+	location, err := url.Parse(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cat, err := NewVersionHintCatalog(location.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		os     = objstr.FromContextOrDefault(ctx)
+		osio   = iceio.NewObjectStoreIO(os)
+		target = conf.Plan.TargetFileSizeBytes
+	)
+
+	if target <= 0 {
+		target = int64(t.Properties().GetInt(table.WriteTargetFileSizeBytesKey, table.WriteTargetFileSizeBytesDefault))
+	}
+
+	if conf.Sort && len(t.SortOrder().Fields) == 0 {
+		return nil, fmt.Errorf("table has no sort order")
+	}
+
+	if snap := t.CurrentSnapshot(); snap != nil {
+		mans, err := snap.Manifests(osio)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// merging files would drop the rows deleted from them
+		if lo.SomeBy(mans, func(man iceberg.ManifestFile) bool { return man.ManifestContent() != iceberg.ManifestContentData }) {
+			return nil, fmt.Errorf("snapshot has delete files")
+		}
+	}
+
+	dataFiles, err := SnapshotDataFiles(osio, t.CurrentSnapshot())
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dataPrefix  = location.JoinPath("data").String() + "/"
+		filesByPath = lo.KeyBy(dataFiles, func(df iceberg.DataFile) string {
+			return strings.TrimPrefix(df.FilePath(), dataPrefix)
+		})
+		groups []CompactionGroup
+	)
+
+	if len(conf.Files) > 0 {
+		groups, err = compactionGroupsOf(t, filesByPath, conf.Files)
+	} else {
+		groups, err = PlanCompaction(ctx, location.String(), conf.Plan)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	writerProps, err := parquetWriterProperties(t.Properties())
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		res     []CompactResult
+		written []string
+	)
+
+	// the files written for this compaction are removed unless it is committed
+	removeWritten := func() {
+		for _, p := range written {
+			_ = osio.Remove(location.JoinPath("data", p).String())
+		}
+	}
+
+	for _, group := range groups {
+		var inputs = make([]iceberg.DataFile, 0, len(group.InputFiles))
+
+		for _, p := range group.InputFiles {
+			df, found := filesByPath[p]
+
+			if !found {
+				removeWritten()
+				return nil, fmt.Errorf("data file %s is not part of the current snapshot", p)
+			}
+
+			inputs = append(inputs, df)
+		}
+
+		outputs, err := compactGroup(ctx, t, location, inputs, group.OutputFile, target, conf.Sort, writerProps, func(p string) {
+			written = append(written, p)
+		})
+
+		if err != nil {
+			removeWritten()
+			return nil, err
+		}
+
+		res = append(res, CompactResult{
+			InputFiles:  group.InputFiles,
+			OutputFiles: outputs,
+			RecordCount: group.RecordCount,
+		})
+	}
+
+	if len(res) == 0 {
+		return []CompactResult{}, nil
+	}
+
+	err = DoCommit(ctx, func() error {
+		return ReplaceFiles(
+			ctx,
+			location.String(),
+			lo.FlatMap(res, func(r CompactResult, _ int) []string { return r.InputFiles }),
+			lo.FlatMap(res, func(r CompactResult, _ int) []string { return r.OutputFiles }),
+			conf.Properties,
+			ReplaceFilesConfig{},
+		)
+	})
+
+	if err != nil {
+		removeWritten()
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// compactionGroupsOf groups the given data files by partition.
+func compactionGroupsOf(t *table.Table, filesByPath map[string]iceberg.DataFile, files []string) ([]CompactionGroup, error) {
+	var (
+		groups = make(map[string]*CompactionGroup)
+		keys   []string
+	)
+
+	for _, p := range files {
+		df, found := filesByPath[p]
+
+		if !found {
+			return nil, fmt.Errorf("data file %s is not part of the current snapshot", p)
+		}
+
+		var key = fmt.Sprint(df.SpecID(), df.Partition())
+
+		if _, found := groups[key]; !found {
+			dir, partition, err := partitionPath(t.Metadata(), t.Schema(), df)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if len(partition) == 0 {
+				partition = nil
+			}
+
+			groups[key] = &CompactionGroup{
+				Partition:  partition,
+				OutputFile: path.Join(dir, fmt.Sprintf("compacted-%s.parquet", uuid.NewString())),
+			}
+			keys = append(keys, key)
+		}
+
+		var g = groups[key]
+		g.InputFiles = append(g.InputFiles, p)
+		g.InputSizeBytes += df.FileSizeBytes()
+		g.RecordCount += df.Count()
+	}
+
+	return lo.Map(keys, func(key string, _ int) CompactionGroup { return *groups[key] }), nil
+}
+
+// compactGroup merges the rows of the input files into one or more output files, named
+// after outputFile, and returns their paths relative to the data directory. Rows are
+// streamed from the input files to the output files, unless they are sorted: the rows
+// are then sorted in runs of about the target file size, spilled to temporary files and
+// merged (see sortRecords).
+func compactGroup(
+	ctx context.Context,
+	t *table.Table,
+	location *url.URL,
+	inputs []iceberg.DataFile,
+	outputFile string,
+	target int64,
+	sorted bool,
+	writerProps *parquet.WriterProperties,
+	onWrite func(string),
+) ([]string, error) {
+	var (
+		sch       = t.Schema()
+		mapping   = t.NameMapping()
+		inputSize = lo.SumBy(inputs, iceberg.DataFile.FileSizeBytes)
+		rowCount  = lo.SumBy(inputs, iceberg.DataFile.Count)
+	)
+
+	if mapping == nil {
+		mapping = sch.NameMapping()
+	}
+
+	arrowSch, err := table.SchemaToArrowSchema(sch, nil, true, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var w = rollingParquetWriter{
+		osio:         iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx)),
+		location:     location,
+		outputFile:   outputFile,
+		sch:          arrowSch,
+		props:        writerProps,
+		rowsPerFile:  math.MaxInt64,
+		rowGroupSize: int64(t.Properties().GetInt(table.ParquetRowGroupSizeBytesKey, table.ParquetRowGroupSizeBytesDefault)),
+		onWrite:      onWrite,
+	}
+
+	// the output is split using the average size of a row in the input files
+	if inputSize > target && rowCount > 0 {
+		w.rowsPerFile = max(1, target*rowCount/inputSize)
+	}
+
+	var read = func(fn func(arrow.Record) error) error {
+		for _, df := range inputs {
+			u, err := url.Parse(df.FilePath())
+
+			if err != nil {
+				return err
+			}
+
+			if err := readDataFileRecords(ctx, u, sch, mapping, fn); err != nil {
+				return fmt.Errorf("cannot read data file %s: %w", df.FilePath(), err)
+			}
+		}
+
+		return nil
+	}
+
+	if sorted {
+		err = sortRecords(ctx, arrowSch, sch, t.SortOrder(), target, read, w.write)
+	} else {
+		err = read(w.write)
+	}
+
+	if err != nil {
+		w.abort()
+		return nil, err
+	}
+
+	return w.close()
+}
+
+// readDataFileRecords passes the rows of a Parquet data file projected to the table schema
+// to fn, batch by batch. Records are released once fn returns. Columns of files without
+// field IDs are resolved through the name mapping.
+func readDataFileRecords(ctx context.Context, u *url.URL, sch *iceberg.Schema, mapping iceberg.NameMapping, fn func(arrow.Record) error) error {
+	return withParquetFile(ctx, u, func(pqr *file.Reader, _ int64) error {
+		fr, err := pqarrow.NewFileReader(pqr, pqarrow.ArrowReadProperties{BatchSize: 64 * 1024}, memory.DefaultAllocator)
+
+		if err != nil {
+			return err
+		}
+
+		arrowSch, err := fr.Schema()
+
+		if err != nil {
+			return err
+		}
+
+		fileSch, err := table.ArrowSchemaToIceberg(arrowSch, true, mapping)
+
+		if err != nil {
+			return err
+		}
+
+		rr, err := fr.GetRecordReader(ctx, nil, nil)
+
+		if err != nil {
+			return err
+		}
+
+		defer rr.Release()
+
+		for rr.Next() {
+			rec, err := table.ToRequestedSchema(ctx, sch, fileSch, rr.Record(), true, true, false)
+
+			if err != nil {
+				return err
+			}
+
+			err = fn(rec)
+			rec.Release()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		// the record reader reports io.EOF once all the row groups are read
+		if err := rr.Err(); err != nil && !errors.Is(err, stdio.EOF) {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// rollingParquetWriter writes records to Parquet files of at most rowsPerFile rows: the
+// first one is written at outputFile, the next ones alongside it. Row groups are cut once
+// they reach rowGroupSize compressed bytes.
+type rollingParquetWriter struct {
+	osio         *iceio.ObjectStoreIO
+	location     *url.URL
+	outputFile   string
+	sch          *arrow.Schema
+	props        *parquet.WriterProperties
+	rowsPerFile  int64
+	rowGroupSize int64
+	onWrite      func(string)
+	w            *pqarrow.FileWriter
+	rows         int64
+	outputs      []string
+}
+
+func (rw *rollingParquetWriter) write(rec arrow.Record) error {
+	for start := int64(0); start < rec.NumRows(); {
+		if rw.w == nil || rw.rows >= rw.rowsPerFile {
+			if err := rw.next(); err != nil {
+				return err
+			}
+		}
+
+		var (
+			end   = min(rec.NumRows(), start+rw.rowsPerFile-rw.rows)
+			slice = rec.NewSlice(start, end)
+		)
+
+		err := rw.w.WriteBuffered(slice)
+		slice.Release()
+
+		if err != nil {
+			return err
+		}
+
+		rw.rows += end - start
+		start = end
+
+		if rw.w.RowGroupTotalCompressedBytes() >= rw.rowGroupSize {
+			rw.w.NewBufferedRowGroup()
+		}
+	}
+
+	return nil
+}
+
+// next closes the current output file and opens the next one.
+func (rw *rollingParquetWriter) next() error {
+	if err := rw.closeFile(); err != nil {
+		return err
+	}
+
+	var p = rw.outputFile
+
+	if len(rw.outputs) > 0 {
+		p = path.Join(path.Dir(rw.outputFile), fmt.Sprintf("compacted-%s.parquet", uuid.NewString()))
+	}
+
+	rw.onWrite(p)
+
+	out, err := rw.osio.Create(rw.location.JoinPath("data", p).String())
+
+	if err != nil {
+		return err
+	}
+
+	w, err := pqarrow.NewFileWriter(rw.sch, out, rw.props, pqarrow.DefaultWriterProps())
+
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	rw.w, rw.rows = w, 0
+	rw.outputs = append(rw.outputs, p)
+	return nil
+}
+
+func (rw *rollingParquetWriter) closeFile() error {
+	if rw.w == nil {
+		return nil
+	}
+
+	var w = rw.w
+	rw.w = nil
+
+	// closing the writer closes the output file as well
+	return w.Close()
+}
+
+// close closes the current output file and returns the paths of the output files. An
+// output file is written even when there is no row.
+func (rw *rollingParquetWriter) close() ([]string, error) {
+	if len(rw.outputs) == 0 {
+		if err := rw.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rw.closeFile(); err != nil {
+		return nil, err
+	}
+
+	return rw.outputs, nil
+}
+
+// abort closes the current output file, which is removed along with the other written
+// files by the caller.
+func (rw *rollingParquetWriter) abort() {
+	_ = rw.closeFile()
+}
+
+// concatRecords concatenates records sharing the given schema into a single record.
+func concatRecords(sch *arrow.Schema, recs []arrow.Record) (arrow.Record, error) {
+	var cols = make([]arrow.Array, sch.NumFields())
+
+	defer func() {
+		for _, col := range cols {
+			if col != nil {
+				col.Release()
+			}
+		}
+	}()
+
+	for i := range cols {
+		var (
+			chunks = lo.Map(recs, func(rec arrow.Record, _ int) arrow.Array { return rec.Column(i) })
+			err    error
+		)
+
+		if len(chunks) == 0 {
+			cols[i] = array.MakeArrayOfNull(memory.DefaultAllocator, sch.Field(i).Type, 0)
+			continue
+		}
+
+		if cols[i], err = array.Concatenate(chunks, memory.DefaultAllocator); err != nil {
+			return nil, err
+		}
+	}
+
+	var rows int64
+
+	if len(cols) > 0 {
+		rows = int64(cols[0].Len())
+	}
+
+	return array.NewRecord(sch, cols, rows), nil
+}
+
+// parquetWriterProperties returns the Parquet writer properties configured by the
+// write.parquet.* table properties.
+func parquetWriterProperties(props iceberg.Properties) (*parquet.WriterProperties, error) {
+	var codec compress.Compression
+
+	if err := codec.UnmarshalText([]byte(strings.ToUpper(props.Get(table.ParquetCompressionKey, table.ParquetCompressionDefault)))); err != nil {
+		return nil, fmt.Errorf("invalid %s table property: %w", table.ParquetCompressionKey, err)
+	}
+
+	return parquet.NewWriterProperties(
+		parquet.WithCompression(codec),
+		parquet.WithCompressionLevel(props.GetInt(table.ParquetCompressionLevelKey, table.ParquetCompressionLevelDefault)),
+		parquet.WithStats(true),
+	), nil
+}
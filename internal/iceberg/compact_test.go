@@ -0,0 +1,252 @@
+package iceberg
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompact(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "category=a/1.parquet", []int64{20, 29}, []string{"a", "a"})
+	writeTestParquetFile(t, dir, "category=a/2.parquet", []int64{0, 9}, []string{"a", "a"})
+	writeTestParquetFile(t, dir, "category=b/3.parquet", []int64{30, 39}, []string{"b", "b"})
+	writeTestParquetFile(t, dir, "category=b/4.parquet", []int64{40}, []string{"b"})
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{
+		"category=a/1.parquet",
+		"category=a/2.parquet",
+		"category=b/3.parquet",
+		"category=b/4.parquet",
+	}, nil, CreateOrAddFilesConfig{PartitionBy: "category"}))
+
+	_, err := Compact(ctx, "file://"+dir, CompactConfig{Sort: true})
+	require.ErrorContains(t, err, "table has no sort order")
+
+	_, err = Compact(ctx, "file://"+dir, CompactConfig{Files: []string{"category=a/unknown.parquet"}})
+	require.ErrorContains(t, err, "is not part of the current snapshot")
+
+	res, err := Compact(ctx, "file://"+dir, CompactConfig{
+		Plan: PlanCompactionConfig{TargetFileSizeBytes: 1 << 20},
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	var outputs []string
+
+	for _, r := range res {
+		require.Len(t, r.OutputFiles, 1)
+		require.Equal(t, strings.SplitN(r.InputFiles[0], "/", 2)[0], strings.SplitN(r.OutputFiles[0], "/", 2)[0])
+		outputs = append(outputs, r.OutputFiles...)
+	}
+
+	require.Equal(t, int64(7), res[0].RecordCount+res[1].RecordCount)
+
+	dataFiles := currentTestDataFiles(t, ctx, dir)
+	require.Len(t, dataFiles, 2)
+
+	for _, df := range dataFiles {
+		var relPath = strings.TrimPrefix(df.FilePath(), "file://"+dir+"/data/")
+		require.Contains(t, outputs, relPath)
+		require.Len(t, df.Partition(), 1)
+	}
+
+	// compacting again leaves a single file per partition, nothing to do
+	res, err = Compact(ctx, "file://"+dir, CompactConfig{
+		Plan: PlanCompactionConfig{TargetFileSizeBytes: 1 << 20},
+	})
+	require.NoError(t, err)
+	require.Empty(t, res)
+}
+
+func TestCompactSplitsOutput(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1, 2, 3, 4}, []string{"a", "b", "c", "d"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{5, 6, 7, 8}, []string{"e", "f", "g", "h"})
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	info, err := os.Stat(filepath.Join(dir, "data", "1.parquet"))
+	require.NoError(t, err)
+
+	// the target size of a file holds half of the rows
+	res, err := Compact(ctx, "file://"+dir, CompactConfig{
+		Files: []string{"1.parquet", "2.parquet"},
+		Plan:  PlanCompactionConfig{TargetFileSizeBytes: info.Size()},
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0].OutputFiles, 2)
+
+	dataFiles := currentTestDataFiles(t, ctx, dir)
+	require.Len(t, dataFiles, 2)
+	require.Equal(t, int64(8), dataFiles[0].Count()+dataFiles[1].Count())
+}
+
+func TestCompactEvolvedSchema(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+		v1  = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int32},
+		}, nil)
+		v2 = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "extra", Type: arrow.BinaryTypes.String, Nullable: true},
+		}, nil)
+	)
+
+	writeTestParquetJSON(t, dir, "1.parquet", v1, `[{"id": 1}]`)
+	writeTestParquetJSON(t, dir, "2.parquet", v2, `[{"id": 2, "extra": "x"}]`)
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{EvolveSchema: true}))
+
+	res, err := Compact(ctx, "file://"+dir, CompactConfig{Files: []string{"1.parquet", "2.parquet"}})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0].OutputFiles, 1)
+
+	sch, err := SchemaFromParquetFile(ctx, &url.URL{Scheme: "file", Path: filepath.Join(dir, "data", res[0].OutputFiles[0])})
+	require.NoError(t, err)
+
+	id, found := sch.FindFieldByName("id")
+	require.True(t, found)
+	require.Equal(t, iceberg.PrimitiveTypes.Int64, id.Type)
+
+	extra, found := sch.FindFieldByName("extra")
+	require.True(t, found)
+	require.Equal(t, 2, extra.ID)
+
+	dataFiles := currentTestDataFiles(t, ctx, dir)
+	require.Len(t, dataFiles, 1)
+	require.Equal(t, int64(2), dataFiles[0].Count())
+	require.Equal(t, int64(1), dataFiles[0].NullValueCounts()[extra.ID])
+}
+
+func TestSortIndices(t *testing.T) {
+	var sch = iceberg.NewSchema(0,
+		iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64, Required: true},
+		iceberg.NestedField{ID: 2, Name: "category", Type: iceberg.PrimitiveTypes.String},
+	)
+
+	arrowSch, err := table.SchemaToArrowSchema(sch, nil, false, false)
+	require.NoError(t, err)
+
+	rec, _, err := array.RecordFromJSON(memory.DefaultAllocator, arrowSch, strings.NewReader(`[
+		{"id": 1, "category": "b"},
+		{"id": 2, "category": null},
+		{"id": 3, "category": "a"},
+		{"id": 4, "category": "b"},
+		{"id": 5, "category": "a"}
+	]`))
+	require.NoError(t, err)
+	defer rec.Release()
+
+	var order = table.SortOrder{OrderID: 1, Fields: []table.SortField{
+		{SourceID: 2, Transform: iceberg.IdentityTransform{}, Direction: table.SortASC, NullOrder: table.NullsLast},
+		{SourceID: 1, Transform: iceberg.IdentityTransform{}, Direction: table.SortDESC, NullOrder: table.NullsFirst},
+	}}
+
+	indices, err := sortIndices(rec, sch, order)
+	require.NoError(t, err)
+	defer indices.Release()
+
+	sorted, err := takeRecord(context.Background(), rec, indices)
+	require.NoError(t, err)
+	defer sorted.Release()
+
+	require.Equal(t, []int64{5, 3, 4, 1, 2}, sorted.Column(0).(*array.Int64).Int64Values())
+}
+
+func TestSortRecords(t *testing.T) {
+	var sch = iceberg.NewSchema(0,
+		iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64, Required: true},
+		iceberg.NestedField{ID: 2, Name: "category", Type: iceberg.PrimitiveTypes.String},
+	)
+
+	arrowSch, err := table.SchemaToArrowSchema(sch, nil, false, false)
+	require.NoError(t, err)
+
+	var (
+		tmp     = t.TempDir()
+		batches = []string{
+			`[{"id": 1, "category": "b"}, {"id": 2, "category": null}]`,
+			`[{"id": 3, "category": "a"}, {"id": 4, "category": "b"}]`,
+			`[{"id": 5, "category": "a"}, {"id": 6, "category": "c"}]`,
+		}
+		order = table.SortOrder{OrderID: 1, Fields: []table.SortField{
+			{SourceID: 2, Transform: iceberg.IdentityTransform{}, Direction: table.SortASC, NullOrder: table.NullsFirst},
+		}}
+		ids []int64
+	)
+
+	// spilled runs are written to the temporary directory and removed once merged
+	t.Setenv("TMPDIR", tmp)
+
+	var read = func(fn func(arrow.Record) error) error {
+		for _, batch := range batches {
+			rec, _, err := array.RecordFromJSON(memory.DefaultAllocator, arrowSch, strings.NewReader(batch))
+
+			if err != nil {
+				return err
+			}
+
+			err = fn(rec)
+			rec.Release()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// a run size of 1 byte spills every batch
+	for _, runSize := range []int64{1, 1 << 30} {
+		ids = nil
+
+		err = sortRecords(context.Background(), arrowSch, sch, order, runSize, read, func(rec arrow.Record) error {
+			ids = append(ids, rec.Column(0).(*array.Int64).Int64Values()...)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int64{2, 3, 5, 1, 4, 6}, ids)
+		require.Empty(t, lo.Must(os.ReadDir(tmp)))
+	}
+}
+
+func currentTestDataFiles(t *testing.T, ctx context.Context, dir string) []iceberg.DataFile {
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+
+	dataFiles, err := SnapshotDataFiles(iceio.NewObjectStoreIO(objstr.FromContext(ctx)), tbl.CurrentSnapshot())
+	require.NoError(t, err)
+
+	return dataFiles
+}
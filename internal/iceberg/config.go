@@ -0,0 +1,6 @@
+package iceberg
+
+type Config struct {
+	VersionHintStore VersionHintStoreConfig
+	CommitRetry      CommitRetryConfig
+}
@@ -2,19 +2,42 @@ package iceberg
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/url"
 
 	"github.com/apache/iceberg-go"
 	"github.com/apache/iceberg-go/catalog"
+	"github.com/apache/iceberg-go/table"
 	"github.com/samber/lo"
 )
 
+type CreateOrAddFilesConfig struct {
+	// PartitionBy is the partition spec used when the table is created (see ParsePartitionSpec).
+	PartitionBy string
+	// SortBy is the sort order used when the table is created (see ParseSortOrder).
+	SortBy string
+	// EvolveSchema adds new columns and widens existing ones to accept the added files.
+	EvolveSchema bool
+	// UnionSchema creates the table with the union of the schemas of the files instead of
+	// requiring them to share the same schema.
+	UnionSchema bool
+	// Branch is the branch the files are committed to, main by default. A branch that does
+	// not exist yet is created from the current snapshot.
+	Branch string
+	// WapId stages the snapshot instead of committing it to the branch, tagged with this
+	// wap.id so that it can be published with Publish once audited.
+	WapId string
+}
+
 func CreateOrAddFiles(
 	ctx context.Context,
 	tableLocation string,
 	inputFiles []string,
 	props iceberg.Properties,
+	conf CreateOrAddFilesConfig,
 ) error {
 	var location, err = url.Parse(tableLocation)
 
@@ -31,13 +54,37 @@ func CreateOrAddFiles(
 	t, err := cat.LoadTable(ctx, nil, props)
 
 	if errors.Is(err, catalog.ErrNoSuchTable) {
-		sch, err := SchemaFromParquetDataFiles(ctx, location, inputFiles)
+		sch, err := inferSchema(ctx, location, inputFiles, conf.UnionSchema)
+
+		if err != nil {
+			return err
+		}
+
+		spec, err := ParsePartitionSpec(sch, conf.PartitionBy)
 
 		if err != nil {
 			return err
 		}
 
-		t, err = cat.CreateTable(ctx, nil, sch, catalog.WithProperties(props))
+		order, err := ParseSortOrder(sch, conf.SortBy)
+
+		if err != nil {
+			return err
+		}
+
+		var tableProps = props
+
+		if _, found := props[table.DefaultNameMappingKey]; !found {
+			js, err := json.Marshal(sch.NameMapping())
+
+			if err != nil {
+				return err
+			}
+
+			tableProps = lo.Assign(props, iceberg.Properties{table.DefaultNameMappingKey: string(js)})
+		}
+
+		t, err = cat.CreateTable(ctx, nil, sch, catalog.WithProperties(tableProps), catalog.WithPartitionSpec(spec), catalog.WithSortOrder(order))
 
 		if err != nil {
 			return err
@@ -48,20 +95,77 @@ func CreateOrAddFiles(
 		}
 	}
 
-	var tx = t.NewTransaction()
+	var (
+		staged = t
+		su     = SnapshotUpdate{Branch: conf.Branch, Properties: props}
+	)
 
-	if err := tx.AddFiles(
-		ctx,
-		lo.Map(inputFiles, func(path string, _ int) string { return location.JoinPath("data", path).String() }),
-		props,
-		true,
-	); err != nil {
-		return err
+	if len(conf.WapId) > 0 {
+		if staged := wapSnapshots(t.Metadata(), conf.WapId); len(staged) > 0 {
+			return fmt.Errorf("wap id %s is already used by snapshot %d", conf.WapId, staged[0].SnapshotID)
+		}
+
+		su.Stage = true
+		su.Properties = lo.Assign(props, iceberg.Properties{WapIdKey: conf.WapId})
 	}
 
-	if _, err := tx.Commit(ctx); err != nil {
+	if conf.EvolveSchema {
+		schemas, err := SchemasFromParquetDataFiles(ctx, location, inputFiles)
+
+		if err != nil {
+			return err
+		}
+
+		su.Schema, err = EvolveSchema(t.Metadata(), schemas)
+
+		if err != nil {
+			return err
+		}
+
+		if su.Schema != nil {
+			if staged, err = stageSchema(t, su.Schema); err != nil {
+				return err
+			}
+		}
+	}
+
+	su.Added, err = DataFilesFromParquetFiles(ctx, staged, location, inputFiles)
+
+	if err != nil {
 		return err
 	}
 
-	return nil
+	var updates []table.Update
+
+	// the name mapping is used by readers to resolve the columns of files without field IDs
+	if t.NameMapping() == nil || su.Schema != nil {
+		js, err := json.Marshal(staged.Schema().NameMapping())
+
+		if err != nil {
+			return err
+		}
+
+		updates = append(updates, table.NewSetPropertiesUpdate(iceberg.Properties{table.DefaultNameMappingKey: string(js)}))
+	}
+
+	_, err = CommitSnapshot(ctx, cat, t, su, updates...)
+	return err
+}
+
+func inferSchema(ctx context.Context, location *url.URL, files []string, union bool) (*iceberg.Schema, error) {
+	if !union {
+		return SchemaFromParquetDataFiles(ctx, location, files)
+	}
+
+	sch, diffs, err := UnionSchemaFromParquetDataFiles(ctx, location, files)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, diff := range diffs {
+		slog.Info("data file schema differs from the table schema", "file", diff.File, "differences", diff.Differences)
+	}
+
+	return sch, nil
 }
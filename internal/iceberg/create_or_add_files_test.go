@@ -0,0 +1,133 @@
+package iceberg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/apache/iceberg-go"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestParquetFile writes rows of (id, category) under the data directory of the table.
+func writeTestParquetFile(t *testing.T, dir string, path string, ids []int64, categories []string) {
+	var (
+		sch = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "category", Type: arrow.BinaryTypes.String},
+		}, nil)
+		b = array.NewRecordBuilder(memory.DefaultAllocator, sch)
+	)
+
+	defer b.Release()
+
+	b.Field(0).(*array.Int64Builder).AppendValues(ids, nil)
+	b.Field(1).(*array.StringBuilder).AppendValues(categories, nil)
+
+	var rec = b.NewRecord()
+	defer rec.Release()
+
+	var fullPath = filepath.Join(dir, "data", path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+
+	f, err := os.Create(fullPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var tbl = array.NewTableFromRecords(sch, []arrow.Record{rec})
+	defer tbl.Release()
+
+	require.NoError(t, pqarrow.WriteTable(tbl, f, 1024, nil, pqarrow.DefaultWriterProps()))
+}
+
+func TestCreateOrAddFilesPartitioned(t *testing.T) {
+	var (
+		ctx  = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir  = t.TempDir()
+		conf = CreateOrAddFilesConfig{PartitionBy: "category, bucket(4, id)"}
+	)
+
+	writeTestParquetFile(t, dir, "category=a/1.parquet", []int64{7, 7}, []string{"a", "a"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{8}, []string{"b"})
+	writeTestParquetFile(t, dir, "3.parquet", []int64{9}, []string{"b"})
+	writeTestParquetFile(t, dir, "mixed.parquet", []int64{10, 10}, []string{"b", "c"})
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"category=a/1.parquet", "2.parquet"}, nil, conf))
+	require.ErrorContains(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"mixed.parquet"}, nil, conf), "more than one partition")
+
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	var spec = tbl.Spec()
+	require.Len(t, slices.Collect(spec.Fields()), 2)
+	require.NotNil(t, tbl.NameMapping())
+
+	var (
+		io         = iceio.NewObjectStoreIO(objstr.FromContext(ctx))
+		categoryID = spec.FieldsBySourceID(lo.Must(tbl.Schema().FindFieldByName("category")).ID)[0].FieldID
+		bucketID   = spec.FieldsBySourceID(lo.Must(tbl.Schema().FindFieldByName("id")).ID)[0].FieldID
+		bucket     = func(v int64) any {
+			// partition values are read back from Avro as int
+			return int(iceberg.BucketTransform{NumBuckets: 4}.Apply(validLiteral(iceberg.NewLiteral(v))).Val.Any().(int32))
+		}
+	)
+
+	dataFiles, err := SnapshotDataFiles(io, tbl.CurrentSnapshot())
+	require.NoError(t, err)
+
+	var partitions = lo.SliceToMap(dataFiles, func(df iceberg.DataFile) (string, map[int]any) {
+		return filepath.Base(df.FilePath()), df.Partition()
+	})
+
+	require.Equal(t, map[string]map[int]any{
+		"1.parquet": {categoryID: "a", bucketID: bucket(7)},
+		"2.parquet": {categoryID: "b", bucketID: bucket(8)},
+	}, partitions)
+
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, []string{"2.parquet"}, []string{"3.parquet"}, nil, ReplaceFilesConfig{}))
+
+	tbl, err = cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+
+	dataFiles, err = SnapshotDataFiles(io, tbl.CurrentSnapshot())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"1.parquet", "3.parquet"}, lo.Map(dataFiles, func(df iceberg.DataFile, _ int) string {
+		return filepath.Base(df.FilePath())
+	}))
+
+	var summary = tbl.CurrentSnapshot().Summary
+	require.Equal(t, "overwrite", string(summary.Operation))
+	require.Equal(t, "2", summary.Properties["total-data-files"])
+	require.Equal(t, "3", summary.Properties["total-records"])
+	require.Equal(t, "1", summary.Properties["deleted-data-files"])
+}
+
+func TestCreateOrAddFilesDuplicate(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1, 2}, []string{"a", "b"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{3}, []string{"a"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	var err = CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet", "1.parquet"}, nil, CreateOrAddFilesConfig{})
+	require.ErrorIs(t, err, ErrCommitConflict)
+	require.ErrorContains(t, err, "already part of the branch head")
+
+	require.Error(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet", "2.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	require.Len(t, currentTestDataFiles(t, ctx, dir), 1)
+}
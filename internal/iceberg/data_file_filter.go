@@ -0,0 +1,477 @@
+package iceberg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+)
+
+// inPredicateLimit is the number of IN values above which the values are not compared
+// with the bounds of a file, like in the Iceberg metrics evaluators.
+const inPredicateLimit = 200
+
+// dataFileFilter tells whether a data file may hold rows matching a row filter, from its
+// partition values and column bounds. Bounds are decoded with boundLiteralFromBytes, so
+// the bounds written before a column was promoted are compared as values of its current
+// type. It is not safe for concurrent use.
+type dataFileFilter struct {
+	md         table.Metadata
+	sch        *iceberg.Schema
+	expr       iceberg.BooleanExpression
+	fields     []iceberg.NestedField
+	partitions map[int]partitionFilter
+}
+
+// partitionFilter is the projection of the row filter on the fields of a partition spec.
+type partitionFilter struct {
+	expr   iceberg.BooleanExpression
+	fields []iceberg.NestedField
+}
+
+// newDataFileFilter binds the filter to the schema. Binding errors are reported as
+// invalid filters.
+func newDataFileFilter(md table.Metadata, sch *iceberg.Schema, filter iceberg.BooleanExpression) (*dataFileFilter, error) {
+	rewritten, err := iceberg.RewriteNotExpr(filter)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+
+	bound, err := iceberg.BindExpr(sch, rewritten, true)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+
+	ids, err := iceberg.ExtractFieldIDs(bound)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []iceberg.NestedField
+
+	for _, id := range lo.Uniq(ids) {
+		if field, found := sch.FindFieldByID(id); found {
+			fields = append(fields, field)
+		}
+	}
+
+	return &dataFileFilter{
+		md:         md,
+		sch:        sch,
+		expr:       bound,
+		fields:     fields,
+		partitions: make(map[int]partitionFilter),
+	}, nil
+}
+
+// match reports whether the data file may hold rows matching the filter.
+func (f *dataFileFilter) match(df iceberg.DataFile) (bool, error) {
+	if df.Count() == 0 {
+		return false, nil
+	}
+
+	pf, err := f.partitionFilter(int(df.SpecID()))
+
+	if err != nil {
+		return false, err
+	}
+
+	partition, err := partitionBounds(pf.fields, df)
+
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", df.FilePath(), err)
+	}
+
+	match, err := iceberg.VisitExpr(pf.expr, partition)
+
+	if err != nil || !match {
+		return false, err
+	}
+
+	columns, err := columnBounds(f.fields, df)
+
+	if err != nil {
+		return false, err
+	}
+
+	return iceberg.VisitExpr(f.expr, columns)
+}
+
+func (f *dataFileFilter) partitionFilter(specID int) (partitionFilter, error) {
+	if pf, found := f.partitions[specID]; found {
+		return pf, nil
+	}
+
+	spec, found := lo.Find(f.md.PartitionSpecs(), func(spec iceberg.PartitionSpec) bool {
+		return spec.ID() == specID
+	})
+
+	if !found {
+		return partitionFilter{}, fmt.Errorf("partition spec %d not found", specID)
+	}
+
+	var pf partitionFilter
+
+	// the fields whose source column was dropped cannot be referenced by the filter
+	for field := range spec.Fields() {
+		if source, found := f.sch.FindFieldByID(field.SourceID); found {
+			pf.fields = append(pf.fields, iceberg.NestedField{
+				ID:   field.FieldID,
+				Name: field.Name,
+				Type: field.Transform.ResultType(source.Type),
+			})
+		}
+	}
+
+	projected, err := iceberg.VisitExpr(f.expr, partitionProjection{spec: spec})
+
+	if err != nil {
+		return partitionFilter{}, err
+	}
+
+	if pf.expr, err = iceberg.BindExpr(iceberg.NewSchema(0, pf.fields...), projected, true); err != nil {
+		return partitionFilter{}, fmt.Errorf("cannot project filter on partition spec %d: %w", specID, err)
+	}
+
+	f.partitions[specID] = pf
+	return pf, nil
+}
+
+// partitionProjection projects a bound row filter on the fields of a partition spec: a
+// partition matches the projection when it may hold rows matching the filter.
+type partitionProjection struct {
+	spec iceberg.PartitionSpec
+}
+
+func (partitionProjection) VisitTrue() iceberg.BooleanExpression  { return iceberg.AlwaysTrue{} }
+func (partitionProjection) VisitFalse() iceberg.BooleanExpression { return iceberg.AlwaysFalse{} }
+
+func (partitionProjection) VisitNot(iceberg.BooleanExpression) iceberg.BooleanExpression {
+	panic("NOT should be rewritten")
+}
+
+func (partitionProjection) VisitAnd(left, right iceberg.BooleanExpression) iceberg.BooleanExpression {
+	return iceberg.NewAnd(left, right)
+}
+
+func (partitionProjection) VisitOr(left, right iceberg.BooleanExpression) iceberg.BooleanExpression {
+	return iceberg.NewOr(left, right)
+}
+
+func (partitionProjection) VisitUnbound(iceberg.UnboundPredicate) iceberg.BooleanExpression {
+	panic("found unbound predicate when projecting filter")
+}
+
+func (p partitionProjection) VisitBound(pred iceberg.BoundPredicate) iceberg.BooleanExpression {
+	var res iceberg.BooleanExpression = iceberg.AlwaysTrue{}
+
+	for _, field := range p.spec.FieldsBySourceID(pred.Ref().Field().ID) {
+		projected, err := field.Transform.Project(field.Name, pred)
+
+		if err != nil {
+			panic(err)
+		}
+
+		if projected != nil {
+			res = iceberg.NewAnd(res, projected)
+		}
+	}
+
+	return res
+}
+
+// boundsEval evaluates a bound filter against the statistics of a data file, or of its
+// partition, keyed by field ID. It returns false when no row can match, following the
+// rules of the Iceberg inclusive metrics evaluator.
+type boundsEval struct {
+	valueCounts map[int]int64
+	nullCounts  map[int]int64
+	nanCounts   map[int]int64
+	lower       map[int]iceberg.Literal
+	upper       map[int]iceberg.Literal
+}
+
+// columnBounds decodes the statistics of the given columns of a data file.
+func columnBounds(fields []iceberg.NestedField, df iceberg.DataFile) (*boundsEval, error) {
+	var e = boundsEval{
+		valueCounts: df.ValueCounts(),
+		nullCounts:  df.NullValueCounts(),
+		nanCounts:   df.NaNValueCounts(),
+		lower:       make(map[int]iceberg.Literal),
+		upper:       make(map[int]iceberg.Literal),
+	}
+
+	for _, field := range fields {
+		for _, v := range []struct {
+			values map[int][]byte
+			dst    map[int]iceberg.Literal
+		}{
+			{df.LowerBoundValues(), e.lower},
+			{df.UpperBoundValues(), e.upper},
+		} {
+			b, found := v.values[field.ID]
+
+			if !found {
+				continue
+			}
+
+			lit, err := boundLiteralFromBytes(field.Type, b)
+
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode bound value of field %s (%s) in datafile %s: %w", field.Name, field.Type, df.FilePath(), err)
+			}
+
+			v.dst[field.ID] = lit
+		}
+	}
+
+	return &e, nil
+}
+
+// partitionBounds describes the partition of a data file as statistics of a single row.
+func partitionBounds(fields []iceberg.NestedField, df iceberg.DataFile) (*boundsEval, error) {
+	var e = boundsEval{
+		valueCounts: make(map[int]int64),
+		nullCounts:  make(map[int]int64),
+		lower:       make(map[int]iceberg.Literal),
+		upper:       make(map[int]iceberg.Literal),
+	}
+
+	for _, field := range fields {
+		v, found := df.Partition()[field.ID]
+
+		if !found {
+			continue
+		}
+
+		e.valueCounts[field.ID] = 1
+
+		if v == nil {
+			e.nullCounts[field.ID] = 1
+			continue
+		}
+
+		lit, err := partitionValueLiteral(v, field.Type)
+
+		if err != nil {
+			return nil, fmt.Errorf("partition field %s: %w", field.Name, err)
+		}
+
+		e.nullCounts[field.ID] = 0
+		e.lower[field.ID] = lit
+		e.upper[field.ID] = lit
+	}
+
+	return &e, nil
+}
+
+func (e *boundsEval) nullsOnly(id int) bool {
+	values, hasValues := e.valueCounts[id]
+	nulls, hasNulls := e.nullCounts[id]
+
+	return hasValues && hasNulls && values == nulls
+}
+
+func (e *boundsEval) nansOnly(id int) bool {
+	values, hasValues := e.valueCounts[id]
+	nans, hasNans := e.nanCounts[id]
+
+	return hasValues && hasNans && values == nans
+}
+
+// bounds returns the lower and upper bounds of a field, ignoring NaN bounds which are
+// not reliable.
+func (e *boundsEval) bounds(id int) (iceberg.Literal, iceberg.Literal) {
+	var isNaN = func(lit iceberg.Literal) bool {
+		switch lit := lit.(type) {
+		case iceberg.Float32Literal:
+			return math.IsNaN(float64(lit))
+		case iceberg.Float64Literal:
+			return math.IsNaN(float64(lit))
+		default:
+			return false
+		}
+	}
+
+	var lower, upper = e.lower[id], e.upper[id]
+
+	if lower != nil && isNaN(lower) {
+		lower = nil
+	}
+
+	if upper != nil && isNaN(upper) {
+		upper = nil
+	}
+
+	return lower, upper
+}
+
+func (e *boundsEval) VisitTrue() bool                { return true }
+func (e *boundsEval) VisitFalse() bool               { return false }
+func (e *boundsEval) VisitAnd(left, right bool) bool { return left && right }
+func (e *boundsEval) VisitOr(left, right bool) bool  { return left || right }
+
+func (e *boundsEval) VisitNot(bool) bool {
+	panic("NOT should be rewritten")
+}
+
+func (e *boundsEval) VisitUnbound(iceberg.UnboundPredicate) bool {
+	panic("found unbound predicate when evaluating filter")
+}
+
+func (e *boundsEval) VisitBound(pred iceberg.BoundPredicate) bool {
+	return iceberg.VisitBoundPredicate(pred, e)
+}
+
+func (e *boundsEval) VisitIsNull(t iceberg.BoundTerm) bool {
+	nulls, found := e.nullCounts[t.Ref().Field().ID]
+	return !found || nulls > 0
+}
+
+func (e *boundsEval) VisitNotNull(t iceberg.BoundTerm) bool {
+	return !e.nullsOnly(t.Ref().Field().ID)
+}
+
+func (e *boundsEval) VisitIsNan(t iceberg.BoundTerm) bool {
+	var id = t.Ref().Field().ID
+
+	if nans, found := e.nanCounts[id]; found && nans == 0 {
+		return false
+	}
+
+	return !e.nullsOnly(id)
+}
+
+func (e *boundsEval) VisitNotNan(t iceberg.BoundTerm) bool {
+	return !e.nansOnly(t.Ref().Field().ID)
+}
+
+// compare tells whether a value in the bounds of the field may compare to the literal as
+// accepted by the predicate.
+func (e *boundsEval) compare(id int, lit iceberg.Literal, accept func(lowerCmp, upperCmp *int) bool) bool {
+	if e.nullsOnly(id) || e.nansOnly(id) {
+		return false
+	}
+
+	var (
+		lower, upper       = e.bounds(id)
+		lowerCmp, upperCmp *int
+	)
+
+	if lower != nil {
+		lowerCmp = lo.ToPtr(compareLiterals(lower, lit))
+	}
+
+	if upper != nil {
+		upperCmp = lo.ToPtr(compareLiterals(upper, lit))
+	}
+
+	return accept(lowerCmp, upperCmp)
+}
+
+func (e *boundsEval) VisitLess(t iceberg.BoundTerm, lit iceberg.Literal) bool {
+	return e.compare(t.Ref().Field().ID, lit, func(lower, _ *int) bool { return lower == nil || *lower < 0 })
+}
+
+func (e *boundsEval) VisitLessEqual(t iceberg.BoundTerm, lit iceberg.Literal) bool {
+	return e.compare(t.Ref().Field().ID, lit, func(lower, _ *int) bool { return lower == nil || *lower <= 0 })
+}
+
+func (e *boundsEval) VisitGreater(t iceberg.BoundTerm, lit iceberg.Literal) bool {
+	return e.compare(t.Ref().Field().ID, lit, func(_, upper *int) bool { return upper == nil || *upper > 0 })
+}
+
+func (e *boundsEval) VisitGreaterEqual(t iceberg.BoundTerm, lit iceberg.Literal) bool {
+	return e.compare(t.Ref().Field().ID, lit, func(_, upper *int) bool { return upper == nil || *upper >= 0 })
+}
+
+func (e *boundsEval) VisitEqual(t iceberg.BoundTerm, lit iceberg.Literal) bool {
+	return e.mayEqual(t.Ref().Field().ID, lit)
+}
+
+// mayEqual tells whether the literal lies within the bounds of the field.
+func (e *boundsEval) mayEqual(id int, lit iceberg.Literal) bool {
+	return e.compare(id, lit, func(lower, upper *int) bool {
+		return (lower == nil || *lower <= 0) && (upper == nil || *upper >= 0)
+	})
+}
+
+func (e *boundsEval) VisitNotEqual(iceberg.BoundTerm, iceberg.Literal) bool {
+	return true
+}
+
+func (e *boundsEval) VisitIn(t iceberg.BoundTerm, lits iceberg.Set[iceberg.Literal]) bool {
+	if lits.Len() > inPredicateLimit {
+		return !e.nullsOnly(t.Ref().Field().ID) && !e.nansOnly(t.Ref().Field().ID)
+	}
+
+	return lo.SomeBy(lits.Members(), func(lit iceberg.Literal) bool { return e.mayEqual(t.Ref().Field().ID, lit) })
+}
+
+func (e *boundsEval) VisitNotIn(iceberg.BoundTerm, iceberg.Set[iceberg.Literal]) bool {
+	return true
+}
+
+func (e *boundsEval) VisitStartsWith(t iceberg.BoundTerm, lit iceberg.Literal) bool {
+	var id = t.Ref().Field().ID
+
+	if e.nullsOnly(id) {
+		return false
+	}
+
+	prefix, ok := lit.(iceberg.StringLiteral)
+
+	if !ok {
+		return true
+	}
+
+	// bounds may be truncated, so only their common length with the prefix is compared
+	var truncated = func(bound iceberg.Literal) (string, string, bool) {
+		s, ok := bound.(iceberg.StringLiteral)
+
+		if !ok {
+			return "", "", false
+		}
+
+		var n = min(len(s), len(prefix))
+		return string(s[:n]), string(prefix[:n]), true
+	}
+
+	if lower, p, ok := truncated(e.lower[id]); ok && lower > p {
+		return false
+	}
+
+	if upper, p, ok := truncated(e.upper[id]); ok && upper < p {
+		return false
+	}
+
+	return true
+}
+
+func (e *boundsEval) VisitNotStartsWith(t iceberg.BoundTerm, lit iceberg.Literal) bool {
+	var id = t.Ref().Field().ID
+
+	if nulls, found := e.nullCounts[id]; !found || nulls > 0 {
+		return true
+	}
+
+	prefix, ok := lit.(iceberg.StringLiteral)
+
+	if !ok {
+		return true
+	}
+
+	// every value lies between bounds starting with the prefix
+	lower, hasLower := e.lower[id].(iceberg.StringLiteral)
+	upper, hasUpper := e.upper[id].(iceberg.StringLiteral)
+
+	return !hasLower || !hasUpper ||
+		!strings.HasPrefix(string(lower), string(prefix)) ||
+		!strings.HasPrefix(string(upper), string(prefix))
+}
@@ -0,0 +1,402 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/io"
+	"github.com/apache/iceberg-go/table"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/iter"
+)
+
+const hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// DataFilesFromParquetFiles builds the data files of the given Parquet files (relative to
+// the table data directory) for the current schema and partition spec of the table.
+// Partition values are read from hive-style path segments (`key=value`) when present,
+// and inferred from column statistics otherwise.
+func DataFilesFromParquetFiles(ctx context.Context, t *table.Table, location *url.URL, files []string) ([]iceberg.DataFile, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	var locations = lo.Map(files, func(path string, _ int) string { return location.JoinPath("data", path).String() })
+
+	stats, err := parquetFilesStatistics(ctx, t, locations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		spec = t.Spec()
+		sch  = t.Schema()
+		res  = make([]iceberg.DataFile, 0, len(files))
+	)
+
+	for i, path := range files {
+		df, found := stats[locations[i]]
+
+		if !found {
+			return nil, fmt.Errorf("no statistics computed for file %s", locations[i])
+		}
+
+		partition, err := partitionValues(spec, sch, path, df)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		df, err = withPartition(df, spec, partition)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, df)
+	}
+
+	return res, nil
+}
+
+// parquetFilesStatistics computes the data files statistics by adding the files to a scratch,
+// unpartitioned table living in memory. This reuses the iceberg-go metrics collection
+// without its partition inference, which only supports order-preserving transforms.
+// Files whose columns carry field IDs, which iceberg-go does not accept, are read directly.
+func parquetFilesStatistics(ctx context.Context, t *table.Table, locations []string) (map[string]iceberg.DataFile, error) {
+	var (
+		os           = objstr.FromContextOrDefault(ctx)
+		osio         = iceio.NewObjectStoreIO(os)
+		scratchLoc   = "memory://icepq-scratch/" + uuid.Must(uuid.NewV7()).String()
+		scratchProps = lo.PickBy(t.Properties(), func(k string, _ string) bool {
+			return strings.HasPrefix(k, "write.metadata.metrics.")
+		})
+		res = make(map[string]iceberg.DataFile)
+	)
+
+	withIDs, err := iter.MapErr(locations, func(loc *string) (iceberg.DataFile, error) {
+		var df iceberg.DataFile
+
+		u, err := url.Parse(*loc)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = withParquetFile(ctx, u, func(pqr *file.Reader, size int64) error {
+			if !hasFieldIDs(pqr.MetaData().Schema) {
+				return nil
+			}
+
+			df, err = parquetDataFileFromMetadata(t.Schema(), t.Properties(), *loc, pqr.MetaData(), size)
+			return err
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", *loc, err)
+		}
+
+		return df, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, df := range withIDs {
+		if df != nil {
+			res[df.FilePath()] = df
+		}
+	}
+
+	locations = lo.Reject(locations, func(loc string, _ int) bool { return res[loc] != nil })
+
+	if len(locations) == 0 {
+		return res, nil
+	}
+
+	md, err := newTableMetadata(scratchLoc, t.Schema(), iceberg.UnpartitionedSpec, table.UnsortedSortOrder, scratchProps)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		scratch = table.New(nil, md, scratchLoc, func(ctx context.Context) (io.IO, error) { return osio, nil }, nil)
+		tx      = scratch.NewTransaction()
+	)
+
+	if err := tx.AddFiles(ctx, locations, nil, true); err != nil {
+		return nil, err
+	}
+
+	staged, err := tx.StagedTable()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var snap = staged.CurrentSnapshot()
+
+	defer deleteSnapshotFiles(context.WithoutCancel(ctx), osio, snap)
+
+	dataFiles, err := SnapshotDataFiles(osio, snap)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, df := range dataFiles {
+		res[df.FilePath()] = df
+	}
+
+	return res, nil
+}
+
+func deleteSnapshotFiles(ctx context.Context, io *iceio.ObjectStoreIO, snap *table.Snapshot) {
+	if snap == nil {
+		return
+	}
+
+	if mans, err := snap.Manifests(io); err == nil {
+		for _, man := range mans {
+			_ = io.Remove(man.FilePath())
+		}
+	}
+
+	_ = io.Remove(snap.ManifestList)
+}
+
+// partitionValues computes the partition tuple of a data file, keyed by partition field ID.
+func partitionValues(spec iceberg.PartitionSpec, sch *iceberg.Schema, path string, df iceberg.DataFile) (map[int]any, error) {
+	if spec.IsUnpartitioned() {
+		return nil, nil
+	}
+
+	var (
+		res      = make(map[int]any)
+		segments = hivePathSegments(path)
+	)
+
+	for field := range spec.Fields() {
+		source, found := sch.FindFieldByID(field.SourceID)
+
+		if !found {
+			return nil, fmt.Errorf("partition source column %d not found", field.SourceID)
+		}
+
+		var (
+			lit iceberg.Optional[iceberg.Literal]
+			err error
+		)
+
+		if v, found := segments[field.Name]; found {
+			lit, err = partitionLiteralFromPath(v, field.Transform.ResultType(source.Type), field.Transform)
+		} else if v, found := segments[source.Name]; found {
+			lit, err = partitionLiteralFromPath(v, source.Type, nil)
+
+			if err == nil {
+				lit = field.Transform.Apply(lit)
+			}
+		} else {
+			lit, err = partitionLiteralFromStats(field, source, df)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("partition field %s: %w", field.Name, err)
+		}
+
+		if lit.Valid {
+			res[field.FieldID] = lit.Val.Any()
+		}
+	}
+
+	return res, nil
+}
+
+func hivePathSegments(path string) map[string]string {
+	var res = make(map[string]string)
+
+	for _, segment := range strings.Split(path, "/") {
+		k, v, found := strings.Cut(segment, "=")
+
+		if !found || len(k) == 0 {
+			continue
+		}
+
+		if unescaped, err := url.PathUnescape(v); err == nil {
+			v = unescaped
+		}
+
+		res[k] = v
+	}
+
+	return res
+}
+
+// partitionLiteralFromPath parses a path value. When transform is set, the value is
+// the already transformed value, rendered the way Iceberg renders partition paths.
+func partitionLiteralFromPath(s string, typ iceberg.Type, transform iceberg.Transform) (iceberg.Optional[iceberg.Literal], error) {
+	if s == hiveDefaultPartition {
+		return iceberg.Optional[iceberg.Literal]{}, nil
+	}
+
+	switch transform.(type) {
+	case iceberg.YearTransform:
+		if tm, err := time.Parse("2006", s); err == nil {
+			return validLiteral(iceberg.NewLiteral(int32(tm.Year() - 1970))), nil
+		}
+	case iceberg.MonthTransform:
+		if tm, err := time.Parse("2006-01", s); err == nil {
+			return validLiteral(iceberg.NewLiteral(int32((tm.Year()-1970)*12 + int(tm.Month()) - 1))), nil
+		}
+	case iceberg.DayTransform:
+		if tm, err := time.Parse(time.DateOnly, s); err == nil {
+			return validLiteral(iceberg.NewLiteral(int32(tm.Unix() / 86400))), nil
+		}
+	case iceberg.HourTransform:
+		if tm, err := time.Parse("2006-01-02-15", s); err == nil {
+			return validLiteral(iceberg.NewLiteral(int32(tm.Unix() / 3600))), nil
+		}
+	}
+
+	switch typ.(type) {
+	case iceberg.TimestampType, iceberg.TimestampTzType:
+		return parseTimestampLiteral(s, typ)
+	case iceberg.BooleanType:
+		b, err := strconv.ParseBool(s)
+
+		if err != nil {
+			return iceberg.Optional[iceberg.Literal]{}, err
+		}
+
+		return validLiteral(iceberg.NewLiteral(b)), nil
+	}
+
+	lit, err := iceberg.NewLiteral(s).To(typ)
+
+	if err != nil {
+		return iceberg.Optional[iceberg.Literal]{}, err
+	}
+
+	return validLiteral(lit), nil
+}
+
+func parseTimestampLiteral(s string, typ iceberg.Type) (iceberg.Optional[iceberg.Literal], error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999", time.DateOnly} {
+		if tm, err := time.Parse(layout, s); err == nil {
+			lit, err := iceberg.NewLiteral(iceberg.Timestamp(tm.UnixMicro())).To(typ)
+
+			if err != nil {
+				return iceberg.Optional[iceberg.Literal]{}, err
+			}
+
+			return validLiteral(lit), nil
+		}
+	}
+
+	return iceberg.Optional[iceberg.Literal]{}, fmt.Errorf("invalid timestamp: %s", s)
+}
+
+// partitionLiteralFromStats infers the partition value from the column bounds, which is
+// only possible when all the rows of the file share the same transformed value.
+func partitionLiteralFromStats(field iceberg.PartitionField, source iceberg.NestedField, df iceberg.DataFile) (iceberg.Optional[iceberg.Literal], error) {
+	var (
+		lower, hasLower = df.LowerBoundValues()[source.ID]
+		upper, hasUpper = df.UpperBoundValues()[source.ID]
+		nulls           = df.NullValueCounts()[source.ID]
+	)
+
+	if !hasLower || !hasUpper {
+		if nulls == df.Count() {
+			return iceberg.Optional[iceberg.Literal]{}, nil
+		}
+
+		return iceberg.Optional[iceberg.Literal]{}, fmt.Errorf("no path segment nor column statistics to infer value from")
+	}
+
+	if nulls > 0 {
+		return iceberg.Optional[iceberg.Literal]{}, fmt.Errorf("file contains both null and non-null values for column %s", source.Name)
+	}
+
+	lowerLit, err := boundLiteralFromBytes(source.Type, lower)
+
+	if err != nil {
+		return iceberg.Optional[iceberg.Literal]{}, err
+	}
+
+	upperLit, err := boundLiteralFromBytes(source.Type, upper)
+
+	if err != nil {
+		return iceberg.Optional[iceberg.Literal]{}, err
+	}
+
+	if !field.Transform.PreservesOrder() && !lowerLit.Equals(upperLit) {
+		return iceberg.Optional[iceberg.Literal]{}, fmt.Errorf("cannot infer %s value from column statistics when column %s holds more than one value", field.Transform, source.Name)
+	}
+
+	var (
+		lowerT = field.Transform.Apply(validLiteral(lowerLit))
+		upperT = field.Transform.Apply(validLiteral(upperLit))
+	)
+
+	if !lowerT.Valid || !upperT.Valid || !lowerT.Val.Equals(upperT.Val) {
+		return iceberg.Optional[iceberg.Literal]{}, fmt.Errorf("file spans more than one partition (lower: %s, upper: %s)", lowerLit, upperLit)
+	}
+
+	return lowerT, nil
+}
+
+func validLiteral(lit iceberg.Literal) iceberg.Optional[iceberg.Literal] {
+	return iceberg.Optional[iceberg.Literal]{Valid: true, Val: lit}
+}
+
+// withPartition rebuilds a data file for the given partition spec and tuple.
+func withPartition(df iceberg.DataFile, spec iceberg.PartitionSpec, partition map[int]any) (iceberg.DataFile, error) {
+	b, err := iceberg.NewDataFileBuilder(
+		spec,
+		df.ContentType(),
+		df.FilePath(),
+		df.FileFormat(),
+		partition,
+		df.Count(),
+		df.FileSizeBytes(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.ColumnSizes(df.ColumnSizes())
+	b.ValueCounts(df.ValueCounts())
+	b.NullValueCounts(df.NullValueCounts())
+	b.NaNValueCounts(df.NaNValueCounts())
+
+	if len(df.LowerBoundValues()) > 0 {
+		b.LowerBoundValues(df.LowerBoundValues())
+	}
+
+	if len(df.UpperBoundValues()) > 0 {
+		b.UpperBoundValues(df.UpperBoundValues())
+	}
+
+	if len(df.SplitOffsets()) > 0 {
+		b.SplitOffsets(df.SplitOffsets())
+	}
+
+	if df.SortOrderID() != nil {
+		b.SortOrderID(*df.SortOrderID())
+	}
+
+	return b.Build(), nil
+}
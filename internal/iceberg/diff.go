@@ -0,0 +1,257 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+)
+
+// DiffFiles sums a set of data files.
+type DiffFiles struct {
+	FileCount     int   `json:"file_count"`
+	RecordCount   int64 `json:"record_count"`
+	FileSizeBytes int64 `json:"file_size_bytes"`
+}
+
+// DiffFileList sums a set of data files and lists their locations.
+type DiffFileList struct {
+	DiffFiles
+	Files []string `json:"files"`
+}
+
+// ColumnChange describes a change of a column between two schemas: added, dropped, renamed,
+// type_changed or nullability_changed. Name is the column name in the newest schema holding
+// it, and From and To hold the former and new name, type or nullability.
+type ColumnChange struct {
+	FieldId int    `json:"field_id"`
+	Name    string `json:"name"`
+	Change  string `json:"change"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+}
+
+type SchemaDiff struct {
+	FromSchemaId int            `json:"from_schema_id"`
+	ToSchemaId   int            `json:"to_schema_id"`
+	Changes      []ColumnChange `json:"changes"`
+}
+
+// PartitionSpecDiff lists the partition specs of the data files of each snapshot, along
+// with the fields of these specs.
+type PartitionSpecDiff struct {
+	FromSpecIds []int            `json:"from_spec_ids"`
+	ToSpecIds   []int            `json:"to_spec_ids"`
+	Specs       map[int][]string `json:"specs"`
+}
+
+type SnapshotDiff struct {
+	FromSnapshotId int64             `json:"from_snapshot_id"`
+	ToSnapshotId   int64             `json:"to_snapshot_id"`
+	Added          DiffFileList      `json:"added"`
+	Removed        DiffFileList      `json:"removed"`
+	CarriedOver    DiffFiles         `json:"carried_over"`
+	Schema         SchemaDiff        `json:"schema"`
+	PartitionSpec  PartitionSpecDiff `json:"partition_spec"`
+}
+
+// DiffSnapshots reports the live data files added, removed and carried over from a
+// snapshot to another, along with the changes of their schemas and of the partition specs
+// of their data files. Files are matched by location, so a file rewritten by a compaction
+// is reported as removed and its output as added.
+func DiffSnapshots(ctx context.Context, tableLocation string, fromSnapshotId int64, toSnapshotId int64) (*SnapshotDiff, error) {
+	var io = iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx))
+
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		md   = t.Metadata()
+		from = md.SnapshotByID(fromSnapshotId)
+		to   = md.SnapshotByID(toSnapshotId)
+	)
+
+	if from == nil {
+		return nil, fmt.Errorf("snapshot %d not found", fromSnapshotId)
+	}
+
+	if to == nil {
+		return nil, fmt.Errorf("snapshot %d not found", toSnapshotId)
+	}
+
+	fromFiles, err := SnapshotDataFiles(io, from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	toFiles, err := SnapshotDataFiles(io, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		fromPaths = lo.SliceToMap(fromFiles, func(df iceberg.DataFile) (string, bool) { return df.FilePath(), true })
+		toPaths   = lo.SliceToMap(toFiles, func(df iceberg.DataFile) (string, bool) { return df.FilePath(), true })
+		diff      = SnapshotDiff{
+			FromSnapshotId: fromSnapshotId,
+			ToSnapshotId:   toSnapshotId,
+			Added:          DiffFileList{Files: []string{}},
+			Removed:        DiffFileList{Files: []string{}},
+		}
+	)
+
+	diff.Schema, err = diffSchemas(snapshotSchema(md, from), snapshotSchema(md, to))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, df := range toFiles {
+		if fromPaths[df.FilePath()] {
+			diff.CarriedOver.add(df)
+		} else {
+			diff.Added.add(df)
+		}
+	}
+
+	for _, df := range fromFiles {
+		if !toPaths[df.FilePath()] {
+			diff.Removed.add(df)
+		}
+	}
+
+	slices.Sort(diff.Added.Files)
+	slices.Sort(diff.Removed.Files)
+
+	diff.PartitionSpec, err = diffPartitionSpecs(md, fromFiles, toFiles)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &diff, nil
+}
+
+func (files *DiffFiles) add(df iceberg.DataFile) {
+	files.FileCount++
+	files.RecordCount += df.Count()
+	files.FileSizeBytes += df.FileSizeBytes()
+}
+
+func (files *DiffFileList) add(df iceberg.DataFile) {
+	files.DiffFiles.add(df)
+	files.Files = append(files.Files, df.FilePath())
+}
+
+// diffSchemas reports the changes of the columns matched by field ID, ordered by field ID.
+func diffSchemas(from *iceberg.Schema, to *iceberg.Schema) (SchemaDiff, error) {
+	var diff = SchemaDiff{FromSchemaId: from.ID, ToSchemaId: to.ID, Changes: []ColumnChange{}}
+
+	fromNames, err := iceberg.IndexNameByID(from)
+
+	if err != nil {
+		return diff, err
+	}
+
+	toNames, err := iceberg.IndexNameByID(to)
+
+	if err != nil {
+		return diff, err
+	}
+
+	var ids = lo.Uniq(append(lo.Keys(fromNames), lo.Keys(toNames)...))
+
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		var (
+			fromField, inFrom = from.FindFieldByID(id)
+			toField, inTo     = to.FindFieldByID(id)
+		)
+
+		switch {
+		case !inFrom:
+			diff.Changes = append(diff.Changes, ColumnChange{FieldId: id, Name: toNames[id], Change: "added", To: toField.Type.String()})
+		case !inTo:
+			diff.Changes = append(diff.Changes, ColumnChange{FieldId: id, Name: fromNames[id], Change: "dropped", From: fromField.Type.String()})
+		default:
+			if fromNames[id] != toNames[id] {
+				diff.Changes = append(diff.Changes, ColumnChange{FieldId: id, Name: toNames[id], Change: "renamed", From: fromNames[id], To: toNames[id]})
+			}
+
+			// nested types are compared through their own fields
+			if _, primitive := toField.Type.(iceberg.PrimitiveType); primitive && !fromField.Type.Equals(toField.Type) {
+				diff.Changes = append(diff.Changes, ColumnChange{FieldId: id, Name: toNames[id], Change: "type_changed", From: fromField.Type.String(), To: toField.Type.String()})
+			}
+
+			if fromField.Required != toField.Required {
+				diff.Changes = append(diff.Changes, ColumnChange{FieldId: id, Name: toNames[id], Change: "nullability_changed", From: nullability(fromField), To: nullability(toField)})
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+func nullability(field iceberg.NestedField) string {
+	if field.Required {
+		return "required"
+	}
+
+	return "optional"
+}
+
+func diffPartitionSpecs(md table.Metadata, fromFiles []iceberg.DataFile, toFiles []iceberg.DataFile) (PartitionSpecDiff, error) {
+	var (
+		specIds = func(files []iceberg.DataFile) []int {
+			var ids = lo.Uniq(lo.Map(files, func(df iceberg.DataFile, _ int) int { return int(df.SpecID()) }))
+			slices.Sort(ids)
+			return ids
+		}
+		diff = PartitionSpecDiff{
+			FromSpecIds: specIds(fromFiles),
+			ToSpecIds:   specIds(toFiles),
+			Specs:       map[int][]string{},
+		}
+	)
+
+	for _, id := range lo.Union(diff.FromSpecIds, diff.ToSpecIds) {
+		spec, found := lo.Find(md.PartitionSpecs(), func(spec iceberg.PartitionSpec) bool { return spec.ID() == id })
+
+		if !found {
+			return diff, fmt.Errorf("partition spec %d not found", id)
+		}
+
+		var fields = []string{}
+
+		for field := range spec.Fields() {
+			var source = fmt.Sprint(field.SourceID)
+
+			if name, found := md.CurrentSchema().FindColumnName(field.SourceID); found {
+				source = name
+			}
+
+			fields = append(fields, fmt.Sprintf("%s: %s(%s)", field.Name, field.Transform, source))
+		}
+
+		diff.Specs[id] = fields
+	}
+
+	return diff, nil
+}
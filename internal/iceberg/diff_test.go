@@ -0,0 +1,86 @@
+package iceberg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	var snapshots = func() []int64 {
+		rows, err := Inspect(ctx, "file://"+dir, "snapshots", InspectConfig{})
+		require.NoError(t, err)
+
+		var res []int64
+
+		for _, row := range rows {
+			res = append(res, row.(SnapshotsRow).SnapshotId)
+		}
+
+		return res
+	}
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1, 2}, []string{"a", "b"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{3}, []string{"a"})
+	writeTestParquetFile(t, dir, "3.parquet", []int64{4, 5, 6}, []string{"a", "a", "b"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	_, err := UpdateSchema(ctx, "file://"+dir, AddColumn("extra", "string", ""))
+	require.NoError(t, err)
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, []string{"2.parquet"}, []string{"3.parquet"}, nil, ReplaceFilesConfig{}))
+
+	var ids = snapshots()
+	require.Len(t, ids, 2)
+
+	diff, err := DiffSnapshots(ctx, "file://"+dir, ids[0], ids[1])
+	require.NoError(t, err)
+	require.Equal(t, []string{"file://" + filepath.Join(dir, "data", "3.parquet")}, diff.Added.Files)
+	require.EqualValues(t, 3, diff.Added.RecordCount)
+	require.Equal(t, []string{"file://" + filepath.Join(dir, "data", "2.parquet")}, diff.Removed.Files)
+	require.EqualValues(t, 1, diff.Removed.RecordCount)
+	require.Equal(t, 1, diff.CarriedOver.FileCount)
+	require.EqualValues(t, 2, diff.CarriedOver.RecordCount)
+	require.Equal(t, []int{0}, diff.PartitionSpec.ToSpecIds)
+
+	require.Equal(t, []ColumnChange{{FieldId: 3, Name: "extra", Change: "added", To: "string"}}, diff.Schema.Changes)
+
+	// the reverse diff swaps added and removed files
+	diff, err = DiffSnapshots(ctx, "file://"+dir, ids[1], ids[0])
+	require.NoError(t, err)
+	require.Equal(t, 1, diff.Added.FileCount)
+	require.Equal(t, 1, diff.Removed.FileCount)
+	require.Equal(t, []ColumnChange{{FieldId: 3, Name: "extra", Change: "dropped", From: "string"}}, diff.Schema.Changes)
+
+	_, err = DiffSnapshots(ctx, "file://"+dir, ids[0], 42)
+	require.ErrorContains(t, err, "snapshot 42 not found")
+}
+
+func TestDiffSchemas(t *testing.T) {
+	var (
+		from = iceberg.NewSchema(0,
+			iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int32, Required: true},
+			iceberg.NestedField{ID: 2, Name: "category", Type: iceberg.PrimitiveTypes.String},
+		)
+		to = iceberg.NewSchema(1,
+			iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64},
+			iceberg.NestedField{ID: 2, Name: "kind", Type: iceberg.PrimitiveTypes.String},
+		)
+	)
+
+	diff, err := diffSchemas(from, to)
+	require.NoError(t, err)
+	require.Equal(t, []ColumnChange{
+		{FieldId: 1, Name: "id", Change: "type_changed", From: "int", To: "long"},
+		{FieldId: 1, Name: "id", Change: "nullability_changed", From: "required", To: "optional"},
+		{FieldId: 2, Name: "kind", Change: "renamed", From: "category", To: "kind"},
+	}, diff.Changes)
+}
@@ -0,0 +1,367 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go/table"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/sourcegraph/conc/pool"
+)
+
+const (
+	HistoryExpireMaxSnapshotAgeMsKey     = "history.expire.max-snapshot-age-ms"
+	HistoryExpireMaxSnapshotAgeMsDefault = int64(5 * 24 * time.Hour / time.Millisecond)
+
+	HistoryExpireMinSnapshotsToKeepKey     = "history.expire.min-snapshots-to-keep"
+	HistoryExpireMinSnapshotsToKeepDefault = 1
+
+	HistoryExpireMaxRefAgeMsKey     = "history.expire.max-ref-age-ms"
+	HistoryExpireMaxRefAgeMsDefault = int64(math.MaxInt64)
+
+	// GcEnabledKey set to false prevents expire-snapshots from deleting files.
+	GcEnabledKey     = "gc.enabled"
+	GcEnabledDefault = true
+
+	ExpireSnapshotsConcurrencyDefault = 16
+)
+
+type ExpireSnapshotsConfig struct {
+	// OlderThan overrides the history.expire.max-snapshot-age-ms table property.
+	OlderThan time.Duration
+	// RetainLast overrides the history.expire.min-snapshots-to-keep table property.
+	RetainLast int
+	// DryRun only reports the snapshots and refs to remove and the files to delete.
+	DryRun bool
+	// Concurrency is the maximum number of concurrent file deletions, 16 by default.
+	Concurrency int
+}
+
+type ExpireSnapshotsResult struct {
+	RemovedRefs          []string `json:"removed_refs"`
+	ExpiredSnapshotIds   []int64  `json:"expired_snapshot_ids"`
+	DeletedManifestLists []string `json:"deleted_manifest_lists"`
+	DeletedManifests     []string `json:"deleted_manifests"`
+	// DeletedDataFiles holds the data and delete files.
+	DeletedDataFiles []string `json:"deleted_data_files"`
+}
+
+// ExpireSnapshots removes old snapshots and refs from the table metadata. Refs other than
+// main older than their max-ref-age-ms are dropped. The snapshots of the remaining tags
+// are kept, as are the head of each branch and its ancestors until both its
+// min-snapshots-to-keep and max-snapshot-age-ms are exceeded. Snapshots no ref points to
+// are kept until they are older than the table max snapshot age. Retention settings of a
+// ref take precedence over the configuration, which takes precedence over the
+// history.expire.* table properties.
+//
+// Once the expiration is committed, the manifest lists, manifests, data and delete files
+// of the expired snapshots that no retained snapshot uses are deleted, unless the
+// gc.enabled table property is false. Files that fail to be deleted are only logged: they
+// are left to remove-orphan-files.
+func ExpireSnapshots(ctx context.Context, tableLocation string, conf ExpireSnapshotsConfig) (*ExpireSnapshotsResult, error) {
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		md  = t.Metadata()
+		now = time.Now().UnixMilli()
+		res = ExpireSnapshotsResult{
+			RemovedRefs:          []string{},
+			ExpiredSnapshotIds:   []int64{},
+			DeletedManifestLists: []string{},
+			DeletedManifests:     []string{},
+			DeletedDataFiles:     []string{},
+		}
+	)
+
+	if conf.Concurrency <= 0 {
+		conf.Concurrency = ExpireSnapshotsConcurrencyDefault
+	}
+
+	gcEnabled, err := strconv.ParseBool(md.Properties().Get(GcEnabledKey, strconv.FormatBool(GcEnabledDefault)))
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s table property: %w", GcEnabledKey, err)
+	}
+
+	maxSnapshotAgeMs, err := int64Property(md, HistoryExpireMaxSnapshotAgeMsKey, HistoryExpireMaxSnapshotAgeMsDefault)
+
+	if err != nil {
+		return nil, err
+	}
+
+	minSnapshotsToKeep, err := int64Property(md, HistoryExpireMinSnapshotsToKeepKey, HistoryExpireMinSnapshotsToKeepDefault)
+
+	if err != nil {
+		return nil, err
+	}
+
+	maxRefAgeMs, err := int64Property(md, HistoryExpireMaxRefAgeMsKey, HistoryExpireMaxRefAgeMsDefault)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.OlderThan > 0 {
+		maxSnapshotAgeMs = conf.OlderThan.Milliseconds()
+	}
+
+	if conf.RetainLast > 0 {
+		minSnapshotsToKeep = int64(conf.RetainLast)
+	}
+
+	var (
+		requirements = []table.Requirement{table.AssertTableUUID(md.TableUUID())}
+		updates      []table.Update
+		retained     = make(map[int64]bool)
+		referenced   = make(map[int64]bool)
+	)
+
+	for name, ref := range md.Refs() {
+		requirements = append(requirements, table.AssertRefSnapshotID(name, &ref.SnapshotID))
+
+		var snap = md.SnapshotByID(ref.SnapshotID)
+
+		if name != table.MainBranch && (snap == nil || now-snap.TimestampMs > refSetting(ref.MaxRefAgeMs, maxRefAgeMs)) {
+			res.RemovedRefs = append(res.RemovedRefs, name)
+			updates = append(updates, table.NewRemoveSnapshotRefUpdate(name))
+			continue
+		}
+
+		if snap == nil {
+			continue
+		}
+
+		if ref.SnapshotRefType == table.TagRef {
+			retained[snap.SnapshotID] = true
+			continue
+		}
+
+		var (
+			branchMaxSnapshotAgeMs   = refSetting(ref.MaxSnapshotAgeMs, maxSnapshotAgeMs)
+			branchMinSnapshotsToKeep = max(int64(refSetting(ref.MinSnapshotsToKeep, int(minSnapshotsToKeep))), 1)
+			count                    int64
+		)
+
+		for ; snap != nil; snap = parentSnapshot(md, snap) {
+			referenced[snap.SnapshotID] = true
+
+			if count < branchMinSnapshotsToKeep || now-snap.TimestampMs <= branchMaxSnapshotAgeMs {
+				retained[snap.SnapshotID] = true
+			}
+
+			count++
+		}
+	}
+
+	for _, snap := range md.Snapshots() {
+		if !referenced[snap.SnapshotID] && now-snap.TimestampMs <= maxSnapshotAgeMs {
+			retained[snap.SnapshotID] = true
+		}
+
+		if !retained[snap.SnapshotID] {
+			res.ExpiredSnapshotIds = append(res.ExpiredSnapshotIds, snap.SnapshotID)
+		}
+	}
+
+	if len(res.ExpiredSnapshotIds) > 0 {
+		if md.CurrentSnapshot() == nil {
+			return nil, fmt.Errorf("table has no current snapshot")
+		}
+
+		updates = append(updates, table.NewRemoveSnapshotsUpdate(res.ExpiredSnapshotIds))
+	}
+
+	slices.Sort(res.RemovedRefs)
+
+	if len(updates) == 0 {
+		return &res, nil
+	}
+
+	var (
+		io       = iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx))
+		expired  []table.Snapshot
+		toDelete expiredFiles
+	)
+
+	for _, id := range res.ExpiredSnapshotIds {
+		expired = append(expired, *md.SnapshotByID(id))
+	}
+
+	if gcEnabled && len(expired) > 0 {
+		builder, err := table.MetadataBuilderFromBase(md)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, update := range updates {
+			if err := update.Apply(builder); err != nil {
+				return nil, err
+			}
+		}
+
+		newMd, err := builder.Build()
+
+		if err != nil {
+			return nil, err
+		}
+
+		retained, err := ReachableFiles(
+			ctx,
+			table.New(t.Identifier(), newMd, t.MetadataLocation(), t.FS, cat),
+			ReachableFilesConfig{AllSnapshots: true, IncludeDeleteFiles: true, LiveEntriesOnly: true},
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if toDelete, err = snapshotsFiles(io, expired); err != nil {
+			return nil, err
+		}
+
+		toDelete.ManifestLists = toDelete.ManifestLists.Difference(retained)
+		toDelete.Manifests = toDelete.Manifests.Difference(retained)
+		toDelete.DataFiles = toDelete.DataFiles.Difference(retained)
+	}
+
+	if conf.DryRun {
+		res.DeletedManifestLists = sortedSlice(toDelete.ManifestLists)
+		res.DeletedManifests = sortedSlice(toDelete.Manifests)
+		res.DeletedDataFiles = sortedSlice(toDelete.DataFiles)
+		return &res, nil
+	}
+
+	if _, _, err := cat.CommitTable(ctx, t, requirements, updates); err != nil {
+		return nil, err
+	}
+
+	res.DeletedManifestLists = removeFiles(ctx, io, toDelete.ManifestLists, conf.Concurrency)
+	res.DeletedManifests = removeFiles(ctx, io, toDelete.Manifests, conf.Concurrency)
+	res.DeletedDataFiles = removeFiles(ctx, io, toDelete.DataFiles, conf.Concurrency)
+
+	return &res, nil
+}
+
+type expiredFiles struct {
+	ManifestLists mapset.Set[string]
+	Manifests     mapset.Set[string]
+	DataFiles     mapset.Set[string]
+}
+
+// snapshotsFiles returns the files referenced by the given snapshots.
+func snapshotsFiles(io *iceio.ObjectStoreIO, snapshots []table.Snapshot) (expiredFiles, error) {
+	var res = expiredFiles{
+		ManifestLists: mapset.NewSet[string](),
+		Manifests:     mapset.NewSet[string](),
+		DataFiles:     mapset.NewSet[string](),
+	}
+
+	var p = pool.New().WithErrors()
+
+	for _, snap := range snapshots {
+		p.Go(func() error {
+			return addSnapshotFiles(io, snap, res)
+		})
+	}
+
+	return res, p.Wait()
+}
+
+// addSnapshotFiles adds the files of a snapshot, including the files it marks as deleted.
+func addSnapshotFiles(io *iceio.ObjectStoreIO, snap table.Snapshot, res expiredFiles) error {
+	res.ManifestLists.Add(snap.ManifestList)
+
+	mans, err := snap.Manifests(io)
+
+	if err != nil {
+		return err
+	}
+
+	for _, man := range mans {
+		res.Manifests.Add(man.FilePath())
+
+		entries, err := man.FetchEntries(io, false)
+
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			res.DataFiles.Add(entry.DataFile().FilePath())
+		}
+	}
+
+	return nil
+}
+
+// removeFiles deletes files with bounded concurrency and returns the sorted locations of
+// the deleted ones. Failures are logged.
+func removeFiles(ctx context.Context, io *iceio.ObjectStoreIO, files mapset.Set[string], concurrency int) []string {
+	var (
+		p       = pool.New().WithMaxGoroutines(concurrency)
+		deleted = mapset.NewSet[string]()
+	)
+
+	for _, loc := range sortedSlice(files) {
+		p.Go(func() {
+			if err := ctx.Err(); err != nil {
+				slog.Warn("failed to delete expired file", "location", loc, "error", err)
+				return
+			}
+
+			if err := io.Remove(loc); err != nil {
+				slog.Warn("failed to delete expired file", "location", loc, "error", err)
+				return
+			}
+
+			deleted.Add(loc)
+		})
+	}
+
+	p.Wait()
+	return sortedSlice(deleted)
+}
+
+func sortedSlice(s mapset.Set[string]) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	var res = s.ToSlice()
+	slices.Sort(res)
+	return res
+}
+
+func int64Property(md table.Metadata, key string, defaultValue int64) (int64, error) {
+	var s, found = md.Properties()[key]
+
+	if !found {
+		return defaultValue, nil
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s table property: %w", key, err)
+	}
+
+	return v, nil
+}
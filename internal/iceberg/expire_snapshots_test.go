@@ -0,0 +1,130 @@
+package iceberg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpireSnapshots(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	var load = func() *table.Table {
+		cat, err := NewVersionHintCatalog("file://" + dir)
+		require.NoError(t, err)
+
+		tbl, err := cat.LoadTable(ctx, nil, nil)
+		require.NoError(t, err)
+
+		return tbl
+	}
+
+	var snapshots []int64
+
+	for _, name := range []string{"1.parquet", "2.parquet", "3.parquet", "4.parquet"} {
+		writeTestParquetFile(t, dir, name, []int64{1}, []string{"a"})
+		require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{name}, nil, CreateOrAddFilesConfig{}))
+		snapshots = append(snapshots, load().CurrentSnapshot().SnapshotID)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// snapshots are younger than the default max snapshot age
+	res, err := ExpireSnapshots(ctx, "file://"+dir, ExpireSnapshotsConfig{})
+	require.NoError(t, err)
+	require.Empty(t, res.ExpiredSnapshotIds)
+
+	_, err = CreateTag(ctx, "file://"+dir, "v1", RefConfig{SnapshotId: snapshots[0]})
+	require.NoError(t, err)
+
+	_, err = CreateTag(ctx, "file://"+dir, "old", RefConfig{SnapshotId: snapshots[0], MaxRefAgeMs: 1})
+	require.NoError(t, err)
+
+	_, err = CreateBranch(ctx, "file://"+dir, "audit", RefConfig{SnapshotId: snapshots[2], MinSnapshotsToKeep: 2})
+	require.NoError(t, err)
+
+	time.Sleep(2 * time.Millisecond)
+
+	res, err = ExpireSnapshots(ctx, "file://"+dir, ExpireSnapshotsConfig{OlderThan: time.Millisecond})
+	require.NoError(t, err)
+	require.Equal(t, []string{"old"}, res.RemovedRefs)
+	// snapshots[0] is tagged, snapshots[1..2] are kept by the audit branch
+	require.Empty(t, res.ExpiredSnapshotIds)
+
+	_, err = DropRef(ctx, "file://"+dir, "audit")
+	require.NoError(t, err)
+
+	res, err = ExpireSnapshots(ctx, "file://"+dir, ExpireSnapshotsConfig{OlderThan: time.Millisecond, RetainLast: 2})
+	require.NoError(t, err)
+	require.Equal(t, []int64{snapshots[1]}, res.ExpiredSnapshotIds)
+	require.Len(t, load().Metadata().Snapshots(), 3)
+	require.Equal(t, snapshots[3], load().CurrentSnapshot().SnapshotID)
+	require.NotNil(t, load().Metadata().SnapshotByName("v1"))
+}
+
+func TestExpireSnapshotsDeletesFiles(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	for _, name := range []string{"1.parquet", "2.parquet", "3.parquet"} {
+		writeTestParquetFile(t, dir, name, []int64{1}, []string{"a"})
+	}
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, []string{"1.parquet"}, []string{"2.parquet"}, nil, ReplaceFilesConfig{}))
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"3.parquet"}, nil, CreateOrAddFilesConfig{}))
+	time.Sleep(2 * time.Millisecond)
+
+	var conf = ExpireSnapshotsConfig{OlderThan: time.Millisecond, DryRun: true}
+
+	res, err := ExpireSnapshots(ctx, "file://"+dir, conf)
+	require.NoError(t, err)
+	require.Len(t, res.ExpiredSnapshotIds, 2)
+	require.Equal(t, []string{"file://" + filepath.Join(dir, "data", "1.parquet")}, res.DeletedDataFiles)
+	require.Len(t, res.DeletedManifestLists, 2)
+	// the manifests of the second snapshot are inherited by the current one
+	require.Len(t, res.DeletedManifests, 1)
+	require.FileExists(t, filepath.Join(dir, "data", "1.parquet"))
+
+	conf.DryRun = false
+
+	committed, err := ExpireSnapshots(ctx, "file://"+dir, conf)
+	require.NoError(t, err)
+	require.Equal(t, res, committed)
+	require.NoFileExists(t, filepath.Join(dir, "data", "1.parquet"))
+
+	for _, loc := range append(res.DeletedManifestLists, res.DeletedManifests...) {
+		require.NoFileExists(t, loc[len("file://"):])
+	}
+
+	require.Len(t, currentTestDataFiles(t, ctx, dir), 2)
+}
+
+func TestExpireSnapshotsGcDisabled(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1}, []string{"a"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{1}, []string{"a"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, iceberg.Properties{GcEnabledKey: "false"}, CreateOrAddFilesConfig{}))
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, []string{"1.parquet"}, []string{"2.parquet"}, nil, ReplaceFilesConfig{}))
+	time.Sleep(2 * time.Millisecond)
+
+	res, err := ExpireSnapshots(ctx, "file://"+dir, ExpireSnapshotsConfig{OlderThan: time.Millisecond})
+	require.NoError(t, err)
+	require.Len(t, res.ExpiredSnapshotIds, 1)
+	require.Empty(t, res.DeletedDataFiles)
+	require.FileExists(t, filepath.Join(dir, "data", "1.parquet"))
+}
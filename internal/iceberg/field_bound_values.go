@@ -3,11 +3,17 @@ package iceberg
 import (
 	"context"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"strconv"
+	"time"
 
 	"github.com/agnosticeng/icepq/internal/io"
 	"github.com/agnosticeng/objstr"
 	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/google/uuid"
 	"github.com/samber/lo"
 	"github.com/sourcegraph/conc/iter"
 )
@@ -21,20 +27,82 @@ type FieldBoundValuesItem struct {
 	Upper     any    `json:"upper"`
 }
 
+// DecodeBoundValue decodes a lower or upper bound of a data file to a JSON-friendly value:
+// booleans and numbers are kept as is (non-finite floats are formatted as strings), dates,
+// times and timestamps are formatted as ISO 8601 strings, decimals as decimal strings,
+// UUIDs as canonical strings and fixed or binary values as hex strings. The timestamp_ns
+// and timestamptz_ns types of format version 3 are not supported by iceberg-go yet, so the
+// tables using them cannot be loaded and their bounds are never decoded here.
 func DecodeBoundValue(field iceberg.NestedField, v []byte) (any, error) {
-	if len(v) == 0 {
+	if v == nil {
 		return nil, nil
 	}
 
-	switch field.Type {
-	case iceberg.Int64Type{}:
-		return int64(binary.LittleEndian.Uint64(v)), nil
+	lit, err := boundLiteralFromBytes(field.Type, v)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode bound value of field %s (%s): %w", field.Name, field.Type, err)
+	}
+
+	return jsonLiteralValue(lit, field.Type), nil
+}
+
+// boundLiteralFromBytes decodes a serialized bound. Bounds written before an int column
+// was promoted to long, or a float column to double, keep their 4-byte encoding, so they
+// are widened to the current type of the column.
+func boundLiteralFromBytes(typ iceberg.Type, v []byte) (iceberg.Literal, error) {
+	if len(v) == 4 {
+		switch typ.(type) {
+		case iceberg.Int64Type:
+			return iceberg.Int64Literal(int32(binary.LittleEndian.Uint32(v))), nil
+		case iceberg.Float64Type:
+			return iceberg.Float64Literal(math.Float32frombits(binary.LittleEndian.Uint32(v))), nil
+		}
+	}
+
+	return iceberg.LiteralFromBytes(typ, v)
+}
+
+func jsonLiteralValue(lit iceberg.Literal, typ iceberg.Type) any {
+	switch l := lit.(type) {
+	case iceberg.Float32Literal:
+		return jsonFloatValue(float64(l), float32(l))
+	case iceberg.Float64Literal:
+		return jsonFloatValue(float64(l), float64(l))
+	case iceberg.DateLiteral:
+		return iceberg.Date(l).ToTime().Format(time.DateOnly)
+	case iceberg.TimeLiteral:
+		return iceberg.Time(l).ToTime().Format("15:04:05.999999")
+	case iceberg.TimestampLiteral:
+		if _, tz := typ.(iceberg.TimestampTzType); tz {
+			return iceberg.Timestamp(l).ToTime().UTC().Format(time.RFC3339Nano)
+		}
+
+		return iceberg.Timestamp(l).ToTime().UTC().Format("2006-01-02T15:04:05.999999")
+	case iceberg.DecimalLiteral:
+		return l.Val.ToString(int32(l.Scale))
+	case iceberg.UUIDLiteral:
+		return uuid.UUID(l).String()
+	case iceberg.BinaryLiteral:
+		return hex.EncodeToString(l)
+	case iceberg.FixedLiteral:
+		return hex.EncodeToString(l)
 	default:
-		return nil, fmt.Errorf("unsupported type: %s", field.Type.String())
+		return lit.Any()
 	}
 }
 
+func jsonFloatValue[T float32 | float64](f float64, v T) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+
+	return v
+}
+
 type FieldBoundValuesConfig struct {
+	// SnapshotId selects the snapshot to read; the current snapshot is read when zero.
+	SnapshotId          int64
 	FailOnDeleteFiles   bool
 	FailOnMissingValues bool
 }
@@ -45,6 +113,49 @@ func FieldBoundValues(
 	fieldName string,
 	conf FieldBoundValuesConfig,
 ) ([]FieldBoundValuesItem, error) {
+	field, _, bounds, err := fieldBounds(ctx, tableLocation, fieldName, conf, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(bounds, func(b fieldBound, _ int) FieldBoundValuesItem {
+		var item = FieldBoundValuesItem{
+			FieldName: field.Name,
+			FieldId:   field.ID,
+			FilePath:  b.filePath,
+			FileCount: b.count,
+		}
+
+		if b.lower != nil {
+			item.Lower = jsonLiteralValue(b.lower, field.Type)
+		}
+
+		if b.upper != nil {
+			item.Upper = jsonLiteralValue(b.upper, field.Type)
+		}
+
+		return item
+	}), nil
+}
+
+// fieldBound holds the decoded bounds of a field for a data file. Missing bounds are nil.
+type fieldBound struct {
+	filePath string
+	count    int64
+	lower    iceberg.Literal
+	upper    iceberg.Literal
+}
+
+// fieldBounds decodes the bounds of a field for the files of a snapshot. When liveDataOnly
+// is set, the deleted manifest entries and the delete files are skipped; otherwise every
+// entry is returned, as the field-bound-values command always did.
+func fieldBounds(
+	ctx context.Context,
+	tableLocation string,
+	fieldName string,
+	conf FieldBoundValuesConfig,
+	liveDataOnly bool,
+) (iceberg.NestedField, *table.Snapshot, []fieldBound, error) {
 	var (
 		os = objstr.FromContextOrDefault(ctx)
 		io = io.NewObjectStoreIO(os)
@@ -52,72 +163,101 @@ func FieldBoundValues(
 
 	cat, err := NewVersionHintCatalog(tableLocation)
 	if err != nil {
-		return nil, err
+		return iceberg.NestedField{}, nil, nil, err
 	}
 
 	t, err := cat.LoadTable(ctx, nil, nil)
 	if err != nil {
-		return nil, err
+		return iceberg.NestedField{}, nil, nil, err
+	}
+
+	var snap = t.CurrentSnapshot()
+
+	if conf.SnapshotId != 0 {
+		if snap = t.SnapshotByID(conf.SnapshotId); snap == nil {
+			return iceberg.NestedField{}, nil, nil, fmt.Errorf("snapshot %d not found", conf.SnapshotId)
+		}
 	}
 
-	field, found := t.Schema().FindFieldByName(fieldName)
+	// the field is resolved against the schema the snapshot was written with
+	var sch = t.Schema()
+
+	if snap != nil && snap.SchemaID != nil {
+		if s, found := lo.Find(t.Metadata().Schemas(), func(s *iceberg.Schema) bool { return s.ID == *snap.SchemaID }); found {
+			sch = s
+		}
+	}
+
+	field, found := sch.FindFieldByName(fieldName)
 	if !found {
-		return nil, fmt.Errorf("field %s not found", fieldName)
+		return iceberg.NestedField{}, nil, nil, fmt.Errorf("field %s not found", fieldName)
+	}
+
+	if snap == nil {
+		return field, nil, nil, nil
 	}
 
-	mans, err := t.CurrentSnapshot().Manifests(io)
+	mans, err := snap.Manifests(io)
 	if err != nil {
-		return nil, err
+		return iceberg.NestedField{}, nil, nil, err
 	}
 
-	res, err := iter.MapErr(mans, func(man *iceberg.ManifestFile) ([]FieldBoundValuesItem, error) {
-		entries, err := (*man).FetchEntries(io, false)
+	res, err := iter.MapErr(mans, func(man *iceberg.ManifestFile) ([]fieldBound, error) {
+		entries, err := (*man).FetchEntries(io, liveDataOnly)
 		if err != nil {
 			return nil, err
 		}
 
-		var res []FieldBoundValuesItem
+		var res []fieldBound
 
 		for _, entry := range entries {
-			var contentType = entry.DataFile().ContentType()
+			var (
+				df          = entry.DataFile()
+				contentType = df.ContentType()
+			)
 
-			if conf.FailOnDeleteFiles &&
-				(contentType == iceberg.EntryContentEqDeletes || contentType == iceberg.EntryContentPosDeletes) {
-				return nil, fmt.Errorf("snapshot has delete files")
-			}
+			if contentType == iceberg.EntryContentEqDeletes || contentType == iceberg.EntryContentPosDeletes {
+				if conf.FailOnDeleteFiles {
+					return nil, fmt.Errorf("snapshot has delete files")
+				}
 
-			upper, found := entry.DataFile().UpperBoundValues()[field.ID]
-			if conf.FailOnMissingValues && !found {
-				return nil, fmt.Errorf("upper bound value not found for field %s in datafile %s", fieldName, entry.DataFile().FilePath())
+				if liveDataOnly {
+					continue
+				}
 			}
 
-			lower, found := entry.DataFile().LowerBoundValues()[field.ID]
-			if conf.FailOnMissingValues && !found {
-				return nil, fmt.Errorf("lower bound value not found for field %s in datafile %s", fieldName, entry.DataFile().FilePath())
-			}
+			var b = fieldBound{filePath: df.FilePath(), count: df.Count()}
 
-			decodedLower, err := DecodeBoundValue(field, lower)
-			if err != nil {
-				return nil, err
-			}
+			for _, v := range []struct {
+				name   string
+				values map[int][]byte
+				dst    *iceberg.Literal
+			}{
+				{"lower", df.LowerBoundValues(), &b.lower},
+				{"upper", df.UpperBoundValues(), &b.upper},
+			} {
+				value, found := v.values[field.ID]
+				if !found {
+					if conf.FailOnMissingValues {
+						return nil, fmt.Errorf("%s bound value not found for field %s in datafile %s", v.name, fieldName, df.FilePath())
+					}
+
+					continue
+				}
+
+				lit, err := boundLiteralFromBytes(field.Type, value)
+				if err != nil {
+					return nil, fmt.Errorf("cannot decode bound value of field %s (%s): %w", field.Name, field.Type, err)
+				}
 
-			decodedUpper, err := DecodeBoundValue(field, upper)
-			if err != nil {
-				return nil, err
+				*v.dst = lit
 			}
 
-			res = append(res, FieldBoundValuesItem{
-				FieldName: field.Name,
-				FieldId:   field.ID,
-				FilePath:  entry.DataFile().FilePath(),
-				FileCount: entry.DataFile().Count(),
-				Lower:     decodedLower,
-				Upper:     decodedUpper,
-			})
+			res = append(res, b)
 		}
 
 		return res, nil
 	})
 
-	return lo.Flatten(res), err
+	return field, snap, lo.Flatten(res), err
 }
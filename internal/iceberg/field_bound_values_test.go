@@ -0,0 +1,82 @@
+package iceberg
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/iceberg-go"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBoundValue(t *testing.T) {
+	var (
+		ts = time.Date(2024, 3, 1, 12, 30, 45, 123456000, time.UTC)
+		id = uuid.MustParse("0191d5e6-7f3a-7c2e-9b1a-2f4e6d8c0a11")
+	)
+
+	var tests = []struct {
+		typ      iceberg.Type
+		lit      iceberg.Literal
+		expected any
+	}{
+		{iceberg.PrimitiveTypes.Bool, iceberg.NewLiteral(true), true},
+		{iceberg.PrimitiveTypes.Int32, iceberg.NewLiteral(int32(-7)), int32(-7)},
+		{iceberg.PrimitiveTypes.Int64, iceberg.NewLiteral(int64(1) << 40), int64(1) << 40},
+		{iceberg.PrimitiveTypes.Float32, iceberg.NewLiteral(float32(1.5)), float32(1.5)},
+		{iceberg.PrimitiveTypes.Float64, iceberg.NewLiteral(2.25), 2.25},
+		{iceberg.PrimitiveTypes.Float64, iceberg.NewLiteral(math.Inf(-1)), "-Inf"},
+		{iceberg.PrimitiveTypes.Date, iceberg.NewLiteral(iceberg.Date(19783)), "2024-03-01"},
+		{iceberg.PrimitiveTypes.Time, iceberg.NewLiteral(iceberg.Time(45045123456)), "12:30:45.123456"},
+		{iceberg.PrimitiveTypes.Timestamp, iceberg.NewLiteral(iceberg.Timestamp(ts.UnixMicro())), "2024-03-01T12:30:45.123456"},
+		{iceberg.PrimitiveTypes.TimestampTz, iceberg.NewLiteral(iceberg.Timestamp(ts.UnixMicro())), "2024-03-01T12:30:45.123456Z"},
+		{iceberg.PrimitiveTypes.String, iceberg.NewLiteral("héllo"), "héllo"},
+		{iceberg.PrimitiveTypes.String, iceberg.NewLiteral(""), ""},
+		{iceberg.PrimitiveTypes.UUID, iceberg.NewLiteral(id), id.String()},
+		{iceberg.FixedTypeOf(2), iceberg.FixedLiteral{0xca, 0xfe}, "cafe"},
+		{iceberg.PrimitiveTypes.Binary, iceberg.NewLiteral([]byte{0x01, 0xff}), "01ff"},
+		{iceberg.DecimalTypeOf(10, 2), iceberg.DecimalLiteral{Val: decimal128.FromI64(-12345), Scale: 2}, "-123.45"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.typ.String(), func(t *testing.T) {
+			b, err := test.lit.MarshalBinary()
+			require.NoError(t, err)
+
+			if b == nil {
+				// an empty bound is not a missing bound
+				b = []byte{}
+			}
+
+			v, err := DecodeBoundValue(iceberg.NestedField{Name: "col", Type: test.typ}, b)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v)
+		})
+	}
+
+	// bounds written before the column was promoted keep their former encoding
+	for _, test := range []struct {
+		typ      iceberg.Type
+		lit      iceberg.Literal
+		expected any
+	}{
+		{iceberg.PrimitiveTypes.Int64, iceberg.NewLiteral(int32(-7)), int64(-7)},
+		{iceberg.PrimitiveTypes.Float64, iceberg.NewLiteral(float32(1.5)), 1.5},
+	} {
+		b, err := test.lit.MarshalBinary()
+		require.NoError(t, err)
+
+		v, err := DecodeBoundValue(iceberg.NestedField{Name: "col", Type: test.typ}, b)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, v)
+	}
+
+	v, err := DecodeBoundValue(iceberg.NestedField{Name: "col", Type: iceberg.PrimitiveTypes.Int64}, nil)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	_, err = DecodeBoundValue(iceberg.NestedField{Name: "col", Type: iceberg.PrimitiveTypes.Int64}, []byte{1})
+	require.Error(t, err)
+}
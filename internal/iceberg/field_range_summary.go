@@ -0,0 +1,157 @@
+package iceberg
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/apache/iceberg-go"
+)
+
+// FieldRangeGap is a range of values covered by no data file, between the upper bound
+// After of a file and the lower bound Before of the next one (both excluded).
+type FieldRangeGap struct {
+	After  any `json:"after"`
+	Before any `json:"before"`
+}
+
+// FieldRangeOverlap is the range of values covered by the bounds of two data files.
+type FieldRangeOverlap struct {
+	FilePaths [2]string `json:"file_paths"`
+	Lower     any       `json:"lower"`
+	Upper     any       `json:"upper"`
+}
+
+type FieldRangeSummaryReport struct {
+	FieldName   string `json:"field_name"`
+	FieldId     int    `json:"field_id"`
+	SnapshotId  int64  `json:"snapshot_id"`
+	FileCount   int    `json:"file_count"`
+	RecordCount int64  `json:"record_count"`
+	Lower       any    `json:"lower"`
+	Upper       any    `json:"upper"`
+	// FilesWithoutBounds counts the data files missing a bound for the field, which are
+	// ignored when computing gaps and overlaps.
+	FilesWithoutBounds int                 `json:"files_without_bounds"`
+	Gaps               []FieldRangeGap     `json:"gaps"`
+	Overlaps           []FieldRangeOverlap `json:"overlaps"`
+}
+
+// FieldRangeSummary aggregates the bounds of a field over the data files of a snapshot:
+// it returns the global lower and upper bounds, the gaps between the file ranges and the
+// pairs of files whose ranges overlap. For integer and date fields, a file range starting
+// right after the end of another one (e.g. blocks 0-999 and 1000-1999) is not a gap.
+//
+// Bounds are inclusive and may be truncated for string and binary fields, in which case
+// gaps can be missed and overlaps reported for files sharing a common prefix.
+func FieldRangeSummary(
+	ctx context.Context,
+	tableLocation string,
+	fieldName string,
+	conf FieldBoundValuesConfig,
+) (*FieldRangeSummaryReport, error) {
+	field, snap, bounds, err := fieldBounds(ctx, tableLocation, fieldName, conf, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var report = FieldRangeSummaryReport{
+		FieldName: field.Name,
+		FieldId:   field.ID,
+		Gaps:      []FieldRangeGap{},
+		Overlaps:  []FieldRangeOverlap{},
+	}
+
+	if snap != nil {
+		report.SnapshotId = snap.SnapshotID
+	}
+
+	var ranges []fieldBound
+
+	for _, b := range bounds {
+		report.FileCount++
+		report.RecordCount += b.count
+
+		if b.lower == nil || b.upper == nil {
+			report.FilesWithoutBounds++
+			continue
+		}
+
+		ranges = append(ranges, b)
+	}
+
+	if len(ranges) == 0 {
+		return &report, nil
+	}
+
+	slices.SortFunc(ranges, func(a, b fieldBound) int {
+		if c := compareLiterals(a.lower, b.lower); c != 0 {
+			return c
+		}
+
+		if c := compareLiterals(a.upper, b.upper); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.filePath, b.filePath)
+	})
+
+	var (
+		value   = func(lit iceberg.Literal) any { return jsonLiteralValue(lit, field.Type) }
+		covered = ranges[0].upper
+		active  []fieldBound
+	)
+
+	for i, r := range ranges {
+		if i > 0 && compareLiterals(r.lower, covered) > 0 && !consecutiveLiterals(covered, r.lower) {
+			report.Gaps = append(report.Gaps, FieldRangeGap{After: value(covered), Before: value(r.lower)})
+		}
+
+		if compareLiterals(r.upper, covered) > 0 {
+			covered = r.upper
+		}
+
+		// ranges are sorted by lower bound, so a file overlaps with the previous files
+		// whose upper bound is not below its lower bound
+		active = slices.DeleteFunc(active, func(a fieldBound) bool { return compareLiterals(a.upper, r.lower) < 0 })
+
+		for _, a := range active {
+			var upper = a.upper
+
+			if compareLiterals(r.upper, upper) < 0 {
+				upper = r.upper
+			}
+
+			report.Overlaps = append(report.Overlaps, FieldRangeOverlap{
+				FilePaths: [2]string{a.filePath, r.filePath},
+				Lower:     value(r.lower),
+				Upper:     value(upper),
+			})
+		}
+
+		active = append(active, r)
+	}
+
+	report.Lower = value(ranges[0].lower)
+	report.Upper = value(covered)
+
+	return &report, nil
+}
+
+// consecutiveLiterals reports whether b directly follows a for discrete types.
+func consecutiveLiterals(a iceberg.Literal, b iceberg.Literal) bool {
+	switch a := a.(type) {
+	case iceberg.Int32Literal:
+		b, ok := b.(iceberg.Int32Literal)
+		return ok && int64(b)-int64(a) == 1
+	case iceberg.Int64Literal:
+		b, ok := b.(iceberg.Int64Literal)
+		return ok && a < b && b-a == 1
+	case iceberg.DateLiteral:
+		b, ok := b.(iceberg.DateLiteral)
+		return ok && int64(b)-int64(a) == 1
+	default:
+		return false
+	}
+}
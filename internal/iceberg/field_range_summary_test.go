@@ -0,0 +1,64 @@
+package iceberg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldRangeSummary(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{0, 9}, []string{"a", "a"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{10, 19}, []string{"b", "b"})
+	writeTestParquetFile(t, dir, "3.parquet", []int64{25, 30}, []string{"c", "c"})
+	writeTestParquetFile(t, dir, "4.parquet", []int64{28, 40}, []string{"d", "d"})
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet", "3.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	var firstSnapshotID = tbl.CurrentSnapshot().SnapshotID
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"4.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	report, err := FieldRangeSummary(ctx, "file://"+dir, "id", FieldBoundValuesConfig{})
+	require.NoError(t, err)
+	require.Equal(t, 4, report.FileCount)
+	require.Equal(t, int64(8), report.RecordCount)
+	require.Equal(t, int64(0), report.Lower)
+	require.Equal(t, int64(40), report.Upper)
+	require.Equal(t, []FieldRangeGap{{After: int64(19), Before: int64(25)}}, report.Gaps)
+	require.Len(t, report.Overlaps, 1)
+	require.Equal(t, "3.parquet", filepath.Base(report.Overlaps[0].FilePaths[0]))
+	require.Equal(t, "4.parquet", filepath.Base(report.Overlaps[0].FilePaths[1]))
+	require.Equal(t, int64(28), report.Overlaps[0].Lower)
+	require.Equal(t, int64(30), report.Overlaps[0].Upper)
+
+	report, err = FieldRangeSummary(ctx, "file://"+dir, "id", FieldBoundValuesConfig{SnapshotId: firstSnapshotID})
+	require.NoError(t, err)
+	require.Equal(t, firstSnapshotID, report.SnapshotId)
+	require.Equal(t, int64(30), report.Upper)
+	require.Empty(t, report.Overlaps)
+
+	// string ranges are not discrete: adjacent values still leave a gap
+	report, err = FieldRangeSummary(ctx, "file://"+dir, "category", FieldBoundValuesConfig{})
+	require.NoError(t, err)
+	require.Equal(t, []FieldRangeGap{{After: "a", Before: "b"}, {After: "b", Before: "c"}, {After: "c", Before: "d"}}, report.Gaps)
+	require.Empty(t, report.Overlaps)
+
+	items, err := FieldBoundValues(ctx, "file://"+dir, "id", FieldBoundValuesConfig{FailOnMissingValues: true})
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+
+	_, err = FieldRangeSummary(ctx, "file://"+dir, "id", FieldBoundValuesConfig{SnapshotId: 1})
+	require.ErrorContains(t, err, "snapshot 1 not found")
+}
@@ -0,0 +1,450 @@
+package iceberg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/apache/iceberg-go"
+)
+
+// ParseFilterExpr parses an Iceberg-style row filter such as
+// `block_number >= 1000 AND date = '2024-01-01'` into an unbound boolean expression.
+//
+// Predicates compare a column (dot-separated path, optionally double-quoted or
+// backquoted) with a literal using =, !=, <>, <, <=, > or >=, or use one of
+// IN (...), NOT IN (...), IS [NOT] NULL, IS [NOT] NAN and [NOT] LIKE 'prefix%'.
+// Predicates are combined with AND, OR, NOT and parentheses. String literals are
+// single-quoted and converted to the column type when the expression is bound, so that
+// dates, timestamps, UUIDs and decimals are written as strings. An empty filter matches
+// every row.
+func ParseFilterExpr(s string) (iceberg.BooleanExpression, error) {
+	tokens, err := lexFilterExpr(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var p = filterParser{tokens: tokens}
+
+	if p.peek().kind == filterTokenEOF {
+		return iceberg.AlwaysTrue{}, nil
+	}
+
+	expr, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != filterTokenEOF {
+		return nil, fmt.Errorf("invalid filter: unexpected %s at position %d", tok, tok.pos)
+	}
+
+	return expr, nil
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	filterTokenIdent
+	filterTokenQuotedIdent
+	filterTokenString
+	filterTokenNumber
+	filterTokenOperator
+	filterTokenLParen
+	filterTokenRParen
+	filterTokenComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+func (tok filterToken) String() string {
+	if tok.kind == filterTokenEOF {
+		return "end of filter"
+	}
+
+	return strconv.Quote(tok.text)
+}
+
+// keyword reports whether the token is the given unquoted keyword, case-insensitively.
+func (tok filterToken) keyword(kw string) bool {
+	return tok.kind == filterTokenIdent && strings.EqualFold(tok.text, kw)
+}
+
+func lexFilterExpr(s string) ([]filterToken, error) {
+	var (
+		runes = []rune(s)
+		res   []filterToken
+	)
+
+	for i := 0; i < len(runes); {
+		var r = runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			res = append(res, filterToken{kind: filterTokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			res = append(res, filterToken{kind: filterTokenRParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			res = append(res, filterToken{kind: filterTokenComma, text: ",", pos: i})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			var j = i + 1
+
+			for j < len(runes) && strings.ContainsRune("=<>", runes[j]) {
+				j++
+			}
+
+			var op = string(runes[i:j])
+
+			switch op {
+			case "=", "==", "!=", "<>", "<", "<=", ">", ">=":
+			default:
+				return nil, fmt.Errorf("invalid filter: unknown operator %s at position %d", op, i)
+			}
+
+			res = append(res, filterToken{kind: filterTokenOperator, text: op, pos: i})
+			i = j
+		case r == '\'' || r == '"' || r == '`':
+			var (
+				sb strings.Builder
+				j  = i + 1
+			)
+
+			for {
+				if j >= len(runes) {
+					return nil, fmt.Errorf("invalid filter: unterminated quote at position %d", i)
+				}
+
+				// the quote character is escaped by doubling it
+				if runes[j] == r {
+					if j+1 < len(runes) && runes[j+1] == r {
+						sb.WriteRune(r)
+						j += 2
+						continue
+					}
+
+					break
+				}
+
+				sb.WriteRune(runes[j])
+				j++
+			}
+
+			var kind = filterTokenQuotedIdent
+
+			if r == '\'' {
+				kind = filterTokenString
+			}
+
+			res = append(res, filterToken{kind: kind, text: sb.String(), pos: i})
+			i = j + 1
+		case r == '-' || r == '+' || unicode.IsDigit(r):
+			var j = i + 1
+
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || strings.ContainsRune(".eE", runes[j]) ||
+				(strings.ContainsRune("+-", runes[j]) && strings.ContainsRune("eE", runes[j-1]))) {
+				j++
+			}
+
+			res = append(res, filterToken{kind: filterTokenNumber, text: string(runes[i:j]), pos: i})
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			var j = i + 1
+
+			for j < len(runes) && (runes[j] == '_' || runes[j] == '.' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+
+			res = append(res, filterToken{kind: filterTokenIdent, text: string(runes[i:j]), pos: i})
+			i = j
+		default:
+			return nil, fmt.Errorf("invalid filter: unexpected character %q at position %d", r, i)
+		}
+	}
+
+	return append(res, filterToken{kind: filterTokenEOF, pos: len(runes)}), nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	var tok = p.tokens[p.pos]
+
+	if tok.kind != filterTokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *filterParser) expect(kind filterTokenKind, what string) (filterToken, error) {
+	var tok = p.next()
+
+	if tok.kind != kind {
+		return tok, fmt.Errorf("invalid filter: expected %s at position %d, got %s", what, tok.pos, tok)
+	}
+
+	return tok, nil
+}
+
+func (p *filterParser) expectKeyword(kw string) error {
+	if tok := p.next(); !tok.keyword(kw) {
+		return fmt.Errorf("invalid filter: expected %s at position %d, got %s", kw, tok.pos, tok)
+	}
+
+	return nil
+}
+
+func (p *filterParser) parseOr() (iceberg.BooleanExpression, error) {
+	left, err := p.parseAnd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().keyword("or") {
+		p.next()
+
+		right, err := p.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = iceberg.NewOr(left, right)
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (iceberg.BooleanExpression, error) {
+	left, err := p.parseNot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().keyword("and") {
+		p.next()
+
+		right, err := p.parseNot()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = iceberg.NewAnd(left, right)
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (iceberg.BooleanExpression, error) {
+	if !p.peek().keyword("not") {
+		return p.parsePrimary()
+	}
+
+	p.next()
+
+	child, err := p.parseNot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return iceberg.NewNot(child), nil
+}
+
+func (p *filterParser) parsePrimary() (iceberg.BooleanExpression, error) {
+	var tok = p.peek()
+
+	switch {
+	case tok.kind == filterTokenLParen:
+		p.next()
+
+		expr, err := p.parseOr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(filterTokenRParen, ")"); err != nil {
+			return nil, err
+		}
+
+		return expr, nil
+	case tok.keyword("true"):
+		p.next()
+		return iceberg.AlwaysTrue{}, nil
+	case tok.keyword("false"):
+		p.next()
+		return iceberg.AlwaysFalse{}, nil
+	case tok.kind == filterTokenIdent || tok.kind == filterTokenQuotedIdent:
+		return p.parsePredicate()
+	default:
+		return nil, fmt.Errorf("invalid filter: expected a column or ( at position %d, got %s", tok.pos, tok)
+	}
+}
+
+func (p *filterParser) parsePredicate() (iceberg.BooleanExpression, error) {
+	var (
+		ref = iceberg.Reference(p.next().text)
+		tok = p.next()
+	)
+
+	switch {
+	case tok.kind == filterTokenOperator:
+		lit, err := p.parseLiteral()
+
+		if err != nil {
+			return nil, err
+		}
+
+		var op = map[string]iceberg.Operation{
+			"=":  iceberg.OpEQ,
+			"==": iceberg.OpEQ,
+			"!=": iceberg.OpNEQ,
+			"<>": iceberg.OpNEQ,
+			"<":  iceberg.OpLT,
+			"<=": iceberg.OpLTEQ,
+			">":  iceberg.OpGT,
+			">=": iceberg.OpGTEQ,
+		}[tok.text]
+
+		return iceberg.LiteralPredicate(op, ref, lit), nil
+	case tok.keyword("is"):
+		var negate = p.peek().keyword("not")
+
+		if negate {
+			p.next()
+		}
+
+		switch kw := p.next(); {
+		case kw.keyword("null") && negate:
+			return iceberg.NotNull(ref), nil
+		case kw.keyword("null"):
+			return iceberg.IsNull(ref), nil
+		case kw.keyword("nan") && negate:
+			return iceberg.NotNaN(ref), nil
+		case kw.keyword("nan"):
+			return iceberg.IsNaN(ref), nil
+		default:
+			return nil, fmt.Errorf("invalid filter: expected NULL or NAN at position %d, got %s", kw.pos, kw)
+		}
+	case tok.keyword("not"):
+		switch kw := p.next(); {
+		case kw.keyword("in"):
+			return p.parseIn(ref, iceberg.OpNotIn)
+		case kw.keyword("like"):
+			return p.parseLike(ref, true)
+		default:
+			return nil, fmt.Errorf("invalid filter: expected IN or LIKE at position %d, got %s", kw.pos, kw)
+		}
+	case tok.keyword("in"):
+		return p.parseIn(ref, iceberg.OpIn)
+	case tok.keyword("like"):
+		return p.parseLike(ref, false)
+	default:
+		return nil, fmt.Errorf("invalid filter: expected an operator after column %s at position %d, got %s", ref, tok.pos, tok)
+	}
+}
+
+func (p *filterParser) parseIn(ref iceberg.UnboundTerm, op iceberg.Operation) (iceberg.BooleanExpression, error) {
+	if _, err := p.expect(filterTokenLParen, "("); err != nil {
+		return nil, err
+	}
+
+	var lits []iceberg.Literal
+
+	for {
+		lit, err := p.parseLiteral()
+
+		if err != nil {
+			return nil, err
+		}
+
+		lits = append(lits, lit)
+
+		if p.peek().kind != filterTokenComma {
+			break
+		}
+
+		p.next()
+	}
+
+	if _, err := p.expect(filterTokenRParen, ")"); err != nil {
+		return nil, err
+	}
+
+	return iceberg.SetPredicate(op, ref, lits), nil
+}
+
+// parseLike only supports prefix patterns, which are the ones Iceberg can evaluate
+// against column bounds.
+func (p *filterParser) parseLike(ref iceberg.UnboundTerm, negate bool) (iceberg.BooleanExpression, error) {
+	tok, err := p.expect(filterTokenString, "a string pattern")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var prefix, found = strings.CutSuffix(tok.text, "%")
+
+	if !found || strings.ContainsAny(prefix, "%_") {
+		return nil, fmt.Errorf("invalid filter: only prefix LIKE patterns ('abc%%') are supported, got '%s'", tok.text)
+	}
+
+	if negate {
+		return iceberg.NotStartsWith(ref, prefix), nil
+	}
+
+	return iceberg.StartsWith(ref, prefix), nil
+}
+
+func (p *filterParser) parseLiteral() (iceberg.Literal, error) {
+	var tok = p.next()
+
+	switch {
+	case tok.kind == filterTokenString:
+		return iceberg.NewLiteral(tok.text), nil
+	case tok.kind == filterTokenNumber:
+		if v, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return iceberg.NewLiteral(v), nil
+		}
+
+		v, err := strconv.ParseFloat(tok.text, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: invalid number %s at position %d", tok.text, tok.pos)
+		}
+
+		return iceberg.NewLiteral(v), nil
+	case tok.keyword("true"):
+		return iceberg.NewLiteral(true), nil
+	case tok.keyword("false"):
+		return iceberg.NewLiteral(false), nil
+	case tok.keyword("null"):
+		return nil, fmt.Errorf("invalid filter: use IS NULL or IS NOT NULL to compare with NULL at position %d", tok.pos)
+	default:
+		return nil, fmt.Errorf("invalid filter: expected a literal at position %d, got %s", tok.pos, tok)
+	}
+}
@@ -0,0 +1,61 @@
+package iceberg
+
+import (
+	"testing"
+
+	"github.com/apache/iceberg-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilterExpr(t *testing.T) {
+	var tests = []struct {
+		filter   string
+		expected iceberg.BooleanExpression
+	}{
+		{"", iceberg.AlwaysTrue{}},
+		{"block_number >= 1000", iceberg.GreaterThanEqual(iceberg.Reference("block_number"), int64(1000))},
+		{
+			"block_number >= 1000 AND date = '2024-01-01'",
+			iceberg.NewAnd(
+				iceberg.GreaterThanEqual(iceberg.Reference("block_number"), int64(1000)),
+				iceberg.EqualTo(iceberg.Reference("date"), "2024-01-01"),
+			),
+		},
+		{
+			"a = 1 or b <> 'x' and not c < -2.5",
+			iceberg.NewOr(
+				iceberg.EqualTo(iceberg.Reference("a"), int64(1)),
+				iceberg.NewAnd(
+					iceberg.NotEqualTo(iceberg.Reference("b"), "x"),
+					iceberg.NewNot(iceberg.LessThan(iceberg.Reference("c"), -2.5)),
+				),
+			),
+		},
+		{"(a = 1 OR a = 2) AND b", nil},
+		{"\"my col\" IN ('it''s', 'b')", iceberg.IsIn(iceberg.Reference("my col"), "it's", "b")},
+		{"s.x NOT IN (1, 2)", iceberg.NotIn(iceberg.Reference("s.x"), int64(1), int64(2))},
+		{"x IS NULL", iceberg.IsNull(iceberg.Reference("x"))},
+		{"x is not nan", iceberg.NotNaN(iceberg.Reference("x"))},
+		{"name LIKE 'ab%'", iceberg.StartsWith(iceberg.Reference("name"), "ab")},
+		{"name NOT LIKE 'ab%'", iceberg.NotStartsWith(iceberg.Reference("name"), "ab")},
+		{"name LIKE '%ab'", nil},
+		{"x = NULL", nil},
+		{"x = 1 y", nil},
+		{"x ! 1", nil},
+		{"x = 'open", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			expr, err := ParseFilterExpr(test.filter)
+
+			if test.expected == nil {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, test.expected.Equals(expr), "got %s", expr)
+		})
+	}
+}
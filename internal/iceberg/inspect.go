@@ -0,0 +1,571 @@
+package iceberg
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/iter"
+)
+
+// InspectTables lists the metadata tables that can be inspected. They mirror the metadata
+// tables of the Spark Iceberg runtime.
+var InspectTables = []string{"snapshots", "history", "refs", "manifests", "files", "entries", "partitions"}
+
+type InspectConfig struct {
+	// SnapshotId selects the snapshot whose manifests, files, entries and partitions are
+	// listed; the current snapshot is read when zero.
+	SnapshotId int64
+	// Ref selects the snapshot by branch or tag name instead.
+	Ref string
+}
+
+type SnapshotsRow struct {
+	CommittedAt  time.Time         `json:"committed_at"`
+	SnapshotId   int64             `json:"snapshot_id"`
+	ParentId     *int64            `json:"parent_id"`
+	Operation    string            `json:"operation"`
+	ManifestList string            `json:"manifest_list"`
+	Summary      map[string]string `json:"summary"`
+}
+
+type HistoryRow struct {
+	MadeCurrentAt     time.Time `json:"made_current_at"`
+	SnapshotId        int64     `json:"snapshot_id"`
+	ParentId          *int64    `json:"parent_id"`
+	IsCurrentAncestor bool      `json:"is_current_ancestor"`
+}
+
+type RefsRow struct {
+	Name                string `json:"name"`
+	Type                string `json:"type"`
+	SnapshotId          int64  `json:"snapshot_id"`
+	MaxReferenceAgeInMs *int64 `json:"max_reference_age_in_ms"`
+	MinSnapshotsToKeep  *int   `json:"min_snapshots_to_keep"`
+	MaxSnapshotAgeInMs  *int64 `json:"max_snapshot_age_in_ms"`
+}
+
+type PartitionFieldSummary struct {
+	ContainsNull bool  `json:"contains_null"`
+	ContainsNan  *bool `json:"contains_nan"`
+	LowerBound   any   `json:"lower_bound"`
+	UpperBound   any   `json:"upper_bound"`
+}
+
+type ManifestsRow struct {
+	Content                  int                     `json:"content"`
+	Path                     string                  `json:"path"`
+	Length                   int64                   `json:"length"`
+	PartitionSpecId          int32                   `json:"partition_spec_id"`
+	AddedSnapshotId          int64                   `json:"added_snapshot_id"`
+	AddedDataFilesCount      int32                   `json:"added_data_files_count"`
+	ExistingDataFilesCount   int32                   `json:"existing_data_files_count"`
+	DeletedDataFilesCount    int32                   `json:"deleted_data_files_count"`
+	AddedDeleteFilesCount    int32                   `json:"added_delete_files_count"`
+	ExistingDeleteFilesCount int32                   `json:"existing_delete_files_count"`
+	DeletedDeleteFilesCount  int32                   `json:"deleted_delete_files_count"`
+	PartitionSummaries       []PartitionFieldSummary `json:"partition_summaries"`
+}
+
+// FilesRow describes a data or delete file. Metrics are keyed by field ID and bounds are
+// decoded as by DecodeBoundValue.
+type FilesRow struct {
+	Content         int            `json:"content"`
+	FilePath        string         `json:"file_path"`
+	FileFormat      string         `json:"file_format"`
+	SpecId          int32          `json:"spec_id"`
+	Partition       map[string]any `json:"partition"`
+	RecordCount     int64          `json:"record_count"`
+	FileSizeInBytes int64          `json:"file_size_in_bytes"`
+	ColumnSizes     map[int]int64  `json:"column_sizes"`
+	ValueCounts     map[int]int64  `json:"value_counts"`
+	NullValueCounts map[int]int64  `json:"null_value_counts"`
+	NanValueCounts  map[int]int64  `json:"nan_value_counts"`
+	LowerBounds     map[int]any    `json:"lower_bounds"`
+	UpperBounds     map[int]any    `json:"upper_bounds"`
+	KeyMetadata     *string        `json:"key_metadata"`
+	SplitOffsets    []int64        `json:"split_offsets"`
+	EqualityIds     []int          `json:"equality_ids"`
+	SortOrderId     *int           `json:"sort_order_id"`
+}
+
+type EntriesRow struct {
+	Status             int      `json:"status"`
+	SnapshotId         int64    `json:"snapshot_id"`
+	SequenceNumber     int64    `json:"sequence_number"`
+	FileSequenceNumber *int64   `json:"file_sequence_number"`
+	DataFile           FilesRow `json:"data_file"`
+}
+
+type PartitionsRow struct {
+	Partition                 map[string]any `json:"partition"`
+	SpecId                    int32          `json:"spec_id"`
+	RecordCount               int64          `json:"record_count"`
+	FileCount                 int            `json:"file_count"`
+	TotalDataFileSizeInBytes  int64          `json:"total_data_file_size_in_bytes"`
+	PositionDeleteRecordCount int64          `json:"position_delete_record_count"`
+	PositionDeleteFileCount   int            `json:"position_delete_file_count"`
+	EqualityDeleteRecordCount int64          `json:"equality_delete_record_count"`
+	EqualityDeleteFileCount   int            `json:"equality_delete_file_count"`
+	LastUpdatedAt             *time.Time     `json:"last_updated_at"`
+	LastUpdatedSnapshotId     *int64         `json:"last_updated_snapshot_id"`
+}
+
+// Inspect returns the rows of a metadata table of the table: one of the InspectTables.
+func Inspect(ctx context.Context, tableLocation string, name string, conf InspectConfig) ([]any, error) {
+	if !slices.Contains(InspectTables, name) {
+		return nil, fmt.Errorf("unknown metadata table %s, must be one of %s", name, strings.Join(InspectTables, ", "))
+	}
+
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var md = t.Metadata()
+
+	switch name {
+	case "snapshots":
+		return lo.ToAnySlice(inspectSnapshots(md)), nil
+	case "history":
+		return lo.ToAnySlice(inspectHistory(md)), nil
+	case "refs":
+		return lo.ToAnySlice(inspectRefs(md)), nil
+	}
+
+	snap, err := inspectedSnapshot(md, conf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if snap == nil {
+		return []any{}, nil
+	}
+
+	var io = iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx))
+
+	mans, err := snap.Manifests(io)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "manifests" {
+		rows, err := inspectManifests(md, mans)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return lo.ToAnySlice(rows), nil
+	}
+
+	// the files table only lists live files, as the entries of the manifests not deleted
+	// by the snapshot
+	entries, err := iter.MapErr(mans, func(man *iceberg.ManifestFile) ([]iceberg.ManifestEntry, error) {
+		return (*man).FetchEntries(io, name != "entries")
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		sch  = snapshotSchema(md, snap)
+		rows []any
+	)
+
+	switch name {
+	case "files":
+		for _, entry := range lo.Flatten(entries) {
+			row, err := inspectFile(md, sch, entry.DataFile())
+
+			if err != nil {
+				return nil, err
+			}
+
+			rows = append(rows, row)
+		}
+	case "entries":
+		for _, entry := range lo.Flatten(entries) {
+			row, err := inspectFile(md, sch, entry.DataFile())
+
+			if err != nil {
+				return nil, err
+			}
+
+			rows = append(rows, EntriesRow{
+				Status:             int(entry.Status()),
+				SnapshotId:         entry.SnapshotID(),
+				SequenceNumber:     entry.SequenceNum(),
+				FileSequenceNumber: entry.FileSequenceNum(),
+				DataFile:           row,
+			})
+		}
+	case "partitions":
+		partitions, err := inspectPartitions(md, sch, lo.Flatten(entries))
+
+		if err != nil {
+			return nil, err
+		}
+
+		rows = lo.ToAnySlice(partitions)
+	}
+
+	if rows == nil {
+		rows = []any{}
+	}
+
+	return rows, nil
+}
+
+func inspectedSnapshot(md table.Metadata, conf InspectConfig) (*table.Snapshot, error) {
+	switch {
+	case conf.SnapshotId != 0:
+		if snap := md.SnapshotByID(conf.SnapshotId); snap != nil {
+			return snap, nil
+		}
+
+		return nil, fmt.Errorf("snapshot %d not found", conf.SnapshotId)
+	case len(conf.Ref) > 0:
+		if snap := md.SnapshotByName(conf.Ref); snap != nil {
+			return snap, nil
+		}
+
+		return nil, fmt.Errorf("ref %s not found", conf.Ref)
+	default:
+		return md.CurrentSnapshot(), nil
+	}
+}
+
+// snapshotSchema returns the schema the snapshot was written with, or the current schema
+// when unknown.
+func snapshotSchema(md table.Metadata, snap *table.Snapshot) *iceberg.Schema {
+	if snap.SchemaID != nil {
+		if sch, found := lo.Find(md.Schemas(), func(s *iceberg.Schema) bool { return s.ID == *snap.SchemaID }); found {
+			return sch
+		}
+	}
+
+	return md.CurrentSchema()
+}
+
+func inspectSnapshots(md table.Metadata) []SnapshotsRow {
+	return lo.Map(md.Snapshots(), func(snap table.Snapshot, _ int) SnapshotsRow {
+		var row = SnapshotsRow{
+			CommittedAt:  time.UnixMilli(snap.TimestampMs).UTC(),
+			SnapshotId:   snap.SnapshotID,
+			ParentId:     snap.ParentSnapshotID,
+			ManifestList: snap.ManifestList,
+			Summary:      map[string]string{},
+		}
+
+		if snap.Summary != nil {
+			row.Operation = string(snap.Summary.Operation)
+
+			for k, v := range snap.Summary.Properties {
+				row.Summary[k] = v
+			}
+		}
+
+		return row
+	})
+}
+
+func inspectHistory(md table.Metadata) []HistoryRow {
+	var (
+		ancestors = map[int64]bool{}
+		rows      = []HistoryRow{}
+	)
+
+	for snap := md.CurrentSnapshot(); snap != nil; snap = parentSnapshot(md, snap) {
+		ancestors[snap.SnapshotID] = true
+	}
+
+	for entry := range md.SnapshotLogs() {
+		var row = HistoryRow{
+			MadeCurrentAt:     time.UnixMilli(entry.TimestampMs).UTC(),
+			SnapshotId:        entry.SnapshotID,
+			IsCurrentAncestor: ancestors[entry.SnapshotID],
+		}
+
+		if snap := md.SnapshotByID(entry.SnapshotID); snap != nil {
+			row.ParentId = snap.ParentSnapshotID
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func inspectRefs(md table.Metadata) []RefsRow {
+	var rows = []RefsRow{}
+
+	for name, ref := range md.Refs() {
+		rows = append(rows, RefsRow{
+			Name:                name,
+			Type:                string(ref.SnapshotRefType),
+			SnapshotId:          ref.SnapshotID,
+			MaxReferenceAgeInMs: ref.MaxRefAgeMs,
+			MinSnapshotsToKeep:  ref.MinSnapshotsToKeep,
+			MaxSnapshotAgeInMs:  ref.MaxSnapshotAgeMs,
+		})
+	}
+
+	slices.SortFunc(rows, func(a, b RefsRow) int { return strings.Compare(a.Name, b.Name) })
+	return rows
+}
+
+func inspectManifests(md table.Metadata, mans []iceberg.ManifestFile) ([]ManifestsRow, error) {
+	var rows = []ManifestsRow{}
+
+	for _, man := range mans {
+		var row = ManifestsRow{
+			Content:            int(man.ManifestContent()),
+			Path:               man.FilePath(),
+			Length:             man.Length(),
+			PartitionSpecId:    man.PartitionSpecID(),
+			AddedSnapshotId:    man.SnapshotID(),
+			PartitionSummaries: []PartitionFieldSummary{},
+		}
+
+		if man.ManifestContent() == iceberg.ManifestContentDeletes {
+			row.AddedDeleteFilesCount = man.AddedDataFiles()
+			row.ExistingDeleteFilesCount = man.ExistingDataFiles()
+			row.DeletedDeleteFilesCount = man.DeletedDataFiles()
+		} else {
+			row.AddedDataFilesCount = man.AddedDataFiles()
+			row.ExistingDataFilesCount = man.ExistingDataFiles()
+			row.DeletedDataFilesCount = man.DeletedDataFiles()
+		}
+
+		spec, found := lo.Find(md.PartitionSpecs(), func(spec iceberg.PartitionSpec) bool {
+			return spec.ID() == int(man.PartitionSpecID())
+		})
+
+		if !found {
+			return nil, fmt.Errorf("partition spec %d not found", man.PartitionSpecID())
+		}
+
+		for i, summary := range man.Partitions() {
+			var item = PartitionFieldSummary{
+				ContainsNull: summary.ContainsNull,
+				ContainsNan:  summary.ContainsNaN,
+			}
+
+			if i < spec.NumFields() {
+				var typ = partitionFieldType(md.CurrentSchema(), spec.Field(i))
+				item.LowerBound = inspectBoundValue(typ, summary.LowerBound)
+				item.UpperBound = inspectBoundValue(typ, summary.UpperBound)
+			}
+
+			row.PartitionSummaries = append(row.PartitionSummaries, item)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func inspectFile(md table.Metadata, sch *iceberg.Schema, df iceberg.DataFile) (FilesRow, error) {
+	partition, err := inspectPartition(md, sch, df)
+
+	if err != nil {
+		return FilesRow{}, err
+	}
+
+	var row = FilesRow{
+		Content:         int(df.ContentType()),
+		FilePath:        df.FilePath(),
+		FileFormat:      string(df.FileFormat()),
+		SpecId:          df.SpecID(),
+		Partition:       partition,
+		RecordCount:     df.Count(),
+		FileSizeInBytes: df.FileSizeBytes(),
+		ColumnSizes:     df.ColumnSizes(),
+		ValueCounts:     df.ValueCounts(),
+		NullValueCounts: df.NullValueCounts(),
+		NanValueCounts:  df.NaNValueCounts(),
+		LowerBounds:     inspectBoundValues(sch, df.LowerBoundValues()),
+		UpperBounds:     inspectBoundValues(sch, df.UpperBoundValues()),
+		SplitOffsets:    df.SplitOffsets(),
+		EqualityIds:     df.EqualityFieldIDs(),
+		SortOrderId:     df.SortOrderID(),
+	}
+
+	if km := df.KeyMetadata(); km != nil {
+		row.KeyMetadata = lo.ToPtr(hex.EncodeToString(km))
+	}
+
+	return row, nil
+}
+
+// inspectPartitions aggregates the live entries of a snapshot by partition.
+func inspectPartitions(md table.Metadata, sch *iceberg.Schema, entries []iceberg.ManifestEntry) ([]PartitionsRow, error) {
+	var (
+		rows  = []PartitionsRow{}
+		index = map[string]int{}
+	)
+
+	for _, entry := range entries {
+		var df = entry.DataFile()
+
+		partition, err := inspectPartition(md, sch, df)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var key = fmt.Sprintf("%d/%s", df.SpecID(), lo.Must(json.Marshal(partition)))
+
+		i, found := index[key]
+
+		if !found {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, PartitionsRow{Partition: partition, SpecId: df.SpecID()})
+		}
+
+		var row = &rows[i]
+
+		switch df.ContentType() {
+		case iceberg.EntryContentPosDeletes:
+			row.PositionDeleteRecordCount += df.Count()
+			row.PositionDeleteFileCount++
+		case iceberg.EntryContentEqDeletes:
+			row.EqualityDeleteRecordCount += df.Count()
+			row.EqualityDeleteFileCount++
+		default:
+			row.RecordCount += df.Count()
+			row.FileCount++
+			row.TotalDataFileSizeInBytes += df.FileSizeBytes()
+		}
+
+		var snap = md.SnapshotByID(entry.SnapshotID())
+
+		if snap != nil && (row.LastUpdatedAt == nil || snap.TimestampMs > row.LastUpdatedAt.UnixMilli()) {
+			row.LastUpdatedAt = lo.ToPtr(time.UnixMilli(snap.TimestampMs).UTC())
+			row.LastUpdatedSnapshotId = lo.ToPtr(snap.SnapshotID)
+		}
+	}
+
+	return rows, nil
+}
+
+// inspectPartition returns the partition values of a data file by partition field name,
+// decoded as by DecodeBoundValue.
+func inspectPartition(md table.Metadata, sch *iceberg.Schema, df iceberg.DataFile) (map[string]any, error) {
+	spec, found := lo.Find(md.PartitionSpecs(), func(spec iceberg.PartitionSpec) bool {
+		return spec.ID() == int(df.SpecID())
+	})
+
+	if !found {
+		return nil, fmt.Errorf("partition spec %d not found", df.SpecID())
+	}
+
+	var res = map[string]any{}
+
+	for field := range spec.Fields() {
+		var v = df.Partition()[field.FieldID]
+
+		if v == nil {
+			res[field.Name] = nil
+			continue
+		}
+
+		// the type of the values is unknown once the source column is dropped
+		if _, found := sch.FindFieldByID(field.SourceID); !found {
+			res[field.Name] = inspectRawValue(v)
+			continue
+		}
+
+		var typ = partitionFieldType(sch, field)
+
+		lit, err := partitionValueLiteral(v, typ)
+
+		if err != nil {
+			return nil, fmt.Errorf("partition field %s: %w", field.Name, err)
+		}
+
+		res[field.Name] = jsonLiteralValue(lit, typ)
+	}
+
+	return res, nil
+}
+
+// partitionFieldType returns the type of the values of a partition field, or binary when
+// its source column was dropped.
+func partitionFieldType(sch *iceberg.Schema, field iceberg.PartitionField) iceberg.Type {
+	source, found := sch.FindFieldByID(field.SourceID)
+
+	if !found {
+		return iceberg.PrimitiveTypes.Binary
+	}
+
+	return field.Transform.ResultType(source.Type)
+}
+
+// inspectRawValue returns a partition value as read from a manifest, binary values being
+// hex-encoded.
+func inspectRawValue(v any) any {
+	if b, ok := v.([]byte); ok {
+		return hex.EncodeToString(b)
+	}
+
+	return v
+}
+
+func inspectBoundValues(sch *iceberg.Schema, values map[int][]byte) map[int]any {
+	if values == nil {
+		return nil
+	}
+
+	var res = make(map[int]any, len(values))
+
+	for id, v := range values {
+		var typ iceberg.Type = iceberg.PrimitiveTypes.Binary
+
+		if field, found := sch.FindFieldByID(id); found {
+			typ = field.Type
+		}
+
+		res[id] = inspectBoundValue(typ, &v)
+	}
+
+	return res
+}
+
+// inspectBoundValue decodes a serialized bound, falling back to its hex encoding when it
+// cannot be decoded, e.g. for bounds of dropped columns.
+func inspectBoundValue(typ iceberg.Type, v *[]byte) any {
+	if v == nil || *v == nil {
+		return nil
+	}
+
+	lit, err := boundLiteralFromBytes(typ, *v)
+
+	if err != nil {
+		return hex.EncodeToString(*v)
+	}
+
+	return jsonLiteralValue(lit, typ)
+}
@@ -0,0 +1,122 @@
+package iceberg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	var inspect = func(name string, conf InspectConfig) []any {
+		rows, err := Inspect(ctx, "file://"+dir, name, conf)
+		require.NoError(t, err)
+		return rows
+	}
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1, 2}, []string{"b", "b"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{3}, []string{"a"})
+	writeTestParquetFile(t, dir, "3.parquet", []int64{4, 5}, []string{"a", "a"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet"}, nil, CreateOrAddFilesConfig{PartitionBy: "category"}))
+
+	var first = inspect("snapshots", InspectConfig{})[0].(SnapshotsRow).SnapshotId
+
+	_, err := CreateTag(ctx, "file://"+dir, "v1", RefConfig{})
+	require.NoError(t, err)
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, []string{"2.parquet"}, []string{"3.parquet"}, nil, ReplaceFilesConfig{}))
+
+	var snapshots = inspect("snapshots", InspectConfig{})
+	require.Len(t, snapshots, 2)
+	require.Equal(t, first, *snapshots[1].(SnapshotsRow).ParentId)
+	require.Equal(t, "overwrite", snapshots[1].(SnapshotsRow).Operation)
+
+	var history = inspect("history", InspectConfig{})
+	require.Len(t, history, 2)
+	require.True(t, history[0].(HistoryRow).IsCurrentAncestor)
+
+	require.Equal(t, []string{"main", "v1"}, lo.Map(inspect("refs", InspectConfig{}), func(row any, _ int) string {
+		return row.(RefsRow).Name
+	}))
+
+	var files = inspect("files", InspectConfig{})
+	require.Len(t, files, 2)
+
+	for _, row := range files {
+		var file = row.(FilesRow)
+		require.Equal(t, "PARQUET", file.FileFormat)
+		require.Contains(t, file.Partition, "category")
+		require.NotEmpty(t, file.LowerBounds)
+	}
+
+	// the entries of the replaced file are kept, with the deleted status
+	var statuses = lo.CountValuesBy(inspect("entries", InspectConfig{}), func(row any) int { return row.(EntriesRow).Status })
+	require.Equal(t, map[int]int{0: 1, 1: 1, 2: 1}, statuses)
+
+	var partitions = lo.SliceToMap(inspect("partitions", InspectConfig{}), func(row any) (any, PartitionsRow) {
+		return row.(PartitionsRow).Partition["category"], row.(PartitionsRow)
+	})
+	require.Len(t, partitions, 2)
+	require.EqualValues(t, 2, partitions["a"].RecordCount)
+	require.EqualValues(t, 1, partitions["a"].FileCount)
+
+	var manifests = inspect("manifests", InspectConfig{Ref: "v1"})
+	require.Len(t, manifests, 1)
+	require.EqualValues(t, 2, manifests[0].(ManifestsRow).AddedDataFilesCount)
+	require.Len(t, manifests[0].(ManifestsRow).PartitionSummaries, 1)
+
+	require.Len(t, inspect("files", InspectConfig{SnapshotId: first}), 2)
+
+	_, err = Inspect(ctx, "file://"+dir, "files", InspectConfig{Ref: "missing"})
+	require.ErrorContains(t, err, "ref missing not found")
+
+	_, err = Inspect(ctx, "file://"+dir, "unknown", InspectConfig{})
+	require.ErrorContains(t, err, "unknown metadata table")
+}
+
+func TestInspectDroppedPartitionSource(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1, 2}, []string{"b", "b"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{1, 2}, []string{"b", "b"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{PartitionBy: "category"}))
+
+	snapshots, err := Inspect(ctx, "file://"+dir, "snapshots", InspectConfig{})
+	require.NoError(t, err)
+
+	var first = snapshots[0].(SnapshotsRow).SnapshotId
+
+	// the column can only be dropped once no live file uses the spec partitioned by it
+	_, err = UpdatePartitionSpec(ctx, "file://"+dir, UpdatePartitionSpecConfig{Remove: []string{"category"}})
+	require.NoError(t, err)
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, []string{"1.parquet"}, []string{"2.parquet"}, nil, ReplaceFilesConfig{}))
+	_, err = UpdateSchema(ctx, "file://"+dir, DropColumn("category"))
+	require.NoError(t, err)
+
+	// the files added afterwards are read with the schema lacking the column
+	writeTestParquetJSON(t, dir, "3.parquet", arrow.NewSchema([]arrow.Field{{Name: "id", Type: arrow.PrimitiveTypes.Int64}}, nil), `[{"id": 3}]`)
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"3.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	files, err := Inspect(ctx, "file://"+dir, "files", InspectConfig{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []map[string]any{{}, {}}, lo.Map(files, func(row any, _ int) map[string]any { return row.(FilesRow).Partition }))
+
+	// the partition values of the former snapshot are reported as read from the manifests
+	files, err = Inspect(ctx, "file://"+dir, "files", InspectConfig{SnapshotId: first})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"category": "b"}}, lo.Map(files, func(row any, _ int) map[string]any { return row.(FilesRow).Partition }))
+
+	partitions, err := Inspect(ctx, "file://"+dir, "partitions", InspectConfig{SnapshotId: first})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"category": "b"}}, lo.Map(partitions, func(row any, _ int) map[string]any { return row.(PartitionsRow).Partition }))
+}
@@ -16,6 +16,8 @@ import (
 type MetadataFile struct {
 	table.Metadata
 	Path string
+	// Err is set when the file could not be read or parsed, in which case Metadata is nil.
+	Err error
 }
 
 func FetchAllMetadataFiles(ctx context.Context, location *url.URL) ([]*MetadataFile, error) {
@@ -49,7 +51,10 @@ func FetchAllMetadataFiles(ctx context.Context, location *url.URL) ([]*MetadataF
 		md, err := table.ParseMetadataBytes(mdBytes)
 
 		if err != nil {
-			return nil, err
+			return &MetadataFile{
+				Path: (*f).URL.String(),
+				Err:  err,
+			}, nil
 		}
 
 		return &MetadataFile{
@@ -0,0 +1,476 @@
+package iceberg
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+	"github.com/apache/iceberg-go"
+	"github.com/google/uuid"
+)
+
+const (
+	metricsModeDefaultKey      = "write.metadata.metrics.default"
+	metricsModeColumnKeyPrefix = "write.metadata.metrics.column."
+	metricsModeDefault         = "truncate(16)"
+)
+
+var truncateMetricsModeRegex = regexp.MustCompile(`^truncate\((\d+)\)$`)
+
+// hasFieldIDs returns whether the columns of a Parquet file carry Iceberg field IDs.
+func hasFieldIDs(sch *schema.Schema) bool {
+	for i := range sch.NumColumns() {
+		if sch.Column(i).SchemaNode().FieldID() >= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parquetDataFileFromMetadata builds an unpartitioned data file from the footer of a
+// Parquet file whose columns carry field IDs, which iceberg-go refuses to add. Metrics
+// are collected following the table write.metadata.metrics.* properties.
+func parquetDataFileFromMetadata(
+	sch *iceberg.Schema,
+	props iceberg.Properties,
+	location string,
+	meta *metadata.FileMetaData,
+	size int64,
+) (iceberg.DataFile, error) {
+	var (
+		colSizes     = make(map[int]int64)
+		valueCounts  = make(map[int]int64)
+		nullCounts   = make(map[int]int64)
+		lowerBounds  = make(map[int]iceberg.Literal)
+		upperBounds  = make(map[int]iceberg.Literal)
+		invalidated  = make(map[int]bool)
+		splitOffsets []int64
+	)
+
+	for rg := range meta.NumRowGroups() {
+		var rowGroup = meta.RowGroup(rg)
+
+		for pos := range rowGroup.NumColumns() {
+			chunk, err := rowGroup.ColumnChunk(pos)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if pos == 0 {
+				var offset = chunk.DataPageOffset()
+
+				if chunk.HasDictionaryPage() && chunk.DictionaryPageOffset() < offset {
+					offset = chunk.DictionaryPageOffset()
+				}
+
+				splitOffsets = append(splitOffsets, offset)
+			}
+
+			var (
+				col = meta.Schema.Column(pos)
+				id  = int(col.SchemaNode().FieldID())
+			)
+
+			if id < 0 {
+				continue
+			}
+
+			field, found := sch.FindFieldByID(id)
+
+			if !found {
+				return nil, fmt.Errorf("field ID %d of column %s not found in the table schema", id, col.Path())
+			}
+
+			name, _ := sch.FindColumnName(id)
+
+			mode, err := metricsMode(props, name)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if mode == "none" {
+				continue
+			}
+
+			colSizes[id] += chunk.TotalCompressedSize()
+			valueCounts[id] += chunk.NumValues()
+
+			set, err := chunk.StatsSet()
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !set {
+				invalidated[id] = true
+				continue
+			}
+
+			stats, err := chunk.Statistics()
+
+			if err != nil || stats == nil {
+				invalidated[id] = true
+				continue
+			}
+
+			if stats.HasNullCount() {
+				nullCounts[id] += stats.NullCount()
+			}
+
+			typ, ok := field.Type.(iceberg.PrimitiveType)
+
+			if mode == "counts" || !ok || col.MaxRepetitionLevel() > 0 || !stats.HasMinMax() {
+				continue
+			}
+
+			lower, upper, err := parquetStatsBounds(stats, col, typ)
+
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", col.Path(), err)
+			}
+
+			if current, found := lowerBounds[id]; !found || compareLiterals(lower, current) < 0 {
+				lowerBounds[id] = lower
+			}
+
+			if current, found := upperBounds[id]; !found || compareLiterals(upper, current) > 0 {
+				upperBounds[id] = upper
+			}
+		}
+	}
+
+	maps.DeleteFunc(nullCounts, func(id int, _ int64) bool { return invalidated[id] })
+	maps.DeleteFunc(lowerBounds, func(id int, _ iceberg.Literal) bool { return invalidated[id] })
+	maps.DeleteFunc(upperBounds, func(id int, _ iceberg.Literal) bool { return invalidated[id] })
+	slices.Sort(splitOffsets)
+
+	lowerBytes, upperBytes, err := truncatedBounds(sch, props, lowerBounds, upperBounds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := iceberg.NewDataFileBuilder(
+		*iceberg.UnpartitionedSpec,
+		iceberg.EntryContentData,
+		location,
+		iceberg.ParquetFile,
+		nil,
+		meta.GetNumRows(),
+		size,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.ColumnSizes(colSizes)
+	b.ValueCounts(valueCounts)
+	b.NullValueCounts(nullCounts)
+	b.NaNValueCounts(map[int]int64{})
+
+	if len(lowerBytes) > 0 {
+		b.LowerBoundValues(lowerBytes)
+	}
+
+	if len(upperBytes) > 0 {
+		b.UpperBoundValues(upperBytes)
+	}
+
+	if len(splitOffsets) > 0 {
+		b.SplitOffsets(splitOffsets)
+	}
+
+	return b.Build(), nil
+}
+
+// metricsMode returns the metrics mode of a column: none, counts, full or truncate(N).
+func metricsMode(props iceberg.Properties, column string) (string, error) {
+	var mode = props.Get(metricsModeColumnKeyPrefix+column, props.Get(metricsModeDefaultKey, metricsModeDefault))
+
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); {
+	case mode == "none", mode == "counts", mode == "full", truncateMetricsModeRegex.MatchString(mode):
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid metrics mode for column %s: %s", column, mode)
+	}
+}
+
+// truncatedBounds serializes the bounds, truncating string and binary values as
+// configured by the truncate(N) metrics mode.
+func truncatedBounds(
+	sch *iceberg.Schema,
+	props iceberg.Properties,
+	lowerBounds map[int]iceberg.Literal,
+	upperBounds map[int]iceberg.Literal,
+) (map[int][]byte, map[int][]byte, error) {
+	var (
+		lowerBytes = make(map[int][]byte)
+		upperBytes = make(map[int][]byte)
+	)
+
+	for id, lower := range lowerBounds {
+		name, _ := sch.FindColumnName(id)
+
+		mode, err := metricsMode(props, name)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var (
+			upper   = upperBounds[id]
+			trunc   = -1
+			matches = truncateMetricsModeRegex.FindStringSubmatch(mode)
+		)
+
+		if matches != nil {
+			trunc, _ = strconv.Atoi(matches[1])
+		}
+
+		if trunc >= 0 {
+			switch l := lower.(type) {
+			case iceberg.StringLiteral:
+				lower = iceberg.StringLiteral(truncateString(string(l), trunc))
+				upper = truncateUpperString(string(upper.(iceberg.StringLiteral)), trunc)
+			case iceberg.BinaryLiteral:
+				lower = iceberg.BinaryLiteral(l[:min(len(l), trunc)])
+				upper = truncateUpperBinary(upper.(iceberg.BinaryLiteral), trunc)
+			}
+		}
+
+		if lowerBytes[id], err = lower.MarshalBinary(); err != nil {
+			return nil, nil, err
+		}
+
+		if upper != nil {
+			if upperBytes[id], err = upper.MarshalBinary(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return lowerBytes, upperBytes, nil
+}
+
+func truncateString(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+
+		n--
+	}
+
+	return s
+}
+
+// truncateUpperString returns the smallest string of at most n characters greater than
+// or equal to s, or nil when there is none.
+func truncateUpperString(s string, n int) iceberg.Literal {
+	var truncated = []rune(truncateString(s, n))
+
+	if len(truncated) == utf8.RuneCountInString(s) {
+		return iceberg.StringLiteral(s)
+	}
+
+	for i := len(truncated) - 1; i >= 0; i-- {
+		for next := truncated[i] + 1; next <= utf8.MaxRune; next++ {
+			if utf8.ValidRune(next) {
+				truncated[i] = next
+				return iceberg.StringLiteral(string(truncated[:i+1]))
+			}
+		}
+	}
+
+	return nil
+}
+
+// truncateUpperBinary returns the smallest binary value of at most n bytes greater than
+// or equal to b, or nil when there is none.
+func truncateUpperBinary(b []byte, n int) iceberg.Literal {
+	if len(b) <= n {
+		return iceberg.BinaryLiteral(b)
+	}
+
+	var truncated = slices.Clone(b[:n])
+
+	for i := len(truncated) - 1; i >= 0; i-- {
+		if truncated[i] < 0xff {
+			truncated[i]++
+			return iceberg.BinaryLiteral(truncated[:i+1])
+		}
+	}
+
+	return nil
+}
+
+// parquetStatsBounds converts the min and max statistics of a column chunk to literals
+// of the Iceberg column type.
+func parquetStatsBounds(stats metadata.TypedStatistics, col *schema.Column, typ iceberg.PrimitiveType) (iceberg.Literal, iceberg.Literal, error) {
+	var lower, upper iceberg.Literal
+
+	switch s := stats.(type) {
+	case *metadata.BooleanStatistics:
+		lower, upper = iceberg.NewLiteral(s.Min()), iceberg.NewLiteral(s.Max())
+	case *metadata.Int32Statistics:
+		lower, upper = iceberg.NewLiteral(s.Min()), iceberg.NewLiteral(s.Max())
+	case *metadata.Int64Statistics:
+		lower, upper = iceberg.NewLiteral(s.Min()), iceberg.NewLiteral(s.Max())
+	case *metadata.Float32Statistics:
+		lower, upper = iceberg.NewLiteral(s.Min()), iceberg.NewLiteral(s.Max())
+	case *metadata.Float64Statistics:
+		lower, upper = iceberg.NewLiteral(s.Min()), iceberg.NewLiteral(s.Max())
+	case *metadata.ByteArrayStatistics:
+		lower, upper = iceberg.NewLiteral([]byte(s.Min())), iceberg.NewLiteral([]byte(s.Max()))
+	case *metadata.FixedLenByteArrayStatistics:
+		lower, upper = iceberg.NewLiteral([]byte(s.Min())), iceberg.NewLiteral([]byte(s.Max()))
+	default:
+		return nil, nil, fmt.Errorf("unsupported statistics type %T", stats)
+	}
+
+	lower, err := parquetStatLiteral(lower, col, typ, false)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upper, err = parquetStatLiteral(upper, col, typ, true)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lower, upper, nil
+}
+
+func parquetStatLiteral(lit iceberg.Literal, col *schema.Column, typ iceberg.PrimitiveType, roundUp bool) (iceberg.Literal, error) {
+	switch t := typ.(type) {
+	case iceberg.TimestampType, iceberg.TimestampTzType, iceberg.TimeType:
+		var v int64
+
+		switch l := lit.(type) {
+		case iceberg.Int32Literal:
+			v = int64(l)
+		case iceberg.Int64Literal:
+			v = int64(l)
+		default:
+			return nil, fmt.Errorf("unexpected physical type %s for %s", col.PhysicalType(), typ)
+		}
+
+		return iceberg.NewLiteral(toMicros(v, parquetTimeUnit(col.LogicalType()), roundUp)).To(typ)
+	case iceberg.DecimalType:
+		switch l := lit.(type) {
+		case iceberg.Int32Literal:
+			return iceberg.DecimalLiteral{Val: decimal128.FromI64(int64(l)), Scale: t.Scale()}, nil
+		case iceberg.Int64Literal:
+			return iceberg.DecimalLiteral{Val: decimal128.FromI64(int64(l)), Scale: t.Scale()}, nil
+		case iceberg.BinaryLiteral:
+			return iceberg.LiteralFromBytes(typ, l)
+		}
+	case iceberg.StringType:
+		if l, ok := lit.(iceberg.BinaryLiteral); ok {
+			return iceberg.StringLiteral(l), nil
+		}
+	case iceberg.UUIDType:
+		if l, ok := lit.(iceberg.BinaryLiteral); ok {
+			u, err := uuid.FromBytes(l)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return iceberg.NewLiteral(u), nil
+		}
+	case iceberg.FixedType:
+		if l, ok := lit.(iceberg.BinaryLiteral); ok {
+			return iceberg.FixedLiteral(l), nil
+		}
+	case iceberg.BinaryType:
+		return lit, nil
+	}
+
+	return lit.To(typ)
+}
+
+func parquetTimeUnit(lt schema.LogicalType) schema.TimeUnitType {
+	switch t := lt.(type) {
+	case schema.TimestampLogicalType:
+		return t.TimeUnit()
+	case schema.TimeLogicalType:
+		return t.TimeUnit()
+	default:
+		return schema.TimeUnitMicros
+	}
+}
+
+// toMicros converts a time value to microseconds, rounding sub-microsecond values down
+// for lower bounds and up for upper bounds.
+func toMicros(v int64, unit schema.TimeUnitType, roundUp bool) int64 {
+	switch unit {
+	case schema.TimeUnitMillis:
+		return v * 1000
+	case schema.TimeUnitNanos:
+		var q, r = v / 1000, v % 1000
+
+		if r < 0 {
+			q, r = q-1, r+1000
+		}
+
+		if roundUp && r > 0 {
+			q++
+		}
+
+		return q
+	default:
+		return v
+	}
+}
+
+// compareLiterals compares two literals of the same type.
+func compareLiterals(a iceberg.Literal, b iceberg.Literal) int {
+	switch a := a.(type) {
+	case iceberg.BoolLiteral:
+		return compareTypedLiterals[bool](a, b)
+	case iceberg.Int32Literal:
+		return compareTypedLiterals[int32](a, b)
+	case iceberg.Int64Literal:
+		return compareTypedLiterals[int64](a, b)
+	case iceberg.Float32Literal:
+		return compareTypedLiterals[float32](a, b)
+	case iceberg.Float64Literal:
+		return compareTypedLiterals[float64](a, b)
+	case iceberg.DateLiteral:
+		return compareTypedLiterals[iceberg.Date](a, b)
+	case iceberg.TimeLiteral:
+		return compareTypedLiterals[iceberg.Time](a, b)
+	case iceberg.TimestampLiteral:
+		return compareTypedLiterals[iceberg.Timestamp](a, b)
+	case iceberg.StringLiteral:
+		return compareTypedLiterals[string](a, b)
+	case iceberg.BinaryLiteral:
+		return compareTypedLiterals[[]byte](a, b)
+	case iceberg.FixedLiteral:
+		return compareTypedLiterals[[]byte](a, b)
+	case iceberg.UUIDLiteral:
+		return compareTypedLiterals[uuid.UUID](a, b)
+	case iceberg.DecimalLiteral:
+		return compareTypedLiterals[iceberg.Decimal](a, b)
+	default:
+		panic(fmt.Sprintf("cannot compare literals of type %s", a.Type()))
+	}
+}
+
+func compareTypedLiterals[T iceberg.LiteralType](a iceberg.TypedLiteral[T], b iceberg.Literal) int {
+	return a.Comparator()(a.Value(), b.(iceberg.TypedLiteral[T]).Value())
+}
@@ -0,0 +1,78 @@
+package iceberg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+func fieldIDMetadata(id string) arrow.Metadata {
+	return arrow.NewMetadata([]string{table.ArrowParquetFieldIDKey}, []string{id})
+}
+
+func TestCreateOrAddFilesWithFieldIDs(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+		sch = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64, Metadata: fieldIDMetadata("10")},
+			{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true, Metadata: fieldIDMetadata("20")},
+			{Name: "ts", Type: &arrow.TimestampType{Unit: arrow.Millisecond}, Nullable: true, Metadata: fieldIDMetadata("30")},
+		}, nil)
+		longName = strings.Repeat("z", 20)
+	)
+
+	writeTestParquetJSON(t, dir, "1.parquet", sch, `[
+		{"id": 1, "name": "a", "ts": "2024-01-01T00:00:00.001Z"},
+		{"id": 3, "name": "`+longName+`", "ts": null}
+	]`)
+	writeTestParquetJSON(t, dir, "2.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64, Metadata: fieldIDMetadata("11")},
+	}, nil), `[{"id": 1}]`)
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.ErrorContains(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{}), "field ID 11")
+
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []int{10, 20, 30}, lo.Map(tbl.Schema().Fields(), func(f iceberg.NestedField, _ int) int { return f.ID }))
+	require.Equal(t, 30, tbl.Metadata().LastColumnID())
+
+	// the name mapping is written when the table is created
+	mapped, found := lo.Find(tbl.NameMapping(), func(f iceberg.MappedField) bool { return f.ID() == 20 })
+	require.True(t, found)
+	require.Equal(t, []string{"name"}, mapped.Names)
+
+	dataFiles, err := SnapshotDataFiles(iceio.NewObjectStoreIO(objstr.FromContext(ctx)), tbl.CurrentSnapshot())
+	require.NoError(t, err)
+	require.Len(t, dataFiles, 1)
+
+	var (
+		df    = dataFiles[0]
+		bound = func(bounds map[int][]byte, id int, typ iceberg.Type) any {
+			lit, err := iceberg.LiteralFromBytes(typ, bounds[id])
+			require.NoError(t, err)
+			return lit.Any()
+		}
+	)
+
+	require.Equal(t, int64(2), df.Count())
+	require.Equal(t, map[int]int64{10: 2, 20: 2, 30: 2}, df.ValueCounts())
+	require.Equal(t, map[int]int64{10: 0, 20: 0, 30: 1}, df.NullValueCounts())
+	require.Equal(t, int64(1), bound(df.LowerBoundValues(), 10, iceberg.PrimitiveTypes.Int64))
+	require.Equal(t, int64(3), bound(df.UpperBoundValues(), 10, iceberg.PrimitiveTypes.Int64))
+	require.Equal(t, "a", bound(df.LowerBoundValues(), 20, iceberg.PrimitiveTypes.String))
+	require.Equal(t, strings.Repeat("z", 15)+"{", bound(df.UpperBoundValues(), 20, iceberg.PrimitiveTypes.String))
+	require.Equal(t, iceberg.Timestamp(1704067200001000), bound(df.LowerBoundValues(), 30, iceberg.PrimitiveTypes.Timestamp))
+}
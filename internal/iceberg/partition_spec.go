@@ -0,0 +1,203 @@
+package iceberg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/apache/iceberg-go"
+)
+
+var (
+	partitionFieldRegexp   = regexp.MustCompile(`^(?:(\w+)\s*\(\s*(?:(\d+)\s*,\s*)?([^\s(),]+)\s*\)|([^\s(),]+))(?:\s+(?i:as)\s+(\w+))?$`)
+	partitionFieldSuffixes = map[string]string{
+		"year":     "year",
+		"month":    "month",
+		"day":      "day",
+		"hour":     "hour",
+		"bucket":   "bucket",
+		"truncate": "trunc",
+	}
+)
+
+// PartitionFieldExpr is a single partition field as written by the user, e.g. `day(ts) as date`.
+type PartitionFieldExpr struct {
+	SourceName string
+	Transform  iceberg.Transform
+	Name       string
+}
+
+// ParsePartitionFieldExprs parses a comma-separated list of partition fields.
+// Supported forms are `col`, `identity(col)`, `year(col)`, `month(col)`, `day(col)`,
+// `hour(col)`, `bucket(N, col)` and `truncate(W, col)`, each optionally followed by `as name`.
+func ParsePartitionFieldExprs(s string) ([]PartitionFieldExpr, error) {
+	var res []PartitionFieldExpr
+
+	for _, part := range splitTopLevel(s) {
+		if len(strings.TrimSpace(part)) == 0 {
+			continue
+		}
+
+		expr, err := parsePartitionFieldExpr(strings.TrimSpace(part))
+
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, expr)
+	}
+
+	return res, nil
+}
+
+func parsePartitionFieldExpr(s string) (PartitionFieldExpr, error) {
+	var m = partitionFieldRegexp.FindStringSubmatch(s)
+
+	if m == nil {
+		return PartitionFieldExpr{}, fmt.Errorf("invalid partition field: %s", s)
+	}
+
+	if len(m[4]) > 0 {
+		return PartitionFieldExpr{SourceName: m[4], Transform: iceberg.IdentityTransform{}, Name: m[5]}, nil
+	}
+
+	var (
+		name      = strings.ToLower(m[1])
+		transform string
+	)
+
+	switch name {
+	case "identity", "year", "month", "day", "hour", "years", "months", "days", "hours":
+		if len(m[2]) > 0 {
+			return PartitionFieldExpr{}, fmt.Errorf("%s transform does not take a width: %s", name, s)
+		}
+
+		transform = strings.TrimSuffix(name, "s")
+	case "bucket", "truncate":
+		if len(m[2]) == 0 {
+			return PartitionFieldExpr{}, fmt.Errorf("%s transform requires a width: %s", name, s)
+		}
+
+		transform = fmt.Sprintf("%s[%s]", name, m[2])
+	default:
+		return PartitionFieldExpr{}, fmt.Errorf("unsupported partition transform: %s", m[1])
+	}
+
+	t, err := iceberg.ParseTransform(transform)
+
+	if err != nil {
+		return PartitionFieldExpr{}, err
+	}
+
+	return PartitionFieldExpr{SourceName: m[3], Transform: t, Name: m[5]}, nil
+}
+
+// PartitionField binds the expression to a schema.
+func (expr PartitionFieldExpr) PartitionField(sch *iceberg.Schema, fieldID int) (iceberg.PartitionField, error) {
+	field, found := sch.FindFieldByName(expr.SourceName)
+
+	if !found {
+		return iceberg.PartitionField{}, fmt.Errorf("partition source column %s not found", expr.SourceName)
+	}
+
+	if !expr.Transform.CanTransform(field.Type) {
+		return iceberg.PartitionField{}, fmt.Errorf("cannot apply transform %s to column %s of type %s", expr.Transform, expr.SourceName, field.Type)
+	}
+
+	var name = expr.Name
+
+	if len(name) == 0 {
+		name = DefaultPartitionFieldName(expr.SourceName, expr.Transform)
+	}
+
+	return iceberg.PartitionField{
+		SourceID:  field.ID,
+		FieldID:   fieldID,
+		Name:      name,
+		Transform: expr.Transform,
+	}, nil
+}
+
+// ParsePartitionSpec builds the initial partition spec of a table from a
+// comma-separated list of partition fields (see ParsePartitionFieldExprs).
+func ParsePartitionSpec(sch *iceberg.Schema, s string) (*iceberg.PartitionSpec, error) {
+	exprs, err := ParsePartitionFieldExprs(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(exprs) == 0 {
+		return iceberg.UnpartitionedSpec, nil
+	}
+
+	var fields []iceberg.PartitionField
+
+	for i, expr := range exprs {
+		field, err := expr.PartitionField(sch, iceberg.PartitionDataIDStart+i)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+	}
+
+	if err := validatePartitionFields(sch, fields); err != nil {
+		return nil, err
+	}
+
+	var spec = iceberg.NewPartitionSpecID(iceberg.InitialPartitionSpecID, fields...)
+	return &spec, nil
+}
+
+// DefaultPartitionFieldName follows the Java implementation naming: identity fields
+// keep the source name while other transforms append a suffix.
+func DefaultPartitionFieldName(sourceName string, t iceberg.Transform) string {
+	var base, _, _ = strings.Cut(t.String(), "[")
+
+	if suffix, found := partitionFieldSuffixes[base]; found {
+		return sourceName + "_" + suffix
+	}
+
+	return sourceName
+}
+
+func validatePartitionFieldNames(spec iceberg.PartitionSpec) error {
+	var seen = make(map[string]bool)
+
+	for field := range spec.Fields() {
+		if seen[field.Name] {
+			return fmt.Errorf("duplicate partition field name: %s", field.Name)
+		}
+
+		seen[field.Name] = true
+	}
+
+	return nil
+}
+
+// splitTopLevel splits s on commas that are not enclosed in parentheses.
+func splitTopLevel(s string) []string {
+	var (
+		res   []string
+		depth int
+		start int
+	)
+
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				res = append(res, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(res, s[start:])
+}
@@ -0,0 +1,53 @@
+package iceberg
+
+import (
+	"testing"
+
+	"github.com/apache/iceberg-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePartitionSpec(t *testing.T) {
+	var sch = iceberg.NewSchema(
+		0,
+		iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64},
+		iceberg.NestedField{ID: 2, Name: "ts", Type: iceberg.PrimitiveTypes.Timestamp},
+		iceberg.NestedField{ID: 3, Name: "name", Type: iceberg.PrimitiveTypes.String},
+	)
+
+	spec, err := ParsePartitionSpec(sch, "day(ts) as date, bucket(16, id), truncate(4, name), name")
+	require.NoError(t, err)
+	require.Equal(t, iceberg.NewPartitionSpecID(0,
+		iceberg.PartitionField{SourceID: 2, FieldID: 1000, Name: "date", Transform: iceberg.DayTransform{}},
+		iceberg.PartitionField{SourceID: 1, FieldID: 1001, Name: "id_bucket", Transform: iceberg.BucketTransform{NumBuckets: 16}},
+		iceberg.PartitionField{SourceID: 3, FieldID: 1002, Name: "name_trunc", Transform: iceberg.TruncateTransform{Width: 4}},
+		iceberg.PartitionField{SourceID: 3, FieldID: 1003, Name: "name", Transform: iceberg.IdentityTransform{}},
+	), *spec)
+
+	spec, err = ParsePartitionSpec(sch, "")
+	require.NoError(t, err)
+	require.True(t, spec.IsUnpartitioned())
+
+	for _, s := range []string{"bucket(id)", "day(4, ts)", "month(name)", "unknown(id)", "missing", "id, id"} {
+		_, err := ParsePartitionSpec(sch, s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestPartitionLiteralFromPath(t *testing.T) {
+	lit, err := partitionLiteralFromPath("2024-01-01 10:00:00", iceberg.PrimitiveTypes.Timestamp, nil)
+	require.NoError(t, err)
+	require.Equal(t, int32(19723), iceberg.DayTransform{}.Apply(lit).Val.Any())
+
+	lit, err = partitionLiteralFromPath("2024-01-01", iceberg.PrimitiveTypes.Date, iceberg.DayTransform{})
+	require.NoError(t, err)
+	require.Equal(t, int32(19723), lit.Val.Any())
+
+	lit, err = partitionLiteralFromPath("2024-02", iceberg.PrimitiveTypes.Int32, iceberg.MonthTransform{})
+	require.NoError(t, err)
+	require.Equal(t, int32(649), lit.Val.Any())
+
+	lit, err = partitionLiteralFromPath(hiveDefaultPartition, iceberg.PrimitiveTypes.String, nil)
+	require.NoError(t, err)
+	require.False(t, lit.Valid)
+}
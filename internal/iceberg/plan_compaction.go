@@ -0,0 +1,394 @@
+package iceberg
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+)
+
+// CompactionGroup is a set of data files to merge into a single output file. Paths are
+// relative to the data directory of the table, as expected by ReplaceFiles.
+type CompactionGroup struct {
+	Partition      map[string]string `json:"partition,omitempty"`
+	InputFiles     []string          `json:"input_files"`
+	InputSizeBytes int64             `json:"input_size_bytes"`
+	RecordCount    int64             `json:"record_count"`
+	OutputFile     string            `json:"output_file"`
+}
+
+type PlanCompactionConfig struct {
+	// TargetFileSizeBytes defaults to the write.target-file-size-bytes table property.
+	TargetFileSizeBytes int64
+	// MinFileSizeBytes is the size from which data files are left as is. It defaults to
+	// 75% of the target file size.
+	MinFileSizeBytes int64
+	// MinInputFiles is the minimum number of files of a group, 2 by default.
+	MinInputFiles int
+	// IgnorePartitions groups files of different partitions together. By default, only
+	// files of the same partition are grouped and output files are named after the
+	// partition path so that their partition is known when they are added. It should only
+	// be set for unpartitioned tables, or when the output files are written in the
+	// partition layout by other means.
+	IgnorePartitions bool
+	// SortField, when set, groups files with adjacent ranges of this field, ordered by
+	// lower bound, instead of bin-packing them by size.
+	SortField string
+}
+
+// PlanCompaction groups the small data files of the current snapshot into groups whose
+// total size is close to the target file size. Each group can be merged into its output
+// file (e.g. with an INSERT INTO s3(...) SELECT query), then committed with ReplaceFiles.
+func PlanCompaction(ctx context.Context, tableLocation string, conf PlanCompactionConfig) ([]CompactionGroup, error) {
+	location, err := url.Parse(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cat, err := NewVersionHintCatalog(location.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		io         = iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx))
+		snap       = t.CurrentSnapshot()
+		sch        = t.Schema()
+		target     = conf.TargetFileSizeBytes
+		minSize    = conf.MinFileSizeBytes
+		minFiles   = conf.MinInputFiles
+		dataPrefix = location.JoinPath("data").String() + "/"
+	)
+
+	if target <= 0 {
+		target = int64(t.Properties().GetInt(table.WriteTargetFileSizeBytesKey, table.WriteTargetFileSizeBytesDefault))
+	}
+
+	if minSize <= 0 {
+		minSize = target * 3 / 4
+	}
+
+	if minFiles <= 0 {
+		minFiles = 2
+	}
+
+	var sortField iceberg.NestedField
+
+	if len(conf.SortField) > 0 {
+		var found bool
+
+		if sortField, found = sch.FindFieldByName(conf.SortField); !found {
+			return nil, fmt.Errorf("field %s not found", conf.SortField)
+		}
+	}
+
+	if snap == nil {
+		return []CompactionGroup{}, nil
+	}
+
+	mans, err := snap.Manifests(io)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// merging files would drop the rows deleted from them
+	if lo.SomeBy(mans, func(man iceberg.ManifestFile) bool { return man.ManifestContent() != iceberg.ManifestContentData }) {
+		return nil, fmt.Errorf("snapshot has delete files")
+	}
+
+	dataFiles, err := SnapshotDataFiles(io, snap)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		candidates = make(map[string][]compactionCandidate)
+		keys       []string
+	)
+
+	for _, df := range dataFiles {
+		if df.FileSizeBytes() >= minSize {
+			continue
+		}
+
+		relPath, found := strings.CutPrefix(df.FilePath(), dataPrefix)
+
+		if !found {
+			return nil, fmt.Errorf("data file %s is not stored under %s", df.FilePath(), dataPrefix)
+		}
+
+		var c = compactionCandidate{df: df, path: relPath}
+
+		if len(conf.SortField) > 0 {
+			if v, found := df.LowerBoundValues()[sortField.ID]; found {
+				if c.lower, err = boundLiteralFromBytes(sortField.Type, v); err != nil {
+					return nil, fmt.Errorf("cannot decode bound value of field %s (%s): %w", sortField.Name, sortField.Type, err)
+				}
+			}
+		}
+
+		var key string
+
+		if !conf.IgnorePartitions {
+			key = fmt.Sprint(df.SpecID(), df.Partition())
+		}
+
+		if _, found := candidates[key]; !found {
+			keys = append(keys, key)
+		}
+
+		candidates[key] = append(candidates[key], c)
+	}
+
+	slices.Sort(keys)
+
+	var res = []CompactionGroup{}
+
+	for _, key := range keys {
+		var bins [][]compactionCandidate
+
+		if len(conf.SortField) > 0 {
+			bins = packSequential(candidates[key], target)
+		} else {
+			bins = packFirstFitDecreasing(candidates[key], target)
+		}
+
+		for _, bin := range bins {
+			if len(bin) < minFiles {
+				continue
+			}
+
+			var group = CompactionGroup{
+				InputFiles:     lo.Map(bin, func(c compactionCandidate, _ int) string { return c.path }),
+				InputSizeBytes: lo.SumBy(bin, func(c compactionCandidate) int64 { return c.df.FileSizeBytes() }),
+				RecordCount:    lo.SumBy(bin, func(c compactionCandidate) int64 { return c.df.Count() }),
+				OutputFile:     fmt.Sprintf("compacted-%s.parquet", uuid.NewString()),
+			}
+
+			if !conf.IgnorePartitions {
+				dir, partition, err := partitionPath(t.Metadata(), sch, bin[0].df)
+
+				if err != nil {
+					return nil, err
+				}
+
+				group.Partition = partition
+				group.OutputFile = path.Join(dir, group.OutputFile)
+			}
+
+			res = append(res, group)
+		}
+	}
+
+	return res, nil
+}
+
+type compactionCandidate struct {
+	df    iceberg.DataFile
+	path  string
+	lower iceberg.Literal
+}
+
+// packSequential splits the files, ordered by lower bound, into consecutive groups of at
+// most target bytes. Files without a lower bound come last.
+func packSequential(files []compactionCandidate, target int64) [][]compactionCandidate {
+	slices.SortFunc(files, func(a, b compactionCandidate) int {
+		switch {
+		case a.lower == nil && b.lower == nil:
+		case a.lower == nil:
+			return 1
+		case b.lower == nil:
+			return -1
+		default:
+			if c := compareLiterals(a.lower, b.lower); c != 0 {
+				return c
+			}
+		}
+
+		return strings.Compare(a.path, b.path)
+	})
+
+	var (
+		res  [][]compactionCandidate
+		bin  []compactionCandidate
+		size int64
+	)
+
+	for _, f := range files {
+		if len(bin) > 0 && size+f.df.FileSizeBytes() > target {
+			res = append(res, bin)
+			bin, size = nil, 0
+		}
+
+		bin = append(bin, f)
+		size += f.df.FileSizeBytes()
+	}
+
+	if len(bin) > 0 {
+		res = append(res, bin)
+	}
+
+	return res
+}
+
+// packFirstFitDecreasing puts each file, largest first, into the first group it fits in.
+func packFirstFitDecreasing(files []compactionCandidate, target int64) [][]compactionCandidate {
+	slices.SortFunc(files, func(a, b compactionCandidate) int {
+		if c := cmp.Compare(b.df.FileSizeBytes(), a.df.FileSizeBytes()); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.path, b.path)
+	})
+
+	var (
+		res   [][]compactionCandidate
+		sizes []int64
+	)
+
+	for _, f := range files {
+		var i = slices.IndexFunc(sizes, func(size int64) bool { return size+f.df.FileSizeBytes() <= target })
+
+		if i < 0 {
+			res = append(res, nil)
+			sizes = append(sizes, 0)
+			i = len(res) - 1
+		}
+
+		res[i] = append(res[i], f)
+		sizes[i] += f.df.FileSizeBytes()
+	}
+
+	return res
+}
+
+// partitionPath renders the partition of a data file as hive-style path segments that
+// partitionValues parses back, along with the rendered value of each partition field.
+func partitionPath(md table.Metadata, sch *iceberg.Schema, df iceberg.DataFile) (string, map[string]string, error) {
+	spec, found := lo.Find(md.PartitionSpecs(), func(spec iceberg.PartitionSpec) bool {
+		return spec.ID() == int(df.SpecID())
+	})
+
+	if !found {
+		return "", nil, fmt.Errorf("partition spec %d not found", df.SpecID())
+	}
+
+	var (
+		segments []string
+		values   = make(map[string]string)
+	)
+
+	for field := range spec.Fields() {
+		if _, void := field.Transform.(iceberg.VoidTransform); void {
+			continue
+		}
+
+		source, found := sch.FindFieldByID(field.SourceID)
+
+		if !found {
+			return "", nil, fmt.Errorf("partition source column %d not found", field.SourceID)
+		}
+
+		v, err := partitionPathValue(field.Transform, field.Transform.ResultType(source.Type), df.Partition()[field.FieldID])
+
+		if err != nil {
+			return "", nil, fmt.Errorf("partition field %s: %w", field.Name, err)
+		}
+
+		values[field.Name] = v
+		segments = append(segments, field.Name+"="+url.PathEscape(v))
+	}
+
+	return path.Join(segments...), values, nil
+}
+
+func partitionPathValue(transform iceberg.Transform, typ iceberg.Type, v any) (string, error) {
+	if v == nil {
+		return hiveDefaultPartition, nil
+	}
+
+	lit, err := partitionValueLiteral(v, typ)
+
+	if err != nil {
+		return "", err
+	}
+
+	var n int64
+
+	if i, ok := lit.(iceberg.Int32Literal); ok {
+		n = int64(i)
+	}
+
+	switch transform.(type) {
+	case iceberg.YearTransform:
+		return fmt.Sprintf("%04d", 1970+n), nil
+	case iceberg.MonthTransform:
+		return time.Date(1970, time.January+time.Month(n), 1, 0, 0, 0, 0, time.UTC).Format("2006-01"), nil
+	case iceberg.DayTransform:
+		return time.Unix(n*86400, 0).UTC().Format(time.DateOnly), nil
+	case iceberg.HourTransform:
+		return time.Unix(n*3600, 0).UTC().Format("2006-01-02-15"), nil
+	default:
+		return fmt.Sprint(jsonLiteralValue(lit, typ)), nil
+	}
+}
+
+// partitionValueLiteral converts a partition value, as read from a manifest, to a literal
+// of the partition field type.
+func partitionValueLiteral(v any, typ iceberg.Type) (iceberg.Literal, error) {
+	var lit iceberg.Literal
+
+	switch v := v.(type) {
+	case int:
+		lit = iceberg.NewLiteral(int64(v))
+	case int32:
+		lit = iceberg.NewLiteral(v)
+	case int64:
+		lit = iceberg.NewLiteral(v)
+	case float32:
+		lit = iceberg.NewLiteral(v)
+	case float64:
+		lit = iceberg.NewLiteral(v)
+	case bool:
+		lit = iceberg.NewLiteral(v)
+	case string:
+		lit = iceberg.NewLiteral(v)
+	case []byte:
+		lit = iceberg.NewLiteral(v)
+	case uuid.UUID:
+		lit = iceberg.NewLiteral(v)
+	case iceberg.Date:
+		lit = iceberg.NewLiteral(v)
+	case iceberg.Time:
+		lit = iceberg.NewLiteral(v)
+	case iceberg.Timestamp:
+		lit = iceberg.NewLiteral(v)
+	case iceberg.Decimal:
+		lit = iceberg.NewLiteral(v)
+	default:
+		return nil, fmt.Errorf("unsupported partition value %v (%T)", v, v)
+	}
+
+	return lit.To(typ)
+}
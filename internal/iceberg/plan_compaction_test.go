@@ -0,0 +1,101 @@
+package iceberg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanCompaction(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "category=a/1.parquet", []int64{20, 29}, []string{"a", "a"})
+	writeTestParquetFile(t, dir, "category=a/2.parquet", []int64{0, 9}, []string{"a", "a"})
+	writeTestParquetFile(t, dir, "category=a/3.parquet", []int64{10, 19}, []string{"a", "a"})
+	writeTestParquetFile(t, dir, "category=b/4.parquet", []int64{30, 39}, []string{"b", "b"})
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{
+		"category=a/1.parquet",
+		"category=a/2.parquet",
+		"category=a/3.parquet",
+		"category=b/4.parquet",
+	}, nil, CreateOrAddFilesConfig{PartitionBy: "category"}))
+
+	groups, err := PlanCompaction(ctx, "file://"+dir, PlanCompactionConfig{TargetFileSizeBytes: 1 << 20})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.ElementsMatch(t, []string{"category=a/1.parquet", "category=a/2.parquet", "category=a/3.parquet"}, groups[0].InputFiles)
+	require.Equal(t, map[string]string{"category": "a"}, groups[0].Partition)
+	require.Equal(t, int64(6), groups[0].RecordCount)
+	require.True(t, strings.HasPrefix(groups[0].OutputFile, "category=a/"), groups[0].OutputFile)
+
+	groups, err = PlanCompaction(ctx, "file://"+dir, PlanCompactionConfig{TargetFileSizeBytes: 1 << 20, IgnorePartitions: true})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].InputFiles, 4)
+	require.Nil(t, groups[0].Partition)
+
+	// with a sort field, files are grouped by adjacent ranges
+	info, err := os.Stat(filepath.Join(dir, "data", "category=a", "1.parquet"))
+	require.NoError(t, err)
+
+	groups, err = PlanCompaction(ctx, "file://"+dir, PlanCompactionConfig{
+		TargetFileSizeBytes: 2*info.Size() + 16,
+		SortField:           "id",
+	})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, []string{"category=a/2.parquet", "category=a/3.parquet"}, groups[0].InputFiles)
+
+	// the output file of a group can replace its input files
+	writeTestParquetFile(t, dir, groups[0].OutputFile, []int64{0, 9, 10, 19}, []string{"a", "a", "a", "a"})
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, groups[0].InputFiles, []string{groups[0].OutputFile}, nil, ReplaceFilesConfig{}))
+
+	_, err = PlanCompaction(ctx, "file://"+dir, PlanCompactionConfig{SortField: "unknown"})
+	require.ErrorContains(t, err, "field unknown not found")
+}
+
+func TestPartitionPathValue(t *testing.T) {
+	var tests = []struct {
+		transform iceberg.Transform
+		typ       iceberg.Type
+		value     any
+		expected  string
+	}{
+		{iceberg.YearTransform{}, iceberg.PrimitiveTypes.Int32, 54, "2024"},
+		{iceberg.MonthTransform{}, iceberg.PrimitiveTypes.Int32, 650, "2024-03"},
+		{iceberg.MonthTransform{}, iceberg.PrimitiveTypes.Int32, -1, "1969-12"},
+		{iceberg.DayTransform{}, iceberg.PrimitiveTypes.Int32, 19783, "2024-03-01"},
+		{iceberg.HourTransform{}, iceberg.PrimitiveTypes.Int32, 474792 + 12, "2024-03-01-12"},
+		{iceberg.IdentityTransform{}, iceberg.PrimitiveTypes.Date, 19783, "2024-03-01"},
+		{iceberg.IdentityTransform{}, iceberg.PrimitiveTypes.String, "a/b", "a/b"},
+		{iceberg.BucketTransform{NumBuckets: 4}, iceberg.PrimitiveTypes.Int32, 3, "3"},
+		{iceberg.IdentityTransform{}, iceberg.PrimitiveTypes.Int64, nil, hiveDefaultPartition},
+	}
+
+	for _, test := range tests {
+		v, err := partitionPathValue(test.transform, test.typ, test.value)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, v)
+
+		if test.value == nil {
+			continue
+		}
+
+		// the rendered value is parsed back by partitionValues
+		lit, err := partitionLiteralFromPath(v, test.typ, test.transform)
+		require.NoError(t, err)
+		expected, err := partitionValueLiteral(test.value, test.typ)
+		require.NoError(t, err)
+		require.True(t, expected.Equals(lit.Val), "%s != %s", expected, lit.Val)
+	}
+}
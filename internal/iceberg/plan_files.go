@@ -0,0 +1,183 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/iter"
+)
+
+type PlanFilesItem struct {
+	FilePath      string   `json:"file_path"`
+	FileFormat    string   `json:"file_format"`
+	RecordCount   int64    `json:"record_count"`
+	FileSizeBytes int64    `json:"file_size_bytes"`
+	DeleteFiles   []string `json:"delete_files,omitempty"`
+}
+
+type PlanFilesConfig struct {
+	// Filter is a row filter expression (see ParseFilterExpr).
+	Filter            string
+	FailOnDeleteFiles bool
+}
+
+// PlanFiles returns the data files of the current snapshot that may contain rows matching
+// the filter. The manifests of the snapshot are walked like in FieldBoundValues and data
+// files are pruned using their partition values and column bounds, so the result is a
+// superset of the files holding matching rows.
+func PlanFiles(ctx context.Context, tableLocation string, conf PlanFilesConfig) ([]PlanFilesItem, error) {
+	var (
+		os = objstr.FromContextOrDefault(ctx)
+		io = iceio.NewObjectStoreIO(os)
+	)
+
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := ParseFilterExpr(conf.Filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newDataFileFilter(t.Metadata(), t.Schema(), expr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		snap = t.CurrentSnapshot()
+		res  = []PlanFilesItem{}
+	)
+
+	if snap == nil {
+		return res, nil
+	}
+
+	mans, err := snap.Manifests(io)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := iter.MapErr(mans, func(man *iceberg.ManifestFile) ([]iceberg.ManifestEntry, error) {
+		return (*man).FetchEntries(io, true)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, deletes := lo.FilterReject(lo.Flatten(entries), func(entry iceberg.ManifestEntry, _ int) bool {
+		return entry.DataFile().ContentType() == iceberg.EntryContentData
+	})
+
+	for _, entry := range data {
+		var df = entry.DataFile()
+
+		match, err := filter.match(df)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !match {
+			continue
+		}
+
+		deleteFiles, err := dataFileDeletes(t.Metadata(), entry, deletes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if conf.FailOnDeleteFiles && len(deleteFiles) > 0 {
+			return nil, fmt.Errorf("data file %s has delete files", df.FilePath())
+		}
+
+		res = append(res, PlanFilesItem{
+			FilePath:      df.FilePath(),
+			FileFormat:    string(df.FileFormat()),
+			RecordCount:   df.Count(),
+			FileSizeBytes: df.FileSizeBytes(),
+			DeleteFiles:   deleteFiles,
+		})
+	}
+
+	slices.SortFunc(res, func(a, b PlanFilesItem) int { return strings.Compare(a.FilePath, b.FilePath) })
+
+	return res, nil
+}
+
+// dataFileDeletes returns the delete files that may apply to a data file: position deletes
+// with a data sequence number not lower than the one of the file, whose file_path bounds
+// include it, and equality deletes with a higher data sequence number, either global or
+// in the partition of the file.
+func dataFileDeletes(md table.Metadata, entry iceberg.ManifestEntry, deletes []iceberg.ManifestEntry) ([]string, error) {
+	var (
+		df       = entry.DataFile()
+		filePath = iceberg.PositionalDeleteSchema.Field(0)
+		res      []string
+	)
+
+	for _, d := range deletes {
+		var del = d.DataFile()
+
+		switch del.ContentType() {
+		case iceberg.EntryContentPosDeletes:
+			if d.SequenceNum() < entry.SequenceNum() {
+				continue
+			}
+
+			columns, err := columnBounds([]iceberg.NestedField{filePath}, del)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !columns.mayEqual(filePath.ID, iceberg.NewLiteral(df.FilePath())) {
+				continue
+			}
+		case iceberg.EntryContentEqDeletes:
+			if d.SequenceNum() <= entry.SequenceNum() {
+				continue
+			}
+
+			spec, found := lo.Find(md.PartitionSpecs(), func(spec iceberg.PartitionSpec) bool {
+				return spec.ID() == int(del.SpecID())
+			})
+
+			if !found {
+				return nil, fmt.Errorf("partition spec %d not found", del.SpecID())
+			}
+
+			if !spec.IsUnpartitioned() && (del.SpecID() != df.SpecID() || !reflect.DeepEqual(del.Partition(), df.Partition())) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		res = append(res, del.FilePath())
+	}
+
+	return res, nil
+}
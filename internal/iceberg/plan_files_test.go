@@ -0,0 +1,56 @@
+package iceberg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanFiles(t *testing.T) {
+	var (
+		ctx  = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir  = t.TempDir()
+		conf = CreateOrAddFilesConfig{PartitionBy: "category"}
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1, 10}, []string{"a", "a"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{11, 20}, []string{"a", "a"})
+	writeTestParquetFile(t, dir, "3.parquet", []int64{15, 30}, []string{"b", "b"})
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet", "3.parquet"}, nil, conf))
+
+	var tests = []struct {
+		filter   string
+		expected []string
+	}{
+		{"", []string{"1.parquet", "2.parquet", "3.parquet"}},
+		{"id >= 12", []string{"2.parquet", "3.parquet"}},
+		{"id >= 12 AND category = 'a'", []string{"2.parquet"}},
+		{"id < 5 OR category IN ('b')", []string{"1.parquet", "3.parquet"}},
+		{"id > 30", []string{}},
+		{"id IN (5, 30)", []string{"1.parquet", "3.parquet"}},
+		{"NOT id < 12", []string{"2.parquet", "3.parquet"}},
+		{"category LIKE 'b%'", []string{"3.parquet"}},
+		{"category IS NULL", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			items, err := PlanFiles(ctx, "file://"+dir, PlanFilesConfig{Filter: test.filter})
+			require.NoError(t, err)
+			require.Equal(t, test.expected, lo.Map(items, func(item PlanFilesItem, _ int) string {
+				return filepath.Base(item.FilePath)
+			}))
+		})
+	}
+
+	_, err := PlanFiles(ctx, "file://"+dir, PlanFilesConfig{Filter: "unknown = 1"})
+	require.ErrorContains(t, err, "invalid filter")
+
+	_, err = PlanFiles(ctx, "file://"+dir, PlanFilesConfig{Filter: "id = 'abc'"})
+	require.ErrorContains(t, err, "invalid filter")
+}
@@ -1,7 +1,11 @@
 package iceberg
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/apache/iceberg-go"
 )
@@ -16,3 +20,35 @@ func ParseProperties(ss []string) iceberg.Properties {
 
 	return res
 }
+
+var daysDurationRegexp = regexp.MustCompile(`^(\d+)d(.*)$`)
+
+// ParseDuration parses a duration like time.ParseDuration, with an additional d (24h)
+// unit, e.g. 3d or 1d12h.
+func ParseDuration(s string) (time.Duration, error) {
+	var m = daysDurationRegexp.FindStringSubmatch(s)
+
+	if m == nil {
+		return time.ParseDuration(s)
+	}
+
+	days, err := strconv.ParseInt(m[1], 10, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+
+	var d = time.Duration(days) * 24 * time.Hour
+
+	if len(m[2]) == 0 {
+		return d, nil
+	}
+
+	rest, err := time.ParseDuration(m[2])
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+
+	return d + rest, nil
+}
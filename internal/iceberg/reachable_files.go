@@ -0,0 +1,90 @@
+package iceberg
+
+import (
+	"context"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/sourcegraph/conc/iter"
+)
+
+type ReachableFilesConfig struct {
+	// AllSnapshots includes the files of every snapshot of the table, staged snapshots and
+	// snapshots of other refs included, instead of the current snapshot only.
+	AllSnapshots bool
+	// DataOnly excludes metadata files: table metadata, manifest lists and manifests.
+	DataOnly bool
+	// IncludeDeleteFiles includes delete manifests and the delete files they reference.
+	IncludeDeleteFiles bool
+	// LiveEntriesOnly ignores the files the snapshots mark as deleted in their manifests.
+	LiveEntriesOnly bool
+}
+
+// ReachableFiles returns the locations of the files referenced by the table metadata.
+func ReachableFiles(ctx context.Context, t *table.Table, conf ReachableFilesConfig) (mapset.Set[string], error) {
+	var (
+		io        = iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx))
+		snapshots []table.Snapshot
+	)
+
+	if conf.AllSnapshots {
+		snapshots = t.Metadata().Snapshots()
+	} else if snap := t.CurrentSnapshot(); snap != nil {
+		snapshots = []table.Snapshot{*snap}
+	}
+
+	sets, err := iter.MapErr(snapshots, func(snap *table.Snapshot) (mapset.Set[string], error) {
+		var files = mapset.NewSet[string]()
+
+		if !conf.DataOnly {
+			files.Add(snap.ManifestList)
+		}
+
+		mans, err := snap.Manifests(io)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, man := range mans {
+			if man.ManifestContent() == iceberg.ManifestContentDeletes && !conf.IncludeDeleteFiles {
+				continue
+			}
+
+			if !conf.DataOnly {
+				files.Add(man.FilePath())
+			}
+
+			entries, err := man.FetchEntries(io, conf.LiveEntriesOnly)
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, entry := range entries {
+				files.Add(entry.DataFile().FilePath())
+			}
+		}
+
+		return files, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files = mapset.NewSet[string]()
+
+	for _, set := range sets {
+		files = files.Union(set)
+	}
+
+	if !conf.DataOnly {
+		files.Add(t.MetadataLocation())
+	}
+
+	return files, nil
+}
@@ -0,0 +1,236 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/iceberg-go/table"
+)
+
+type RefConfig struct {
+	// SnapshotId is the snapshot the ref points to, the current snapshot by default.
+	SnapshotId int64
+	// WapId points the ref to the snapshot staged with this wap.id instead, e.g. to audit
+	// it by reading the branch.
+	WapId string
+	// MaxRefAgeMs is the age after which expire-snapshots drops the ref. Refs are kept
+	// forever by default, the main branch always is.
+	MaxRefAgeMs int64
+	// MaxSnapshotAgeMs is the age of the ancestors of the branch head kept by
+	// expire-snapshots. Branches only.
+	MaxSnapshotAgeMs int64
+	// MinSnapshotsToKeep is the number of snapshots of the branch kept by expire-snapshots
+	// regardless of their age. Branches only.
+	MinSnapshotsToKeep int
+}
+
+type RefChange struct {
+	Name               string        `json:"name"`
+	Type               table.RefType `json:"type"`
+	PreviousSnapshotId *int64        `json:"previous_snapshot_id"`
+	SnapshotId         *int64        `json:"snapshot_id"`
+}
+
+// CreateBranch creates a branch pointing to a snapshot of the table. Files can then be
+// committed to it without changing the current snapshot, and published with FastForward.
+func CreateBranch(ctx context.Context, tableLocation string, name string, conf RefConfig) (*RefChange, error) {
+	return createRef(ctx, tableLocation, name, table.BranchRef, conf)
+}
+
+// CreateTag creates a tag pointing to a snapshot of the table. The snapshot is kept by
+// expire-snapshots as long as the tag exists.
+func CreateTag(ctx context.Context, tableLocation string, name string, conf RefConfig) (*RefChange, error) {
+	if conf.MaxSnapshotAgeMs > 0 || conf.MinSnapshotsToKeep > 0 {
+		return nil, fmt.Errorf("snapshot retention settings only apply to branches")
+	}
+
+	return createRef(ctx, tableLocation, name, table.TagRef, conf)
+}
+
+func createRef(ctx context.Context, tableLocation string, name string, refType table.RefType, conf RefConfig) (*RefChange, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("ref name cannot be empty")
+	}
+
+	return updateRef(ctx, tableLocation, func(md table.Metadata) (*RefChange, []table.Update, error) {
+		if _, found := findRef(md, name); found {
+			return nil, nil, fmt.Errorf("ref %s already exists", name)
+		}
+
+		var snap = md.CurrentSnapshot()
+
+		if conf.SnapshotId != 0 || len(conf.WapId) > 0 {
+			var err error
+
+			if snap, err = stagedSnapshot(md, PublishConfig{WapId: conf.WapId, SnapshotId: conf.SnapshotId}); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if snap == nil {
+			return nil, nil, fmt.Errorf("table has no current snapshot")
+		}
+
+		var (
+			change = RefChange{Name: name, Type: refType, SnapshotId: &snap.SnapshotID}
+			update = table.NewSetSnapshotRefUpdate(
+				name,
+				snap.SnapshotID,
+				refType,
+				conf.MaxRefAgeMs,
+				conf.MaxSnapshotAgeMs,
+				conf.MinSnapshotsToKeep,
+			)
+		)
+
+		return &change, []table.Update{update}, nil
+	})
+}
+
+// DropRef removes a branch or a tag. Its snapshots are removed by the next
+// expire-snapshots unless they are referenced by another ref.
+func DropRef(ctx context.Context, tableLocation string, name string) (*RefChange, error) {
+	if name == table.MainBranch {
+		return nil, fmt.Errorf("the main branch cannot be dropped")
+	}
+
+	return updateRef(ctx, tableLocation, func(md table.Metadata) (*RefChange, []table.Update, error) {
+		ref, found := findRef(md, name)
+
+		if !found {
+			return nil, nil, fmt.Errorf("ref %s not found", name)
+		}
+
+		var change = RefChange{Name: name, Type: ref.SnapshotRefType, PreviousSnapshotId: &ref.SnapshotID}
+
+		return &change, []table.Update{table.NewRemoveSnapshotRefUpdate(name)}, nil
+	})
+}
+
+// FastForward moves a branch to the head of another ref. The head of the branch must be
+// an ancestor of the target snapshot, so no commit of the branch is lost. Fast-forwarding
+// main publishes the changes of the other ref.
+func FastForward(ctx context.Context, tableLocation string, branch string, to string) (*RefChange, error) {
+	return updateRef(ctx, tableLocation, func(md table.Metadata) (*RefChange, []table.Update, error) {
+		ref, found := findRef(md, branch)
+
+		if !found {
+			return nil, nil, fmt.Errorf("branch %s not found", branch)
+		}
+
+		if ref.SnapshotRefType != table.BranchRef {
+			return nil, nil, fmt.Errorf("%s is not a branch", branch)
+		}
+
+		target, found := findRef(md, to)
+
+		if !found {
+			return nil, nil, fmt.Errorf("ref %s not found", to)
+		}
+
+		var change = RefChange{
+			Name:               branch,
+			Type:               table.BranchRef,
+			PreviousSnapshotId: &ref.SnapshotID,
+			SnapshotId:         &target.SnapshotID,
+		}
+
+		if ref.SnapshotID == target.SnapshotID {
+			return &change, nil, nil
+		}
+
+		if !isAncestorOf(md, ref.SnapshotID, target.SnapshotID) {
+			return nil, nil, fmt.Errorf("cannot fast-forward %s: its head is not an ancestor of the head of %s", branch, to)
+		}
+
+		return &change, []table.Update{setBranchHeadUpdate(md, branch, target.SnapshotID)}, nil
+	})
+}
+
+// updateRef commits the ref updates returned by fn, asserting that the changed ref did
+// not move since the table was loaded.
+func updateRef(
+	ctx context.Context,
+	tableLocation string,
+	fn func(md table.Metadata) (*RefChange, []table.Update, error),
+) (*RefChange, error) {
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var md = t.Metadata()
+
+	change, updates, err := fn(md)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(updates) == 0 {
+		return change, nil
+	}
+
+	var requirements = []table.Requirement{
+		table.AssertTableUUID(md.TableUUID()),
+		table.AssertRefSnapshotID(change.Name, change.PreviousSnapshotId),
+	}
+
+	if _, _, err := cat.CommitTable(ctx, t, requirements, updates); err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+// setBranchHeadUpdate moves a branch to a snapshot. The retention settings of an existing
+// branch are kept, as setting a ref replaces it.
+func setBranchHeadUpdate(md table.Metadata, branch string, snapshotID int64) table.Update {
+	var ref, _ = findRef(md, branch)
+
+	return table.NewSetSnapshotRefUpdate(
+		branch,
+		snapshotID,
+		table.BranchRef,
+		refSetting(ref.MaxRefAgeMs, 0),
+		refSetting(ref.MaxSnapshotAgeMs, 0),
+		refSetting(ref.MinSnapshotsToKeep, 0),
+	)
+}
+
+func findRef(md table.Metadata, name string) (table.SnapshotRef, bool) {
+	for refName, ref := range md.Refs() {
+		if refName == name {
+			return ref, true
+		}
+	}
+
+	return table.SnapshotRef{}, false
+}
+
+// isAncestorOf returns true if the snapshot is the given head or one of its ancestors.
+func isAncestorOf(md table.Metadata, snapshotID int64, headID int64) bool {
+	for snap := md.SnapshotByID(headID); snap != nil; snap = parentSnapshot(md, snap) {
+		if snap.SnapshotID == snapshotID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refSetting returns a retention setting of a ref, or the default when unset.
+func refSetting[T int | int64](v *T, defaultValue T) T {
+	if v == nil {
+		return defaultValue
+	}
+
+	return *v
+}
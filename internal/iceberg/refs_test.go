@@ -0,0 +1,139 @@
+package iceberg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefs(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	var load = func() *table.Table {
+		cat, err := NewVersionHintCatalog("file://" + dir)
+		require.NoError(t, err)
+
+		tbl, err := cat.LoadTable(ctx, nil, nil)
+		require.NoError(t, err)
+
+		return tbl
+	}
+
+	var refDataFiles = func(name string) []iceberg.DataFile {
+		dataFiles, err := SnapshotDataFiles(iceio.NewObjectStoreIO(objstr.FromContext(ctx)), load().Metadata().SnapshotByName(name))
+		require.NoError(t, err)
+		return dataFiles
+	}
+
+	for _, name := range []string{"1.parquet", "2.parquet", "3.parquet"} {
+		writeTestParquetFile(t, dir, name, []int64{1}, []string{"a"})
+	}
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	var initial = load().CurrentSnapshot().SnapshotID
+
+	change, err := CreateBranch(ctx, "file://"+dir, "audit", RefConfig{MinSnapshotsToKeep: 2})
+	require.NoError(t, err)
+	require.Equal(t, initial, *change.SnapshotId)
+	require.Nil(t, change.PreviousSnapshotId)
+
+	_, err = CreateBranch(ctx, "file://"+dir, "audit", RefConfig{})
+	require.ErrorContains(t, err, "ref audit already exists")
+
+	// commits to the branch leave the current snapshot unchanged
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{Branch: "audit"}))
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, []string{"1.parquet"}, []string{"3.parquet"}, nil, ReplaceFilesConfig{Branch: "audit"}))
+	require.Equal(t, initial, load().CurrentSnapshot().SnapshotID)
+	require.Len(t, refDataFiles(table.MainBranch), 1)
+	require.Len(t, refDataFiles("audit"), 2)
+
+	_, err = FastForward(ctx, "file://"+dir, "audit", table.MainBranch)
+	require.ErrorContains(t, err, "its head is not an ancestor of the head of main")
+
+	change, err = FastForward(ctx, "file://"+dir, table.MainBranch, "audit")
+	require.NoError(t, err)
+	require.Equal(t, initial, *change.PreviousSnapshotId)
+	require.Equal(t, load().Metadata().SnapshotByName("audit").SnapshotID, load().CurrentSnapshot().SnapshotID)
+	require.Len(t, currentTestDataFiles(t, ctx, dir), 2)
+
+	change, err = CreateTag(ctx, "file://"+dir, "v1", RefConfig{SnapshotId: initial})
+	require.NoError(t, err)
+	require.Equal(t, table.TagRef, change.Type)
+
+	_, err = CreateTag(ctx, "file://"+dir, "v2", RefConfig{MinSnapshotsToKeep: 1})
+	require.ErrorContains(t, err, "only apply to branches")
+
+	_, err = FastForward(ctx, "file://"+dir, "v1", table.MainBranch)
+	require.ErrorContains(t, err, "v1 is not a branch")
+
+	// a missing branch is created from the current snapshot
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{Branch: "new"}))
+	require.Equal(t, load().CurrentSnapshot().SnapshotID, *load().Metadata().SnapshotByName("new").ParentSnapshotID)
+	require.Len(t, refDataFiles("new"), 3)
+
+	for _, name := range []string{"audit", "v1", "new"} {
+		change, err = DropRef(ctx, "file://"+dir, name)
+		require.NoError(t, err)
+		require.Nil(t, change.SnapshotId)
+		require.Nil(t, load().Metadata().SnapshotByName(name))
+	}
+
+	_, err = DropRef(ctx, "file://"+dir, table.MainBranch)
+	require.ErrorContains(t, err, "main branch cannot be dropped")
+
+	_, err = DropRef(ctx, "file://"+dir, "audit")
+	require.ErrorContains(t, err, "ref audit not found")
+}
+
+func TestBranchRetentionKeptOnCommit(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	var auditRef = func() table.SnapshotRef {
+		cat, err := NewVersionHintCatalog("file://" + dir)
+		require.NoError(t, err)
+
+		tbl, err := cat.LoadTable(ctx, nil, nil)
+		require.NoError(t, err)
+
+		ref, found := findRef(tbl.Metadata(), "audit")
+		require.True(t, found)
+		return ref
+	}
+
+	for _, name := range []string{"1.parquet", "2.parquet", "3.parquet"} {
+		writeTestParquetFile(t, dir, name, []int64{1}, []string{"a"})
+	}
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	_, err := CreateBranch(ctx, "file://"+dir, "audit", RefConfig{MaxRefAgeMs: 3_600_000, MaxSnapshotAgeMs: 1, MinSnapshotsToKeep: 3})
+	require.NoError(t, err)
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{Branch: "audit"}))
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"3.parquet"}, nil, CreateOrAddFilesConfig{Branch: "audit"}))
+
+	var ref = auditRef()
+	require.Equal(t, int64(3_600_000), *ref.MaxRefAgeMs)
+	require.Equal(t, int64(1), *ref.MaxSnapshotAgeMs)
+	require.Equal(t, 3, *ref.MinSnapshotsToKeep)
+
+	// the branch keeps its 3 snapshots despite the table-wide retention
+	res, err := ExpireSnapshots(ctx, "file://"+dir, ExpireSnapshotsConfig{OlderThan: time.Nanosecond, RetainLast: 1})
+	require.NoError(t, err)
+	require.Empty(t, res.ExpiredSnapshotIds)
+	require.Empty(t, res.RemovedRefs)
+	require.Equal(t, 3, *auditRef().MinSnapshotsToKeep)
+}
@@ -0,0 +1,160 @@
+package iceberg
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/sourcegraph/conc/pool"
+)
+
+const (
+	RemoveOrphanFilesOlderThanDefault   = 3 * 24 * time.Hour
+	RemoveOrphanFilesConcurrencyDefault = 16
+)
+
+type RemoveOrphanFilesConfig struct {
+	// OlderThan is the minimum age of the removed files, so that files written by
+	// operations still in progress (e.g. ClickHouse inserts not added yet) are kept.
+	// Defaults to 3 days.
+	OlderThan time.Duration
+	// DryRun only reports orphan files.
+	DryRun bool
+	// Concurrency is the maximum number of concurrent deletions, 16 by default.
+	Concurrency int
+}
+
+type OrphanFile struct {
+	Location     string    `json:"location"`
+	Size         uint64    `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// RemoveOrphanFiles deletes the files stored under the table location that are not
+// referenced by its metadata: neither by the current or a previous metadata file of the
+// metadata log, nor by any snapshot of the table (manifest lists, manifests, data and
+// delete files), whatever the ref it belongs to.
+func RemoveOrphanFiles(ctx context.Context, tableLocation string, conf RemoveOrphanFilesConfig) ([]OrphanFile, error) {
+	if conf.OlderThan <= 0 {
+		conf.OlderThan = RemoveOrphanFilesOlderThanDefault
+	}
+
+	if conf.Concurrency <= 0 {
+		conf.Concurrency = RemoveOrphanFilesConcurrencyDefault
+	}
+
+	location, err := url.Parse(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		os     = objstr.FromContextOrDefault(ctx)
+		cutoff = time.Now().Add(-conf.OlderThan)
+		prefix = strings.TrimSuffix(location.String(), "/") + "/"
+	)
+
+	// files are listed before the table is loaded, so that files committed meanwhile are
+	// known to be reachable
+	objects, err := os.ListPrefix(ctx, location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cat, err := NewVersionHintCatalog(location.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reachable, err := ReachableFiles(ctx, t, ReachableFilesConfig{AllSnapshots: true, IncludeDeleteFiles: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for entry := range t.Metadata().PreviousFiles() {
+		reachable.Add(entry.MetadataFile)
+	}
+
+	var reachableKeys = make(map[string]bool, reachable.Cardinality())
+
+	for _, loc := range reachable.ToSlice() {
+		reachableKeys[fileLocationKey(loc)] = true
+	}
+
+	var orphans = []OrphanFile{}
+
+	for _, obj := range objects {
+		var loc = obj.URL.String()
+
+		if !strings.HasPrefix(loc, prefix) || reachableKeys[fileLocationKey(loc)] {
+			continue
+		}
+
+		// the version hint and its lock are not referenced by the metadata
+		if strings.HasPrefix(path.Base(obj.URL.Path), "version-hint.text") {
+			continue
+		}
+
+		if obj.Metadata == nil || !obj.Metadata.ModificationDate.Before(cutoff) {
+			continue
+		}
+
+		orphans = append(orphans, OrphanFile{
+			Location:     loc,
+			Size:         obj.Metadata.Size,
+			LastModified: obj.Metadata.ModificationDate,
+		})
+	}
+
+	slices.SortFunc(orphans, func(a, b OrphanFile) int { return strings.Compare(a.Location, b.Location) })
+
+	if conf.DryRun {
+		return orphans, nil
+	}
+
+	var p = pool.New().WithErrors().WithContext(ctx).WithMaxGoroutines(conf.Concurrency)
+
+	for _, orphan := range orphans {
+		p.Go(func(ctx context.Context) error {
+			u, err := url.Parse(orphan.Location)
+
+			if err != nil {
+				return err
+			}
+
+			return os.Delete(ctx, u)
+		})
+	}
+
+	if err := p.Wait(); err != nil {
+		return nil, err
+	}
+
+	return orphans, nil
+}
+
+// fileLocationKey identifies a file regardless of the URL scheme used to reference it
+// (e.g. s3:// and s3a://).
+func fileLocationKey(loc string) string {
+	u, err := url.Parse(loc)
+
+	if err != nil {
+		return loc
+	}
+
+	return u.Host + path.Clean("/"+u.Path)
+}
@@ -0,0 +1,83 @@
+package iceberg
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveOrphanFiles(t *testing.T) {
+	var (
+		ctx     = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		root    = t.TempDir()
+		dir     = filepath.Join(root, "table")
+		sibling = filepath.Join(root, "table0")
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1}, []string{"a"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{1}, []string{"a"})
+	writeTestParquetFile(t, dir, "orphan.parquet", []int64{1}, []string{"a"})
+	writeTestParquetFile(t, sibling, "1.parquet", []int64{1}, []string{"a"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.NoError(t, ReplaceFiles(ctx, "file://"+dir, []string{"1.parquet"}, []string{"2.parquet"}, nil, ReplaceFilesConfig{}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "metadata", "stray.avro"), []byte("x"), 0644))
+
+	// every file is 2 days old
+	var old = time.Now().Add(-48 * time.Hour)
+
+	require.NoError(t, filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		return os.Chtimes(path, old, old)
+	}))
+
+	writeTestParquetFile(t, dir, "in-flight.parquet", []int64{1}, []string{"a"})
+
+	orphans, err := RemoveOrphanFiles(ctx, "file://"+dir, RemoveOrphanFilesConfig{DryRun: true})
+	require.NoError(t, err)
+	require.Empty(t, orphans)
+
+	orphans, err = RemoveOrphanFiles(ctx, "file://"+dir, RemoveOrphanFilesConfig{OlderThan: time.Hour, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"file://" + filepath.Join(dir, "data", "orphan.parquet"),
+		"file://" + filepath.Join(dir, "metadata", "stray.avro"),
+	}, lo.Map(orphans, func(o OrphanFile, _ int) string { return o.Location }))
+	require.FileExists(t, filepath.Join(dir, "data", "orphan.parquet"))
+
+	_, err = RemoveOrphanFiles(ctx, "file://"+dir, RemoveOrphanFilesConfig{OlderThan: time.Hour})
+	require.NoError(t, err)
+	require.NoFileExists(t, filepath.Join(dir, "data", "orphan.parquet"))
+	require.NoFileExists(t, filepath.Join(dir, "metadata", "stray.avro"))
+	require.FileExists(t, filepath.Join(dir, "data", "1.parquet"))
+	require.FileExists(t, filepath.Join(dir, "data", "in-flight.parquet"))
+	require.FileExists(t, filepath.Join(sibling, "data", "1.parquet"))
+	require.Len(t, currentTestDataFiles(t, ctx, dir), 1)
+}
+
+func TestParseDuration(t *testing.T) {
+	for s, expected := range map[string]time.Duration{
+		"3d":     72 * time.Hour,
+		"1d12h":  36 * time.Hour,
+		"90m":    90 * time.Minute,
+		"0d1.5h": 90 * time.Minute,
+	} {
+		d, err := ParseDuration(s)
+		require.NoError(t, err, s)
+		require.Equal(t, expected, d, s)
+	}
+
+	for _, s := range []string{"", "3 days", "d", "1dx"} {
+		_, err := ParseDuration(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,232 @@
+package iceberg
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
+
+	"github.com/agnosticeng/objstr"
+	objstrerrs "github.com/agnosticeng/objstr/errors"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/samber/lo"
+)
+
+type RepairVersionHintConfig struct {
+	DryRun bool
+	// Force points the hint to the newest lineage head even when the current hint names
+	// a valid metadata file.
+	Force bool
+}
+
+type InvalidMetadataFile struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// MetadataFork describes a metadata file that was used as the base of more than one commit,
+// which happens when concurrent commits raced on the version hint.
+type MetadataFork struct {
+	Parent        string   `json:"parent"`
+	Children      []string `json:"children"`
+	Kept          string   `json:"kept,omitempty"`
+	LostSnapshots []int64  `json:"lost_snapshots"`
+}
+
+type RepairVersionHintReport struct {
+	PreviousHint string                `json:"previous_hint"`
+	NewHint      string                `json:"new_hint"`
+	NewestHead   string                `json:"newest_head"`
+	Updated      bool                  `json:"updated"`
+	DryRun       bool                  `json:"dry_run"`
+	InvalidFiles []InvalidMetadataFile `json:"invalid_files"`
+	Forks        []MetadataFork        `json:"forks"`
+}
+
+// RepairVersionHint points a missing or invalid version hint to the newest valid metadata
+// file. Metadata files are linked to their parent through the metadata-log or, for files
+// written without one, through the parent of their current snapshot. The newest file is
+// the lineage head with the highest sequence number. A hint naming a valid metadata file
+// is kept unless conf.Force is set, as a newer head may be the leftover of a commit that
+// failed before swapping the hint.
+func RepairVersionHint(ctx context.Context, tableLocation string, conf RepairVersionHintConfig) (*RepairVersionHintReport, error) {
+	location, err := url.Parse(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		os                  = objstr.FromContextOrDefault(ctx)
+		store               = VersionHintStoreFromContextOrDefault(ctx)
+		versionHintLocation = location.JoinPath("metadata", "version-hint.text")
+		report              = RepairVersionHintReport{DryRun: conf.DryRun}
+	)
+
+	report.PreviousHint, err = store.Read(ctx, versionHintLocation)
+
+	if err != nil && !errors.Is(err, objstrerrs.ErrObjectNotFound) {
+		return nil, err
+	}
+
+	files, err := FetchAllMetadataFiles(ctx, location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var valid = make(map[string]*MetadataFile)
+
+	for _, f := range files {
+		var name = filepath.Base(f.Path)
+
+		if f.Err != nil {
+			report.InvalidFiles = append(report.InvalidFiles, InvalidMetadataFile{Name: name, Error: f.Err.Error()})
+			continue
+		}
+
+		if snap := f.CurrentSnapshot(); snap != nil {
+			u, err := url.Parse(snap.ManifestList)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err := os.ReadMetadata(ctx, u); err != nil {
+				report.InvalidFiles = append(report.InvalidFiles, InvalidMetadataFile{
+					Name:  name,
+					Error: fmt.Sprintf("manifest list %s: %s", snap.ManifestList, err),
+				})
+				continue
+			}
+		}
+
+		valid[name] = f
+	}
+
+	if len(valid) == 0 {
+		return nil, fmt.Errorf("no valid metadata file found under %s", location.JoinPath("metadata"))
+	}
+
+	var (
+		children = make(map[string][]string)
+		hasChild = mapset.NewThreadUnsafeSet[string]()
+	)
+
+	for name, f := range valid {
+		if parent := metadataFileParent(f, valid); len(parent) > 0 {
+			children[parent] = append(children[parent], name)
+			hasChild.Add(parent)
+		}
+	}
+
+	var heads = lo.Filter(lo.Keys(valid), func(name string, _ int) bool { return !hasChild.Contains(name) })
+
+	report.NewestHead = slices.MaxFunc(heads, func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(valid[a].LastSequenceNumber(), valid[b].LastSequenceNumber()),
+			cmp.Compare(valid[a].LastUpdatedMillis(), valid[b].LastUpdatedMillis()),
+			cmp.Compare(a, b),
+		)
+	})
+
+	if _, found := valid[report.PreviousHint]; found && !conf.Force {
+		report.NewHint = report.PreviousHint
+	} else {
+		report.NewHint = report.NewestHead
+	}
+
+	var (
+		lineage      = mapset.NewThreadUnsafeSet[string]()
+		keptSnapshot = mapset.NewThreadUnsafeSet[int64]()
+	)
+
+	for name := report.NewHint; valid[name] != nil && !lineage.Contains(name); name = metadataFileParent(valid[name], valid) {
+		lineage.Add(name)
+	}
+
+	for _, snap := range valid[report.NewHint].Snapshots() {
+		keptSnapshot.Add(snap.SnapshotID)
+	}
+
+	for _, parent := range slices.Sorted(maps.Keys(children)) {
+		if len(children[parent]) < 2 {
+			continue
+		}
+
+		var fork = MetadataFork{Parent: parent, Children: slices.Sorted(slices.Values(children[parent]))}
+		var lost = mapset.NewThreadUnsafeSet[int64]()
+
+		for _, child := range fork.Children {
+			if lineage.Contains(child) {
+				fork.Kept = child
+				continue
+			}
+
+			for _, desc := range metadataFileDescendants(child, children) {
+				for _, snap := range valid[desc].Snapshots() {
+					if !keptSnapshot.Contains(snap.SnapshotID) {
+						lost.Add(snap.SnapshotID)
+					}
+				}
+			}
+		}
+
+		fork.LostSnapshots = lost.ToSlice()
+		slices.Sort(fork.LostSnapshots)
+		report.Forks = append(report.Forks, fork)
+	}
+
+	if report.NewHint == report.PreviousHint || conf.DryRun {
+		return &report, nil
+	}
+
+	if err := store.Swap(ctx, versionHintLocation, report.PreviousHint, report.NewHint); err != nil {
+		return nil, err
+	}
+
+	report.Updated = true
+	return &report, nil
+}
+
+func metadataFileParent(f *MetadataFile, files map[string]*MetadataFile) string {
+	var previous = slices.Collect(f.PreviousFiles())
+
+	if len(previous) > 0 {
+		return filepath.Base(previous[len(previous)-1].MetadataFile)
+	}
+
+	var snap = f.CurrentSnapshot()
+
+	if snap == nil || snap.ParentSnapshotID == nil {
+		return ""
+	}
+
+	var candidates []string
+
+	for name, candidate := range files {
+		if s := candidate.CurrentSnapshot(); s != nil && s.SnapshotID == *snap.ParentSnapshotID {
+			candidates = append(candidates, name)
+		}
+	}
+
+	if len(candidates) != 1 {
+		return ""
+	}
+
+	return candidates[0]
+}
+
+func metadataFileDescendants(name string, children map[string][]string) []string {
+	var res = []string{name}
+
+	for _, child := range children[name] {
+		res = append(res, metadataFileDescendants(child, children)...)
+	}
+
+	return res
+}
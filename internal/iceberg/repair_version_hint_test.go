@@ -0,0 +1,121 @@
+package iceberg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	osutils "github.com/agnosticeng/objstr/utils"
+	"github.com/apache/iceberg-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairVersionHint(t *testing.T) {
+	var (
+		ctx      = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		location = "file://" + t.TempDir()
+	)
+
+	cat, err := NewVersionHintCatalog(location)
+	require.NoError(t, err)
+
+	base, err := cat.CreateTable(
+		ctx,
+		nil,
+		iceberg.NewSchema(0, iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64, Required: true}),
+	)
+	require.NoError(t, err)
+
+	var commit = func(value string) string {
+		var tx = base.NewTransaction()
+		require.NoError(t, tx.SetProperties(iceberg.Properties{"value": value}))
+		tbl, err := tx.Commit(ctx)
+		require.NoError(t, err)
+		return filepath.Base(tbl.MetadataLocation())
+	}
+
+	var (
+		versionHintLocation = cat.tableLocation.JoinPath("metadata", "version-hint.text")
+		baseName            = filepath.Base(base.MetadataLocation())
+		first               = commit("a")
+	)
+
+	// simulate a lost race: a second commit is made on top of the same base
+	require.NoError(t, osutils.CreateObject(ctx, objstr.FromContext(ctx), versionHintLocation, []byte(baseName)))
+	var second = commit("b")
+
+	require.NoError(t, osutils.CreateObject(ctx, objstr.FromContext(ctx), cat.tableLocation.JoinPath("metadata", "broken.metadata.json"), []byte("{")))
+	require.NoError(t, objstr.FromContext(ctx).Delete(ctx, versionHintLocation))
+
+	report, err := RepairVersionHint(ctx, location, RepairVersionHintConfig{DryRun: true})
+	require.NoError(t, err)
+	require.False(t, report.Updated)
+	require.Equal(t, "", report.PreviousHint)
+	require.Equal(t, second, report.NewHint)
+	require.Len(t, report.InvalidFiles, 1)
+	require.Equal(t, "broken.metadata.json", report.InvalidFiles[0].Name)
+	require.Len(t, report.Forks, 1)
+	require.Equal(t, baseName, report.Forks[0].Parent)
+	require.ElementsMatch(t, []string{first, second}, report.Forks[0].Children)
+	require.Equal(t, second, report.Forks[0].Kept)
+
+	report, err = RepairVersionHint(ctx, location, RepairVersionHintConfig{})
+	require.NoError(t, err)
+	require.True(t, report.Updated)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "b", tbl.Properties()["value"])
+}
+
+func TestRepairVersionHintKeepsValidHint(t *testing.T) {
+	var (
+		ctx      = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		location = "file://" + t.TempDir()
+	)
+
+	cat, err := NewVersionHintCatalog(location)
+	require.NoError(t, err)
+
+	tbl, err := cat.CreateTable(
+		ctx,
+		nil,
+		iceberg.NewSchema(0, iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64, Required: true}),
+	)
+	require.NoError(t, err)
+
+	var tx = tbl.NewTransaction()
+	require.NoError(t, tx.SetProperties(iceberg.Properties{"value": "a"}))
+	tbl, err = tx.Commit(ctx)
+	require.NoError(t, err)
+
+	var (
+		versionHintLocation = cat.tableLocation.JoinPath("metadata", "version-hint.text")
+		hint                = filepath.Base(tbl.MetadataLocation())
+	)
+
+	// simulate a commit that crashed after writing its metadata file
+	tx = tbl.NewTransaction()
+	require.NoError(t, tx.SetProperties(iceberg.Properties{"value": "b"}))
+	stray, err := tx.Commit(ctx)
+	require.NoError(t, err)
+	require.NoError(t, osutils.CreateObject(ctx, objstr.FromContext(ctx), versionHintLocation, []byte(hint)))
+
+	report, err := RepairVersionHint(ctx, location, RepairVersionHintConfig{})
+	require.NoError(t, err)
+	require.False(t, report.Updated)
+	require.Equal(t, hint, report.PreviousHint)
+	require.Equal(t, hint, report.NewHint)
+	require.Equal(t, filepath.Base(stray.MetadataLocation()), report.NewestHead)
+	require.Empty(t, report.Forks)
+
+	tbl, err = cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "a", tbl.Properties()["value"])
+
+	report, err = RepairVersionHint(ctx, location, RepairVersionHintConfig{Force: true})
+	require.NoError(t, err)
+	require.True(t, report.Updated)
+	require.Equal(t, filepath.Base(stray.MetadataLocation()), report.NewHint)
+}
@@ -2,18 +2,30 @@ package iceberg
 
 import (
 	"context"
+	"fmt"
 	"net/url"
+	"strings"
 
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
 	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/samber/lo"
 )
 
+type ReplaceFilesConfig struct {
+	// Branch is the branch the files are replaced on, main by default.
+	Branch string
+}
+
 func ReplaceFiles(
 	ctx context.Context,
 	tableLocation string,
 	inputFiles []string,
 	outputFiles []string,
 	props iceberg.Properties,
+	conf ReplaceFilesConfig,
 ) error {
 	location, err := url.Parse(tableLocation)
 
@@ -42,15 +54,56 @@ func ReplaceFiles(
 		})
 	)
 
-	var tx = t.NewTransaction()
+	if len(conf.Branch) == 0 {
+		conf.Branch = table.MainBranch
+	}
+
+	if err := validateReplaceFiles(ctx, t, conf.Branch, inputLocations, outputLocations); err != nil {
+		return err
+	}
+
+	dataFiles, err := DataFilesFromParquetFiles(ctx, t, location, outputFiles)
 
-	if err := tx.ReplaceDataFiles(ctx, inputLocations, outputLocations, props); err != nil {
+	if err != nil {
 		return err
 	}
 
-	if _, err := tx.Commit(ctx); err != nil {
+	_, err = CommitSnapshot(ctx, cat, t, SnapshotUpdate{Branch: conf.Branch, Added: dataFiles, Deleted: inputLocations, Properties: props})
+	return err
+}
+
+// validateReplaceFiles checks that the replace operation still applies to the
+// branch head, which may have changed since the previous commit attempt.
+func validateReplaceFiles(ctx context.Context, t *table.Table, branch string, inputLocations []string, outputLocations []string) error {
+	var head = t.Metadata().SnapshotByName(branch)
+
+	if head == nil {
+		return &CommitConflictError{Operation: "replace", Reason: fmt.Sprintf("branch %s has no snapshot", branch)}
+	}
+
+	dataFiles, err := SnapshotDataFiles(iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx)), head)
+
+	if err != nil {
 		return err
 	}
 
+	var current = mapset.NewThreadUnsafeSet(lo.Map(dataFiles, func(df iceberg.DataFile, _ int) string {
+		return df.FilePath()
+	})...)
+
+	if missing := mapset.NewThreadUnsafeSet(inputLocations...).Difference(current); missing.Cardinality() > 0 {
+		return &CommitConflictError{
+			Operation: "replace",
+			Reason:    fmt.Sprintf("input files are not part of the branch head anymore: %s", strings.Join(missing.ToSlice(), ", ")),
+		}
+	}
+
+	if existing := mapset.NewThreadUnsafeSet(outputLocations...).Intersect(current); existing.Cardinality() > 0 {
+		return &CommitConflictError{
+			Operation: "replace",
+			Reason:    fmt.Sprintf("output files are already part of the branch head: %s", strings.Join(existing.ToSlice(), ", ")),
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,128 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/apache/iceberg-go/table"
+)
+
+type CurrentSnapshotChange struct {
+	PreviousSnapshotId *int64 `json:"previous_snapshot_id"`
+	SnapshotId         int64  `json:"snapshot_id"`
+}
+
+type RollbackConfig struct {
+	// SnapshotId is the snapshot to roll back to, which must be an ancestor of the current
+	// snapshot.
+	SnapshotId int64
+	// Timestamp is used when SnapshotId is not set: the table is rolled back to the last
+	// ancestor of the current snapshot committed at or before it.
+	Timestamp time.Time
+}
+
+// Rollback makes an ancestor of the current snapshot current again. Snapshots committed
+// after it are kept in the metadata until they expire, so a rollback can be undone with
+// SetCurrentSnapshot.
+func Rollback(ctx context.Context, tableLocation string, conf RollbackConfig) (*CurrentSnapshotChange, error) {
+	return updateCurrentSnapshot(ctx, tableLocation, func(md table.Metadata) (*table.Snapshot, error) {
+		var current = md.CurrentSnapshot()
+
+		if current == nil {
+			return nil, fmt.Errorf("table has no current snapshot")
+		}
+
+		for snap := current; snap != nil; snap = parentSnapshot(md, snap) {
+			if conf.SnapshotId != 0 && snap.SnapshotID == conf.SnapshotId {
+				return snap, nil
+			}
+
+			if conf.SnapshotId == 0 && snap.TimestampMs <= conf.Timestamp.UnixMilli() {
+				return snap, nil
+			}
+		}
+
+		if conf.SnapshotId != 0 {
+			if md.SnapshotByID(conf.SnapshotId) == nil {
+				return nil, fmt.Errorf("snapshot %d not found", conf.SnapshotId)
+			}
+
+			return nil, fmt.Errorf("snapshot %d is not an ancestor of the current snapshot", conf.SnapshotId)
+		}
+
+		return nil, fmt.Errorf("no ancestor of the current snapshot was committed before %s", conf.Timestamp.UTC().Format(time.RFC3339Nano))
+	})
+}
+
+// SetCurrentSnapshot makes any snapshot of the table current, e.g. to undo a rollback.
+func SetCurrentSnapshot(ctx context.Context, tableLocation string, snapshotID int64) (*CurrentSnapshotChange, error) {
+	return updateCurrentSnapshot(ctx, tableLocation, func(md table.Metadata) (*table.Snapshot, error) {
+		if snap := md.SnapshotByID(snapshotID); snap != nil {
+			return snap, nil
+		}
+
+		return nil, fmt.Errorf("snapshot %d not found", snapshotID)
+	})
+}
+
+// updateCurrentSnapshot points the main branch to the snapshot selected by fn.
+func updateCurrentSnapshot(
+	ctx context.Context,
+	tableLocation string,
+	fn func(md table.Metadata) (*table.Snapshot, error),
+) (*CurrentSnapshotChange, error) {
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var md = t.Metadata()
+
+	snap, err := fn(md)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var change = CurrentSnapshotChange{SnapshotId: snap.SnapshotID}
+
+	if current := md.CurrentSnapshot(); current != nil {
+		change.PreviousSnapshotId = &current.SnapshotID
+
+		if current.SnapshotID == snap.SnapshotID {
+			return &change, nil
+		}
+	}
+
+	var (
+		requirements = []table.Requirement{
+			table.AssertTableUUID(md.TableUUID()),
+			table.AssertRefSnapshotID(table.MainBranch, change.PreviousSnapshotId),
+		}
+		updates = []table.Update{
+			setBranchHeadUpdate(md, table.MainBranch, snap.SnapshotID),
+		}
+	)
+
+	if _, _, err := cat.CommitTable(ctx, t, requirements, updates); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+func parentSnapshot(md table.Metadata, snap *table.Snapshot) *table.Snapshot {
+	if snap.ParentSnapshotID == nil {
+		return nil
+	}
+
+	return md.SnapshotByID(*snap.ParentSnapshotID)
+}
@@ -0,0 +1,72 @@
+package iceberg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go/table"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	var load = func() *table.Table {
+		cat, err := NewVersionHintCatalog("file://" + dir)
+		require.NoError(t, err)
+
+		tbl, err := cat.LoadTable(ctx, nil, nil)
+		require.NoError(t, err)
+
+		return tbl
+	}
+
+	var snapshots []int64
+
+	for _, name := range []string{"1.parquet", "2.parquet", "3.parquet"} {
+		writeTestParquetFile(t, dir, name, []int64{1}, []string{"a"})
+		require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{name}, nil, CreateOrAddFilesConfig{}))
+		snapshots = append(snapshots, load().CurrentSnapshot().SnapshotID)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	change, err := Rollback(ctx, "file://"+dir, RollbackConfig{SnapshotId: snapshots[0]})
+	require.NoError(t, err)
+	require.Equal(t, snapshots[2], *change.PreviousSnapshotId)
+	require.Equal(t, snapshots[0], change.SnapshotId)
+	require.Equal(t, snapshots[0], load().CurrentSnapshot().SnapshotID)
+	require.Len(t, load().Metadata().Snapshots(), 3)
+
+	// snapshots committed after the current one are not ancestors anymore
+	_, err = Rollback(ctx, "file://"+dir, RollbackConfig{SnapshotId: snapshots[2]})
+	require.ErrorContains(t, err, "is not an ancestor of the current snapshot")
+
+	_, err = Rollback(ctx, "file://"+dir, RollbackConfig{SnapshotId: 42})
+	require.ErrorContains(t, err, "snapshot 42 not found")
+
+	change, err = SetCurrentSnapshot(ctx, "file://"+dir, snapshots[2])
+	require.NoError(t, err)
+	require.Equal(t, snapshots[0], *change.PreviousSnapshotId)
+	require.Equal(t, snapshots[2], load().CurrentSnapshot().SnapshotID)
+
+	var ts = time.UnixMilli(load().Metadata().SnapshotByID(snapshots[1]).TimestampMs)
+
+	change, err = Rollback(ctx, "file://"+dir, RollbackConfig{Timestamp: ts.Add(time.Millisecond)})
+	require.NoError(t, err)
+	require.Equal(t, snapshots[1], change.SnapshotId)
+	require.Equal(t, snapshots[1], load().CurrentSnapshot().SnapshotID)
+
+	_, err = Rollback(ctx, "file://"+dir, RollbackConfig{Timestamp: ts.Add(-time.Hour)})
+	require.ErrorContains(t, err, "no ancestor of the current snapshot was committed before")
+
+	// new commits are added on top of the current snapshot
+	writeTestParquetFile(t, dir, "4.parquet", []int64{1}, []string{"a"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"4.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.Equal(t, snapshots[1], *load().CurrentSnapshot().ParentSnapshotID)
+	require.Len(t, currentTestDataFiles(t, ctx, dir), 3)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strconv"
 
 	"github.com/agnosticeng/icepq/internal/io"
 	"github.com/agnosticeng/objstr"
@@ -25,71 +26,256 @@ func (schs Schemas) Equals(target *iceberg.Schema) bool {
 	})
 }
 
+// FileSchema is the schema of a data file. EmbeddedIDs reports whether its field IDs were
+// read from the file (PARQUET:field_id) rather than assigned in column order.
+type FileSchema struct {
+	*iceberg.Schema
+	EmbeddedIDs bool
+}
+
 func SchemaFromParquetDataFiles(ctx context.Context, location *url.URL, files []string) (*iceberg.Schema, error) {
-	schemas, err := iter.MapErr(files, func(path *string) (*iceberg.Schema, error) {
-		var u = location.JoinPath("data", *path)
-		return SchemaFromParquetFile(ctx, u)
-	})
+	schemas, err := SchemasFromParquetDataFiles(ctx, location, files)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if !Schemas(schemas).Equals(schemas[0]) {
+	if !Schemas(fileSchemas(schemas)).Equals(schemas[0].Schema) {
 		return nil, fmt.Errorf("not all provided Parquet files have the same schema")
 	}
 
-	return schemas[0], nil
+	return schemas[0].Schema, nil
 }
 
-func SchemaFromParquetFile(ctx context.Context, u *url.URL) (*iceberg.Schema, error) {
-	var os = objstr.FromContextOrDefault(ctx)
+func fileSchemas(schemas []FileSchema) []*iceberg.Schema {
+	return lo.Map(schemas, func(sch FileSchema, _ int) *iceberg.Schema { return sch.Schema })
+}
 
-	md, err := os.ReadMetadata(ctx, u)
+// FileSchemaDiff lists how the schema of a data file differs from the inferred table schema.
+type FileSchemaDiff struct {
+	File        string   `json:"file"`
+	Differences []string `json:"differences"`
+}
+
+// UnionSchemaFromParquetDataFiles infers a schema able to hold the rows of all the given
+// files (see UnionSchema), and reports the differences of each file with this schema.
+func UnionSchemaFromParquetDataFiles(ctx context.Context, location *url.URL, files []string) (*iceberg.Schema, []FileSchemaDiff, error) {
+	schemas, err := SchemasFromParquetDataFiles(ctx, location, files)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	r, err := os.ReaderAt(ctx, u)
+	sch, err := UnionSchema(schemas)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	defer r.Close()
+	var diffs []FileSchemaDiff
 
-	pqr, err := file.NewParquetReader(io.NewReadSeekerAdapter(r, int64(md.Size)))
+	for i, fileSch := range schemas {
+		if differences := schemaDifferences(fileSch.Fields(), sch.Fields(), ""); len(differences) > 0 {
+			diffs = append(diffs, FileSchemaDiff{File: files[i], Differences: differences})
+		}
+	}
 
-	if err != nil {
-		return nil, err
+	return sch, diffs, nil
+}
+
+// UnionSchema returns the least common supertype of the given schemas: columns missing
+// from some schemas or optional in some of them are optional, and numeric columns are
+// widened following the Iceberg type promotion rules. Field IDs embedded in the files are
+// kept and must be the same in every file; other columns are given fresh IDs.
+func UnionSchema(schemas []FileSchema) (*iceberg.Schema, error) {
+	if len(schemas) == 0 {
+		return nil, fmt.Errorf("no schema to merge")
 	}
 
-	defer pqr.Close()
+	var (
+		m       = newSchemaMerger(0, make(map[int]bool), true)
+		ordered = embeddedIDsFirst(schemas)
+		fields  []iceberg.NestedField
+	)
+
+	if ordered[0].EmbeddedIDs {
+		fields = ordered[0].Fields()
 
-	fr, err := pqarrow.NewFileReader(pqr, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+		for _, f := range fields {
+			if err := m.useIDs(f, ""); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		first, err := iceberg.AssignFreshSchemaIDs(ordered[0].Schema, m.next)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields = first.Fields()
+	}
+
+	for _, sch := range ordered[1:] {
+		var err error
+
+		m.embeddedIDs = sch.EmbeddedIDs
+
+		if fields, _, err = m.mergeFields(fields, sch.Fields(), ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return iceberg.NewSchema(0, fields...), nil
+}
+
+func schemaDifferences(fileFields []iceberg.NestedField, fields []iceberg.NestedField, prefix string) []string {
+	var res []string
+
+	for _, f := range fields {
+		var (
+			path   = prefix + f.Name
+			ff, ok = lo.Find(fileFields, func(ff iceberg.NestedField) bool { return ff.Name == f.Name })
+		)
+
+		if !ok {
+			res = append(res, fmt.Sprintf("column %s is missing", path))
+			continue
+		}
+
+		if ff.Required && !f.Required {
+			res = append(res, fmt.Sprintf("column %s is required", path))
+		}
+
+		res = append(res, typeDifferences(ff.Type, f.Type, path)...)
+	}
+
+	return res
+}
+
+func typeDifferences(fileType iceberg.Type, typ iceberg.Type, path string) []string {
+	switch t := typ.(type) {
+	case *iceberg.StructType:
+		if ft, ok := fileType.(*iceberg.StructType); ok {
+			return schemaDifferences(ft.FieldList, t.FieldList, path+".")
+		}
+	case *iceberg.ListType:
+		if ft, ok := fileType.(*iceberg.ListType); ok {
+			var res []string
+
+			if ft.ElementRequired && !t.ElementRequired {
+				res = append(res, fmt.Sprintf("column %s.element is required", path))
+			}
+
+			return append(res, typeDifferences(ft.Element, t.Element, path+".element")...)
+		}
+	case *iceberg.MapType:
+		if ft, ok := fileType.(*iceberg.MapType); ok {
+			var res []string
+
+			if ft.ValueRequired && !t.ValueRequired {
+				res = append(res, fmt.Sprintf("column %s.value is required", path))
+			}
+
+			return append(res, typeDifferences(ft.ValueType, t.ValueType, path+".value")...)
+		}
+	default:
+		if fileType.Equals(typ) {
+			return nil
+		}
+	}
+
+	return []string{fmt.Sprintf("column %s has type %s instead of %s", path, fileType, typ)}
+}
+
+func SchemasFromParquetDataFiles(ctx context.Context, location *url.URL, files []string) ([]FileSchema, error) {
+	return iter.MapErr(files, func(path *string) (FileSchema, error) {
+		var u = location.JoinPath("data", *path)
+		return fileSchemaFromParquetFile(ctx, u)
+	})
+}
+
+func SchemaFromParquetFile(ctx context.Context, u *url.URL) (*iceberg.Schema, error) {
+	sch, err := fileSchemaFromParquetFile(ctx, u)
 
 	if err != nil {
 		return nil, err
 	}
 
-	arrowSch, err := fr.Schema()
+	return sch.Schema, nil
+}
+
+func fileSchemaFromParquetFile(ctx context.Context, u *url.URL) (FileSchema, error) {
+	var (
+		arrowSch *arrow.Schema
+		embedded bool
+	)
+
+	err := withParquetFile(ctx, u, func(pqr *file.Reader, _ int64) error {
+		fr, err := pqarrow.NewFileReader(pqr, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+
+		if err != nil {
+			return err
+		}
+
+		embedded = hasFieldIDs(pqr.MetaData().Schema)
+		arrowSch, err = fr.Schema()
+		return err
+	})
 
 	if err != nil {
-		return nil, err
+		return FileSchema{}, err
 	}
 
-	var v = nameMappingArrowSchemaVisitor{}
+	var v = nameMappingArrowSchemaVisitor{latestFieldId: maxArrowFieldID(arrowSch.Fields())}
 
 	mapping, err := table.VisitArrowSchema(arrowSch, &v)
 
 	if err != nil {
-		return nil, err
+		return FileSchema{}, err
+	}
+
+	sch, err := table.ArrowSchemaToIceberg(arrowSch, true, mapping.Fields)
+
+	if err != nil {
+		return FileSchema{}, err
+	}
+
+	return FileSchema{Schema: sch, EmbeddedIDs: embedded}, nil
+}
+
+// withParquetFile opens the Parquet file at the given location for the duration of fn.
+func withParquetFile(ctx context.Context, u *url.URL, fn func(pqr *file.Reader, size int64) error) error {
+	var os = objstr.FromContextOrDefault(ctx)
+
+	md, err := os.ReadMetadata(ctx, u)
+
+	if err != nil {
+		return err
+	}
+
+	r, err := os.ReaderAt(ctx, u)
+
+	if err != nil {
+		return err
 	}
 
-	return table.ArrowSchemaToIceberg(arrowSch, true, mapping.Fields)
+	defer r.Close()
+
+	pqr, err := file.NewParquetReader(io.NewReadSeekerAdapter(r, int64(md.Size)))
+
+	if err != nil {
+		return err
+	}
+
+	defer pqr.Close()
+
+	return fn(pqr, int64(md.Size))
 }
 
+// nameMappingArrowSchemaVisitor builds the name mapping of an Arrow schema. Field IDs
+// embedded in the Parquet file are reused, other fields are numbered after the highest
+// embedded ID.
 type nameMappingArrowSchemaVisitor struct {
 	latestFieldId int
 }
@@ -99,6 +285,51 @@ func (v *nameMappingArrowSchemaVisitor) nextFieldId() int {
 	return v.latestFieldId
 }
 
+func (v *nameMappingArrowSchemaVisitor) fieldId(f arrow.Field) int {
+	if id, found := arrowFieldID(f); found {
+		return id
+	}
+
+	return v.nextFieldId()
+}
+
+func arrowFieldID(f arrow.Field) (int, bool) {
+	s, found := f.Metadata.GetValue(table.ArrowParquetFieldIDKey)
+
+	if !found {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(s)
+
+	if err != nil || id < 0 {
+		return 0, false
+	}
+
+	return id, true
+}
+
+func maxArrowFieldID(fields []arrow.Field) int {
+	var res int
+
+	for _, f := range fields {
+		if id, found := arrowFieldID(f); found {
+			res = max(res, id)
+		}
+
+		switch t := f.Type.(type) {
+		case *arrow.StructType:
+			res = max(res, maxArrowFieldID(t.Fields()))
+		case *arrow.MapType:
+			res = max(res, maxArrowFieldID([]arrow.Field{t.KeyField(), t.ItemField()}))
+		case arrow.ListLikeType:
+			res = max(res, maxArrowFieldID([]arrow.Field{t.ElemField()}))
+		}
+	}
+
+	return res
+}
+
 func (v *nameMappingArrowSchemaVisitor) unwrap(t arrow.Type, mf iceberg.MappedField) []iceberg.MappedField {
 	switch t {
 	case arrow.STRUCT:
@@ -123,7 +354,7 @@ func (v *nameMappingArrowSchemaVisitor) Struct(st *arrow.StructType, mfs []icebe
 }
 
 func (v *nameMappingArrowSchemaVisitor) Field(f arrow.Field, mf iceberg.MappedField) iceberg.MappedField {
-	var id = v.nextFieldId()
+	var id = v.fieldId(f)
 
 	return iceberg.MappedField{
 		FieldID: &id,
@@ -133,7 +364,7 @@ func (v *nameMappingArrowSchemaVisitor) Field(f arrow.Field, mf iceberg.MappedFi
 }
 
 func (v *nameMappingArrowSchemaVisitor) List(lt arrow.ListLikeType, mf iceberg.MappedField) iceberg.MappedField {
-	var elemId = v.nextFieldId()
+	var elemId = v.fieldId(lt.ElemField())
 
 	return iceberg.MappedField{
 		Fields: []iceberg.MappedField{
@@ -148,8 +379,8 @@ func (v *nameMappingArrowSchemaVisitor) List(lt arrow.ListLikeType, mf iceberg.M
 
 func (v *nameMappingArrowSchemaVisitor) Map(mt *arrow.MapType, keyResult iceberg.MappedField, valueResult iceberg.MappedField) iceberg.MappedField {
 	var (
-		keyId   = v.nextFieldId()
-		valueId = v.nextFieldId()
+		keyId   = v.fieldId(mt.KeyField())
+		valueId = v.fieldId(mt.ItemField())
 	)
 
 	return iceberg.MappedField{
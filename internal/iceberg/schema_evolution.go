@@ -0,0 +1,343 @@
+package iceberg
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+)
+
+// EvolveSchema merges the schemas of incoming data files into the current table schema.
+// Unknown columns are added as optional columns, with the field IDs embedded in the files
+// or fresh ones, and columns are widened following the Iceberg type promotion rules (int
+// to long, float to double, decimal precision increase). The bounds of the data files
+// written before a promotion keep their encoding and are decoded by boundLiteralFromBytes.
+// It returns nil when the table schema does not change.
+func EvolveSchema(md table.Metadata, incoming []FileSchema) (*iceberg.Schema, error) {
+	var (
+		current = md.CurrentSchema()
+		used    = make(map[int]bool)
+		fields  = current.Fields()
+		changed bool
+	)
+
+	// the IDs of dropped columns are not reused, as files may still hold their values
+	for id := range md.LastColumnID() + 1 {
+		used[id] = true
+	}
+
+	var m = newSchemaMerger(md.LastColumnID(), used, false)
+
+	for _, sch := range embeddedIDsFirst(incoming) {
+		var (
+			ch  bool
+			err error
+		)
+
+		m.embeddedIDs = sch.EmbeddedIDs
+		fields, ch, err = m.mergeFields(fields, sch.Fields(), "")
+
+		if err != nil {
+			return nil, err
+		}
+
+		changed = changed || ch
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	var maxID = lo.Max(lo.Map(md.Schemas(), func(sch *iceberg.Schema, _ int) int { return sch.ID }))
+
+	return iceberg.NewSchemaWithIdentifiers(maxID+1, current.IdentifierFieldIDs, fields...), nil
+}
+
+// schemaMerger merges the fields of a file schema into the fields of a target schema.
+// In union mode, nullability is relaxed as well: a field is required only if it is
+// required by both schemas.
+//
+// When the file schema holds the field IDs embedded in the file, they must match the IDs
+// of the target schema, and new columns keep them; otherwise new columns are given fresh
+// IDs.
+type schemaMerger struct {
+	next        func() int
+	union       bool
+	used        map[int]bool
+	embeddedIDs bool
+}
+
+// newSchemaMerger returns a merger numbering new columns after lastID, skipping the used IDs.
+func newSchemaMerger(lastID int, used map[int]bool, union bool) schemaMerger {
+	return schemaMerger{
+		next: func() int {
+			lastID++
+
+			for used[lastID] {
+				lastID++
+			}
+
+			used[lastID] = true
+			return lastID
+		},
+		union: union,
+		used:  used,
+	}
+}
+
+// embeddedIDsFirst orders the schemas holding embedded field IDs first, so that the IDs
+// they carry are kept when columns are merged.
+func embeddedIDsFirst(schemas []FileSchema) []FileSchema {
+	var res = slices.Clone(schemas)
+
+	slices.SortStableFunc(res, func(a, b FileSchema) int {
+		switch {
+		case a.EmbeddedIDs == b.EmbeddedIDs:
+			return 0
+		case a.EmbeddedIDs:
+			return -1
+		default:
+			return 1
+		}
+	})
+
+	return res
+}
+
+// useIDs reserves the field IDs of a column and of its nested fields.
+func (m schemaMerger) useIDs(field iceberg.NestedField, prefix string) error {
+	names, err := iceberg.IndexNameByID(iceberg.NewSchema(0, field))
+
+	if err != nil {
+		return err
+	}
+
+	var ids = lo.Keys(names)
+
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		if m.used[id] {
+			return fmt.Errorf("field ID %d of column %s is already used", id, prefix+names[id])
+		}
+
+		m.used[id] = true
+	}
+
+	return nil
+}
+
+func (m schemaMerger) checkID(path string, tableID int, fileID int) error {
+	if m.embeddedIDs && tableID != fileID {
+		return fmt.Errorf("column %s has field ID %d instead of %d", path, fileID, tableID)
+	}
+
+	return nil
+}
+
+func (m schemaMerger) mergeFields(tableFields []iceberg.NestedField, fileFields []iceberg.NestedField, prefix string) ([]iceberg.NestedField, bool, error) {
+	var (
+		res     = slices.Clone(tableFields)
+		changed bool
+	)
+
+	if m.union {
+		for i, f := range res {
+			if f.Required && !slices.ContainsFunc(fileFields, func(ff iceberg.NestedField) bool { return ff.Name == f.Name }) {
+				res[i].Required = false
+				changed = true
+			}
+		}
+	}
+
+	for _, ff := range fileFields {
+		var i = slices.IndexFunc(res, func(f iceberg.NestedField) bool { return f.Name == ff.Name })
+
+		if i < 0 {
+			var field = ff
+
+			if m.embeddedIDs {
+				if err := m.useIDs(ff, prefix); err != nil {
+					return nil, false, err
+				}
+			} else {
+				sch, err := iceberg.AssignFreshSchemaIDs(iceberg.NewSchema(0, ff), m.next)
+
+				if err != nil {
+					return nil, false, err
+				}
+
+				field = sch.Field(0)
+			}
+
+			field.Required = false
+			res = append(res, field)
+			changed = true
+			continue
+		}
+
+		if err := m.checkID(prefix+ff.Name, res[i].ID, ff.ID); err != nil {
+			return nil, false, err
+		}
+
+		typ, ch, err := m.mergeType(res[i].Type, ff.Type, prefix+ff.Name)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		res[i].Type = typ
+		changed = changed || ch
+
+		if m.union && res[i].Required && !ff.Required {
+			res[i].Required = false
+			changed = true
+		}
+	}
+
+	return res, changed, nil
+}
+
+func (m schemaMerger) mergeType(tableType iceberg.Type, fileType iceberg.Type, path string) (iceberg.Type, bool, error) {
+	switch tt := tableType.(type) {
+	case *iceberg.StructType:
+		ft, ok := fileType.(*iceberg.StructType)
+
+		if !ok {
+			break
+		}
+
+		fields, changed, err := m.mergeFields(tt.FieldList, ft.FieldList, path+".")
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		return &iceberg.StructType{FieldList: fields}, changed, nil
+	case *iceberg.ListType:
+		ft, ok := fileType.(*iceberg.ListType)
+
+		if !ok {
+			break
+		}
+
+		if err := m.checkID(path+".element", tt.ElementID, ft.ElementID); err != nil {
+			return nil, false, err
+		}
+
+		elem, changed, err := m.mergeType(tt.Element, ft.Element, path+".element")
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		var required = tt.ElementRequired
+
+		if m.union && required && !ft.ElementRequired {
+			required, changed = false, true
+		}
+
+		return &iceberg.ListType{ElementID: tt.ElementID, Element: elem, ElementRequired: required}, changed, nil
+	case *iceberg.MapType:
+		ft, ok := fileType.(*iceberg.MapType)
+
+		if !ok || !tt.KeyType.Equals(ft.KeyType) {
+			break
+		}
+
+		if err := m.checkID(path+".key", tt.KeyID, ft.KeyID); err != nil {
+			return nil, false, err
+		}
+
+		if err := m.checkID(path+".value", tt.ValueID, ft.ValueID); err != nil {
+			return nil, false, err
+		}
+
+		value, changed, err := m.mergeType(tt.ValueType, ft.ValueType, path+".value")
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		var required = tt.ValueRequired
+
+		if m.union && required && !ft.ValueRequired {
+			required, changed = false, true
+		}
+
+		return &iceberg.MapType{
+			KeyID:         tt.KeyID,
+			KeyType:       tt.KeyType,
+			ValueID:       tt.ValueID,
+			ValueType:     value,
+			ValueRequired: required,
+		}, changed, nil
+	default:
+		if typ, changed, ok := promoteColumnType(tableType, fileType); ok {
+			return typ, changed, nil
+		}
+	}
+
+	return nil, false, fmt.Errorf("cannot evolve column %s from %s to %s", path, tableType, fileType)
+}
+
+// promoteColumnType returns the type of a column able to hold both the table and the
+// file values, and whether it differs from the table type.
+func promoteColumnType(tableType iceberg.Type, fileType iceberg.Type) (iceberg.Type, bool, bool) {
+	if tableType.Equals(fileType) {
+		return tableType, false, true
+	}
+
+	switch tt := tableType.(type) {
+	case iceberg.Int32Type:
+		if _, ok := fileType.(iceberg.Int64Type); ok {
+			return fileType, true, true
+		}
+	case iceberg.Float32Type:
+		if _, ok := fileType.(iceberg.Float64Type); ok {
+			return fileType, true, true
+		}
+	case iceberg.DecimalType:
+		if ft, ok := fileType.(iceberg.DecimalType); ok && ft.Scale() == tt.Scale() && ft.Precision() > tt.Precision() {
+			return fileType, true, true
+		}
+	}
+
+	// narrower file values are promoted when read
+	if _, err := iceberg.PromoteType(fileType, tableType); err == nil {
+		return tableType, false, true
+	}
+
+	return nil, false, false
+}
+
+// stageSchema returns a copy of the table using the given schema as current schema.
+func stageSchema(t *table.Table, sch *iceberg.Schema) (*table.Table, error) {
+	b, err := table.MetadataBuilderFromBase(t.Metadata())
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = b.AddSchema(sch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = b.SetCurrentSchemaID(sch.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := b.Build()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return table.New(t.Identifier(), md, t.MetadataLocation(), t.FS, nil), nil
+}
@@ -0,0 +1,217 @@
+package iceberg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/apache/iceberg-go"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestParquetJSON writes the JSON rows under the data directory of the table.
+func writeTestParquetJSON(t *testing.T, dir string, path string, sch *arrow.Schema, rows string) {
+	rec, _, err := array.RecordFromJSON(memory.DefaultAllocator, sch, strings.NewReader(rows))
+	require.NoError(t, err)
+	defer rec.Release()
+
+	var fullPath = filepath.Join(dir, "data", path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+
+	f, err := os.Create(fullPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var tbl = array.NewTableFromRecords(sch, []arrow.Record{rec})
+	defer tbl.Release()
+
+	require.NoError(t, pqarrow.WriteTable(tbl, f, 1024, nil, pqarrow.DefaultWriterProps()))
+}
+
+func TestCreateOrAddFilesEvolveSchema(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+		v1  = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+		}, nil)
+		v2 = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+			{Name: "extra", Type: arrow.BinaryTypes.String, Nullable: true},
+		}, nil)
+		narrow = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int32},
+			{Name: "value", Type: arrow.PrimitiveTypes.Float32},
+		}, nil)
+	)
+
+	writeTestParquetJSON(t, dir, "1.parquet", v1, `[{"id": 1, "value": 1.5}]`)
+	writeTestParquetJSON(t, dir, "2.parquet", v2, `[{"id": 2, "value": 2.5, "extra": "x"}]`)
+	writeTestParquetJSON(t, dir, "3.parquet", narrow, `[{"id": 3, "value": 3.5}]`)
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.Error(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{EvolveSchema: true}))
+
+	// files written with narrower types are promoted when read
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"3.parquet"}, nil, CreateOrAddFilesConfig{EvolveSchema: true}))
+
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+
+	var sch = tbl.Schema()
+	require.Equal(t, 1, sch.ID)
+	require.Len(t, tbl.Metadata().Schemas(), 2)
+	require.Equal(t, 3, tbl.Metadata().LastColumnID())
+	require.Equal(t, 1, *tbl.CurrentSnapshot().SchemaID)
+
+	var fieldTypes = make(map[string]iceberg.Type)
+
+	for _, f := range sch.Fields() {
+		fieldTypes[f.Name] = f.Type
+	}
+
+	require.Equal(t, map[string]iceberg.Type{
+		"id":    iceberg.PrimitiveTypes.Int64,
+		"value": iceberg.PrimitiveTypes.Float64,
+		"extra": iceberg.PrimitiveTypes.String,
+	}, fieldTypes)
+
+	extra, found := sch.FindFieldByName("extra")
+	require.True(t, found)
+	require.Equal(t, 3, extra.ID)
+	require.False(t, extra.Required)
+
+	mapped, found := lo.Find(tbl.NameMapping(), func(f iceberg.MappedField) bool { return slices.Contains(f.Names, "extra") })
+	require.True(t, found)
+	require.Equal(t, 3, mapped.ID())
+
+	require.Equal(t, "3", tbl.CurrentSnapshot().Summary.Properties["total-records"])
+}
+
+func TestEvolveSchemaIncompatible(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1}, []string{"a"})
+	writeTestParquetJSON(t, dir, "2.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.BinaryTypes.String},
+	}, nil), `[{"id": "x"}]`)
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.ErrorContains(
+		t,
+		CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{EvolveSchema: true}),
+		"cannot evolve column id",
+	)
+}
+
+func TestPromotedColumnBounds(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+		v1  = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int32},
+			{Name: "value", Type: arrow.PrimitiveTypes.Float32},
+		}, nil)
+		v2 = arrow.NewSchema([]arrow.Field{
+			{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+			{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+		}, nil)
+	)
+
+	writeTestParquetJSON(t, dir, "1.parquet", v1, `[{"id": -3, "value": 1.5}, {"id": 5, "value": 2.5}]`)
+	writeTestParquetJSON(t, dir, "2.parquet", v2, `[{"id": 10, "value": 3.5}, {"id": 20, "value": 4.5}]`)
+	writeTestParquetJSON(t, dir, "3.parquet", v1, `[{"id": 30, "value": 5.5}]`)
+
+	// the bounds of 1.parquet keep their 4-byte encoding once the columns are widened
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{EvolveSchema: true}))
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"3.parquet"}, nil, CreateOrAddFilesConfig{EvolveSchema: true}))
+
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []iceberg.Type{iceberg.PrimitiveTypes.Int64, iceberg.PrimitiveTypes.Float64}, lo.Map(tbl.Schema().Fields(), func(f iceberg.NestedField, _ int) iceberg.Type { return f.Type }))
+
+	bounds, err := FieldBoundValues(ctx, "file://"+dir, "id", FieldBoundValuesConfig{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, [][2]any{{int64(-3), int64(5)}, {int64(10), int64(20)}, {int64(30), int64(30)}}, lo.Map(bounds, func(item FieldBoundValuesItem, _ int) [2]any { return [2]any{item.Lower, item.Upper} }))
+
+	summary, err := FieldRangeSummary(ctx, "file://"+dir, "value", FieldBoundValuesConfig{})
+	require.NoError(t, err)
+	require.Equal(t, 1.5, summary.Lower)
+	require.Equal(t, 5.5, summary.Upper)
+
+	stats, err := ColumnStats(ctx, "file://"+dir, ColumnStatsConfig{})
+	require.NoError(t, err)
+	require.Len(t, stats.Files, 3)
+
+	files, err := PlanFiles(ctx, "file://"+dir, PlanFilesConfig{Filter: "id < 0"})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.True(t, strings.HasSuffix(files[0].FilePath, "1.parquet"))
+
+	files, err = PlanFiles(ctx, "file://"+dir, PlanFilesConfig{Filter: "value > 5.0"})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.True(t, strings.HasSuffix(files[0].FilePath, "3.parquet"))
+}
+
+func TestEvolveSchemaWithFieldIDs(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetJSON(t, dir, "1.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64, Metadata: fieldIDMetadata("10")},
+	}, nil), `[{"id": 1}]`)
+	writeTestParquetJSON(t, dir, "2.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64, Metadata: fieldIDMetadata("10")},
+		{Name: "other", Type: arrow.BinaryTypes.String, Nullable: true, Metadata: fieldIDMetadata("5")},
+	}, nil), `[{"id": 2, "other": "x"}]`)
+	writeTestParquetJSON(t, dir, "3.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "plain", Type: arrow.BinaryTypes.String, Nullable: true},
+	}, nil), `[{"id": 3, "plain": "y"}]`)
+	writeTestParquetJSON(t, dir, "4.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64, Metadata: fieldIDMetadata("10")},
+		{Name: "extra", Type: arrow.BinaryTypes.String, Nullable: true, Metadata: fieldIDMetadata("50")},
+	}, nil), `[{"id": 4, "extra": "z"}]`)
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	// IDs up to the last column ID may have belonged to dropped columns
+	require.ErrorContains(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{EvolveSchema: true}), "field ID 5 of column other is already used")
+
+	// the embedded IDs are kept, and columns of files without IDs get unused fresh IDs
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"3.parquet", "4.parquet"}, nil, CreateOrAddFilesConfig{EvolveSchema: true}))
+
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"id": 10, "extra": 50, "plain": 11}, lo.SliceToMap(tbl.Schema().Fields(), func(f iceberg.NestedField) (string, int) { return f.Name, f.ID }))
+	require.Equal(t, 50, tbl.Metadata().LastColumnID())
+	require.Equal(t, "3", tbl.CurrentSnapshot().Summary.Properties["total-records"])
+}
@@ -0,0 +1,110 @@
+package iceberg
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/iceberg-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnionSchemaFromParquetDataFiles(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetJSON(t, dir, "1.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int32},
+		{Name: "name", Type: arrow.BinaryTypes.String},
+	}, nil), `[{"id": 1, "name": "a"}]`)
+	writeTestParquetJSON(t, dir, "2.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "extra", Type: arrow.BinaryTypes.String, Nullable: true},
+	}, nil), `[{"id": 2, "extra": "x"}]`)
+
+	location, err := url.Parse("file://" + dir)
+	require.NoError(t, err)
+
+	_, err = SchemaFromParquetDataFiles(ctx, location, []string{"1.parquet", "2.parquet"})
+	require.ErrorContains(t, err, "same schema")
+
+	sch, diffs, err := UnionSchemaFromParquetDataFiles(ctx, location, []string{"1.parquet", "2.parquet"})
+	require.NoError(t, err)
+
+	require.True(t, sch.Equals(iceberg.NewSchema(0,
+		iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64},
+		iceberg.NestedField{ID: 2, Name: "name", Type: iceberg.PrimitiveTypes.String},
+		iceberg.NestedField{ID: 3, Name: "extra", Type: iceberg.PrimitiveTypes.String},
+	)), sch.String())
+
+	require.Equal(t, []FileSchemaDiff{
+		{File: "1.parquet", Differences: []string{
+			"column id is required",
+			"column id has type int instead of long",
+			"column name is required",
+			"column extra is missing",
+		}},
+		{File: "2.parquet", Differences: []string{
+			"column name is missing",
+		}},
+	}, diffs)
+
+	require.Error(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet"}, nil, CreateOrAddFilesConfig{}))
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet"}, nil, CreateOrAddFilesConfig{UnionSchema: true}))
+
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	require.True(t, tbl.Schema().Equals(sch))
+	require.Equal(t, "2", tbl.CurrentSnapshot().Summary.Properties["total-records"])
+}
+
+func TestUnionSchemaWithFieldIDs(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetJSON(t, dir, "1.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64, Metadata: fieldIDMetadata("10")},
+		{Name: "name", Type: arrow.BinaryTypes.String, Metadata: fieldIDMetadata("20")},
+	}, nil), `[{"id": 1, "name": "a"}]`)
+	writeTestParquetJSON(t, dir, "2.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64, Metadata: fieldIDMetadata("10")},
+		{Name: "extra", Type: arrow.BinaryTypes.String, Nullable: true, Metadata: fieldIDMetadata("40")},
+	}, nil), `[{"id": 2, "extra": "x"}]`)
+	writeTestParquetJSON(t, dir, "3.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64, Metadata: fieldIDMetadata("11")},
+	}, nil), `[{"id": 3}]`)
+	writeTestParquetJSON(t, dir, "4.parquet", arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "plain", Type: arrow.BinaryTypes.String, Nullable: true},
+	}, nil), `[{"id": 4, "plain": "y"}]`)
+
+	// conflicting field IDs are rejected before the table is created
+	require.ErrorContains(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "3.parquet"}, nil, CreateOrAddFilesConfig{UnionSchema: true}), "column id has field ID 11 instead of 10")
+	require.NoDirExists(t, filepath.Join(dir, "metadata"))
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"4.parquet", "1.parquet", "2.parquet"}, nil, CreateOrAddFilesConfig{UnionSchema: true}))
+
+	cat, err := NewVersionHintCatalog("file://" + dir)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	require.True(t, tbl.Schema().Equals(iceberg.NewSchema(0,
+		iceberg.NestedField{ID: 10, Name: "id", Type: iceberg.PrimitiveTypes.Int64, Required: true},
+		iceberg.NestedField{ID: 20, Name: "name", Type: iceberg.PrimitiveTypes.String},
+		iceberg.NestedField{ID: 40, Name: "extra", Type: iceberg.PrimitiveTypes.String},
+		iceberg.NestedField{ID: 1, Name: "plain", Type: iceberg.PrimitiveTypes.String},
+	)), tbl.Schema().String())
+	require.Equal(t, 40, tbl.Metadata().LastColumnID())
+	require.Equal(t, "3", tbl.CurrentSnapshot().Summary.Properties["total-records"])
+}
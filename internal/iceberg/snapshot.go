@@ -0,0 +1,40 @@
+package iceberg
+
+import (
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/io"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/iter"
+)
+
+// SnapshotDataFiles returns the live data files referenced by a snapshot.
+func SnapshotDataFiles(io io.IO, snap *table.Snapshot) ([]iceberg.DataFile, error) {
+	if snap == nil {
+		return nil, nil
+	}
+
+	mans, err := snap.Manifests(io)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := iter.MapErr(mans, func(man *iceberg.ManifestFile) ([]iceberg.DataFile, error) {
+		if (*man).ManifestContent() != iceberg.ManifestContentData {
+			return nil, nil
+		}
+
+		entries, err := (*man).FetchEntries(io, true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return lo.Map(entries, func(entry iceberg.ManifestEntry, _ int) iceberg.DataFile {
+			return entry.DataFile()
+		}), nil
+	})
+
+	return lo.Flatten(res), err
+}
@@ -0,0 +1,440 @@
+package iceberg
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+)
+
+// SnapshotUpdate describes the data files changes committed as a new snapshot.
+type SnapshotUpdate struct {
+	// Operation defaults to append, overwrite or delete depending on the changes.
+	Operation table.Operation
+	// Branch defaults to main. A branch that does not exist yet is created from the
+	// current snapshot.
+	Branch string
+	// Added files must be built against the current schema and default partition spec.
+	Added []iceberg.DataFile
+	// Deleted are the locations of data files of the branch head to remove.
+	Deleted []string
+	// Properties are added to the snapshot summary.
+	Properties iceberg.Properties
+	// Schema, when set, is added to the table and made current by the same commit.
+	// It must have been produced by EvolveSchema.
+	Schema *iceberg.Schema
+	// Stage adds the snapshot on top of the branch head without moving the branch, so
+	// that it can be audited before being published.
+	Stage bool
+}
+
+// CommitSnapshot writes the manifests of a new snapshot and commits it, along with the
+// given metadata updates, through the catalog. Unlike the iceberg-go snapshot producers,
+// it accepts pre-built data files so that partition values can be computed by the caller.
+func CommitSnapshot(
+	ctx context.Context,
+	cat table.CatalogIO,
+	t *table.Table,
+	su SnapshotUpdate,
+	updates ...table.Update,
+) (*table.Table, error) {
+	var (
+		os         = objstr.FromContextOrDefault(ctx)
+		osio       = iceio.NewObjectStoreIO(os)
+		md         = t.Metadata()
+		commitUUID = uuid.New()
+		snapshotID = newSnapshotID(md)
+		seq        = md.LastSequenceNumber() + 1
+		written    []string
+		committing bool
+	)
+
+	if len(su.Branch) == 0 {
+		su.Branch = table.MainBranch
+	}
+
+	var (
+		schema       = md.CurrentSchema()
+		requirements = []table.Requirement{table.AssertTableUUID(md.TableUUID())}
+	)
+
+	if su.Schema != nil {
+		schema = su.Schema
+		requirements = append(requirements, table.AssertCurrentSchemaID(md.CurrentSchema().ID), table.AssertLastAssignedFieldID(md.LastColumnID()))
+		updates = append([]table.Update{table.NewAddSchemaUpdate(schema), table.NewSetCurrentSchemaUpdate(-1)}, updates...)
+	}
+
+	var (
+		parent    = md.SnapshotByName(su.Branch)
+		refSnapID *int64
+	)
+
+	if parent != nil {
+		refSnapID = &parent.SnapshotID
+	} else if su.Branch != table.MainBranch {
+		parent = md.CurrentSnapshot()
+	}
+
+	if err := checkAddedFiles(osio, parent, su); err != nil {
+		return nil, err
+	}
+
+	locProvider, err := t.LocationProvider()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var newMetadataLocation = func(name string) string {
+		var loc = locProvider.NewMetadataLocation(name)
+		written = append(written, loc)
+		return loc
+	}
+
+	committed, err := func() (*table.Table, error) {
+		manifests, deleted, err := rewriteManifests(osio, md, parent, snapshotID, su.Deleted, func(i int) string {
+			return newMetadataLocation(fmt.Sprintf("%s-m%d.avro", commitUUID, i+1))
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(su.Added) > 0 {
+			var entries = lo.Map(su.Added, func(df iceberg.DataFile, _ int) iceberg.ManifestEntry {
+				return iceberg.NewManifestEntry(iceberg.EntryStatusADDED, &snapshotID, nil, nil, df)
+			})
+
+			man, err := writeManifest(osio, newMetadataLocation(fmt.Sprintf("%s-m0.avro", commitUUID)), md, schema, t.Spec(), snapshotID, entries)
+
+			if err != nil {
+				return nil, err
+			}
+
+			manifests = append([]iceberg.ManifestFile{man}, manifests...)
+		}
+
+		var parentID *int64
+
+		if parent != nil {
+			parentID = &parent.SnapshotID
+		}
+
+		var manifestListLoc = newMetadataLocation(fmt.Sprintf("snap-%d-0-%s.avro", snapshotID, commitUUID))
+
+		out, err := osio.Create(manifestListLoc)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := iceberg.WriteManifestList(md.Version(), out, snapshotID, parentID, &seq, manifests); err != nil {
+			out.Close()
+			return nil, err
+		}
+
+		if err := out.Close(); err != nil {
+			return nil, err
+		}
+
+		var (
+			summary = snapshotSummary(su, parent, deleted)
+			snap    = table.Snapshot{
+				SnapshotID:       snapshotID,
+				ParentSnapshotID: parentID,
+				SequenceNumber:   seq,
+				TimestampMs:      time.Now().UnixMilli(),
+				ManifestList:     manifestListLoc,
+				Summary:          &summary,
+				SchemaID:         &schema.ID,
+			}
+		)
+
+		updates = append(updates, table.NewAddSnapshotUpdate(&snap))
+
+		if !su.Stage {
+			requirements = append(requirements, table.AssertRefSnapshotID(su.Branch, refSnapID))
+			updates = append(updates, setBranchHeadUpdate(md, su.Branch, snapshotID))
+		}
+
+		committing = true
+
+		newMd, newMdLoc, err := cat.CommitTable(ctx, t, requirements, updates)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return table.New(t.Identifier(), newMd, newMdLoc, t.FS, cat), nil
+	}()
+
+	if err != nil {
+		// the files written for this attempt are only known to be unreferenced when the
+		// commit was not attempted or was rejected; other commit failures (e.g. a timeout
+		// after the version hint was swapped) may have published the snapshot, so its
+		// files are left to remove-orphan-files
+		if committing && !errors.Is(err, ErrConsistencyViolation) && !errors.Is(err, ErrCommitConflict) {
+			return nil, err
+		}
+
+		for _, loc := range written {
+			_ = osio.Remove(loc)
+		}
+
+		return nil, err
+	}
+
+	return committed, nil
+}
+
+// checkAddedFiles rejects added files listed twice, or already part of the branch head
+// unless the update deletes them.
+func checkAddedFiles(io *iceio.ObjectStoreIO, parent *table.Snapshot, su SnapshotUpdate) error {
+	if len(su.Added) == 0 {
+		return nil
+	}
+
+	var added = mapset.NewThreadUnsafeSet[string]()
+
+	for _, df := range su.Added {
+		if !added.Add(df.FilePath()) {
+			return &CommitConflictError{Operation: "append", Reason: fmt.Sprintf("file %s is added twice", df.FilePath())}
+		}
+	}
+
+	current, err := SnapshotDataFiles(io, parent)
+
+	if err != nil {
+		return err
+	}
+
+	var existing = mapset.NewThreadUnsafeSet(lo.Map(current, func(df iceberg.DataFile, _ int) string { return df.FilePath() })...)
+
+	if dup := added.Intersect(existing.Difference(mapset.NewThreadUnsafeSet(su.Deleted...))); dup.Cardinality() > 0 {
+		var files = dup.ToSlice()
+		slices.Sort(files)
+
+		return &CommitConflictError{
+			Operation: "append",
+			Reason:    fmt.Sprintf("files are already part of the branch head: %s", strings.Join(files, ", ")),
+		}
+	}
+
+	return nil
+}
+
+// rewriteManifests returns the manifests of the new snapshot inherited from its parent.
+// Manifests referencing deleted files are rewritten with these files marked as deleted.
+func rewriteManifests(
+	io *iceio.ObjectStoreIO,
+	md table.Metadata,
+	parent *table.Snapshot,
+	snapshotID int64,
+	deletedLocations []string,
+	newLocation func(int) string,
+) ([]iceberg.ManifestFile, []iceberg.DataFile, error) {
+	if parent == nil {
+		if len(deletedLocations) > 0 {
+			return nil, nil, &CommitConflictError{Operation: "delete", Reason: "branch has no snapshot"}
+		}
+
+		return nil, nil, nil
+	}
+
+	mans, err := parent.Manifests(io)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		toDelete = mapset.NewThreadUnsafeSet(deletedLocations...)
+		deleted  []iceberg.DataFile
+		res      []iceberg.ManifestFile
+	)
+
+	for _, man := range mans {
+		if toDelete.Cardinality() == 0 || man.ManifestContent() != iceberg.ManifestContentData {
+			res = append(res, man)
+			continue
+		}
+
+		entries, err := man.FetchEntries(io, true)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !lo.SomeBy(entries, func(entry iceberg.ManifestEntry) bool { return toDelete.Contains(entry.DataFile().FilePath()) }) {
+			res = append(res, man)
+			continue
+		}
+
+		var newEntries = make([]iceberg.ManifestEntry, 0, len(entries))
+
+		for _, entry := range entries {
+			var (
+				df       = entry.DataFile()
+				entrySeq = entry.SequenceNum()
+			)
+
+			if toDelete.Contains(df.FilePath()) {
+				toDelete.Remove(df.FilePath())
+				deleted = append(deleted, df)
+				newEntries = append(newEntries, iceberg.NewManifestEntry(iceberg.EntryStatusDELETED, &snapshotID, &entrySeq, entry.FileSequenceNum(), df))
+			} else {
+				var entrySnapshotID = entry.SnapshotID()
+				newEntries = append(newEntries, iceberg.NewManifestEntry(iceberg.EntryStatusEXISTING, &entrySnapshotID, &entrySeq, entry.FileSequenceNum(), df))
+			}
+		}
+
+		spec, found := lo.Find(md.PartitionSpecs(), func(spec iceberg.PartitionSpec) bool {
+			return spec.ID() == int(man.PartitionSpecID())
+		})
+
+		if !found {
+			return nil, nil, fmt.Errorf("partition spec %d not found", man.PartitionSpecID())
+		}
+
+		newMan, err := writeManifest(io, newLocation(len(res)), md, md.CurrentSchema(), spec, snapshotID, newEntries)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		res = append(res, newMan)
+	}
+
+	if toDelete.Cardinality() > 0 {
+		return nil, nil, &CommitConflictError{
+			Operation: "delete",
+			Reason:    fmt.Sprintf("files are not part of the branch head: %s", strings.Join(toDelete.ToSlice(), ", ")),
+		}
+	}
+
+	return res, deleted, nil
+}
+
+func writeManifest(
+	io *iceio.ObjectStoreIO,
+	location string,
+	md table.Metadata,
+	schema *iceberg.Schema,
+	spec iceberg.PartitionSpec,
+	snapshotID int64,
+	entries []iceberg.ManifestEntry,
+) (iceberg.ManifestFile, error) {
+	out, err := io.Create(location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	man, err := iceberg.WriteManifest(location, out, md.Version(), spec, schema, snapshotID, entries)
+
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+
+	return man, out.Close()
+}
+
+// snapshotSummary computes the summary of a snapshot from the changed files and the
+// totals of its parent.
+func snapshotSummary(su SnapshotUpdate, parent *table.Snapshot, deleted []iceberg.DataFile) table.Summary {
+	var (
+		props      = make(iceberg.Properties)
+		previous   iceberg.Properties
+		partitions = mapset.NewThreadUnsafeSet[string]()
+		op         = su.Operation
+	)
+
+	if parent != nil && parent.Summary != nil {
+		previous = parent.Summary.Properties
+	}
+
+	if len(op) == 0 {
+		switch {
+		case len(deleted) == 0:
+			op = table.OpAppend
+		case len(su.Added) == 0:
+			op = table.OpDelete
+		default:
+			op = table.OpOverwrite
+		}
+	}
+
+	var (
+		addedFiles   = int64(len(su.Added))
+		addedRecords = lo.SumBy(su.Added, iceberg.DataFile.Count)
+		addedSize    = lo.SumBy(su.Added, iceberg.DataFile.FileSizeBytes)
+		delFiles     = int64(len(deleted))
+		delRecords   = lo.SumBy(deleted, iceberg.DataFile.Count)
+		delSize      = lo.SumBy(deleted, iceberg.DataFile.FileSizeBytes)
+	)
+
+	for _, df := range append(append([]iceberg.DataFile{}, su.Added...), deleted...) {
+		if len(df.Partition()) > 0 {
+			partitions.Add(fmt.Sprint(df.SpecID(), df.Partition()))
+		}
+	}
+
+	setSummaryProp(props, "added-data-files", addedFiles)
+	setSummaryProp(props, "added-records", addedRecords)
+	setSummaryProp(props, "added-files-size", addedSize)
+	setSummaryProp(props, "deleted-data-files", delFiles)
+	setSummaryProp(props, "deleted-records", delRecords)
+	setSummaryProp(props, "removed-files-size", delSize)
+	setSummaryProp(props, "changed-partition-count", int64(partitions.Cardinality()))
+
+	var total = func(key string, delta int64) string {
+		var v, _ = strconv.ParseInt(previous.Get(key, "0"), 10, 64)
+		return strconv.FormatInt(v+delta, 10)
+	}
+
+	props["total-data-files"] = total("total-data-files", addedFiles-delFiles)
+	props["total-records"] = total("total-records", addedRecords-delRecords)
+	props["total-files-size"] = total("total-files-size", addedSize-delSize)
+	props["total-delete-files"] = total("total-delete-files", 0)
+	props["total-position-deletes"] = total("total-position-deletes", 0)
+	props["total-equality-deletes"] = total("total-equality-deletes", 0)
+
+	maps.Copy(props, su.Properties)
+
+	return table.Summary{Operation: op, Properties: props}
+}
+
+func setSummaryProp(props iceberg.Properties, key string, v int64) {
+	if v > 0 {
+		props[key] = strconv.FormatInt(v, 10)
+	}
+}
+
+// newSnapshotID returns a random positive snapshot ID not used by the table yet.
+func newSnapshotID(md table.Metadata) int64 {
+	for {
+		var (
+			u  = uuid.New()
+			id = int64((binary.BigEndian.Uint64(u[0:8]) ^ binary.BigEndian.Uint64(u[8:16])) & math.MaxInt64)
+		)
+
+		if id > 0 && md.SnapshotByID(id) == nil {
+			return id
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+)
+
+type SortFilesConfig struct {
+	// Files are the data files to sort, relative to the data directory of the table. All
+	// the data files of the current snapshot are sorted when empty.
+	Files []string
+	// TargetFileSizeBytes defaults to the write.target-file-size-bytes table property.
+	TargetFileSizeBytes int64
+	// Properties are added to the snapshot summary.
+	Properties iceberg.Properties
+}
+
+// SortFiles rewrites data files sorted by the table sort order. The rows of the files of
+// a partition are sorted together, then split into files of about the target file size,
+// so that the rewritten files of a partition do not overlap on the sort key. Partitions
+// larger than the target file size are sorted through temporary files (see CompactConfig).
+func SortFiles(ctx context.Context, tableLocation string, conf SortFilesConfig) ([]CompactResult, error) {
+	var files = conf.Files
+
+	if len(files) == 0 {
+		location, err := url.Parse(tableLocation)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cat, err := NewVersionHintCatalog(location.String())
+
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := cat.LoadTable(ctx, nil, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		dataFiles, err := SnapshotDataFiles(iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx)), t.CurrentSnapshot())
+
+		if err != nil {
+			return nil, err
+		}
+
+		var dataPrefix = location.JoinPath("data").String() + "/"
+
+		for _, df := range dataFiles {
+			relPath, found := strings.CutPrefix(df.FilePath(), dataPrefix)
+
+			if !found {
+				return nil, fmt.Errorf("data file %s is not stored under %s", df.FilePath(), dataPrefix)
+			}
+
+			files = append(files, relPath)
+		}
+
+		if len(files) == 0 {
+			return []CompactResult{}, nil
+		}
+	}
+
+	return Compact(ctx, tableLocation, CompactConfig{
+		Files:      files,
+		Plan:       PlanCompactionConfig{TargetFileSizeBytes: conf.TargetFileSizeBytes},
+		Sort:       true,
+		Properties: conf.Properties,
+	})
+}
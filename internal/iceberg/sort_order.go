@@ -0,0 +1,173 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+)
+
+var sortFieldRegexp = regexp.MustCompile(`^(.+?)(?:\s+(?i:(asc|desc)))?(?:\s+(?i:nulls)\s+(?i:(first|last)))?$`)
+
+// ParseSortOrder builds a sort order from a comma-separated list of sort fields, e.g.
+// `block_number ASC NULLS LAST, day(ts) DESC`. Sort fields are columns or transforms
+// (see ParsePartitionFieldExprs), ascending by default. Nulls come first in ascending
+// order and last in descending order unless specified. An empty string is the unsorted
+// order.
+func ParseSortOrder(sch *iceberg.Schema, s string) (table.SortOrder, error) {
+	var fields []table.SortField
+
+	for _, part := range splitTopLevel(s) {
+		if len(strings.TrimSpace(part)) == 0 {
+			continue
+		}
+
+		field, err := parseSortField(sch, strings.TrimSpace(part))
+
+		if err != nil {
+			return table.SortOrder{}, err
+		}
+
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return table.UnsortedSortOrder, nil
+	}
+
+	return table.SortOrder{OrderID: table.InitialSortOrderID, Fields: fields}, nil
+}
+
+func parseSortField(sch *iceberg.Schema, s string) (table.SortField, error) {
+	var m = sortFieldRegexp.FindStringSubmatch(s)
+
+	if m == nil {
+		return table.SortField{}, fmt.Errorf("invalid sort field: %s", s)
+	}
+
+	expr, err := parsePartitionFieldExpr(strings.TrimSpace(m[1]))
+
+	if err != nil {
+		return table.SortField{}, fmt.Errorf("invalid sort field: %s", s)
+	}
+
+	if len(expr.Name) > 0 {
+		return table.SortField{}, fmt.Errorf("sort fields cannot be named: %s", s)
+	}
+
+	field, found := sch.FindFieldByName(expr.SourceName)
+
+	if !found {
+		return table.SortField{}, fmt.Errorf("sort column %s not found", expr.SourceName)
+	}
+
+	if _, ok := field.Type.(iceberg.PrimitiveType); !ok {
+		return table.SortField{}, fmt.Errorf("sort column %s is not a primitive column", expr.SourceName)
+	}
+
+	if !expr.Transform.CanTransform(field.Type) {
+		return table.SortField{}, fmt.Errorf("cannot apply transform %s to column %s of type %s", expr.Transform, expr.SourceName, field.Type)
+	}
+
+	var res = table.SortField{
+		SourceID:  field.ID,
+		Transform: expr.Transform,
+		Direction: table.SortASC,
+		NullOrder: table.NullsFirst,
+	}
+
+	if strings.EqualFold(m[2], "desc") {
+		res.Direction = table.SortDESC
+		res.NullOrder = table.NullsLast
+	}
+
+	switch strings.ToLower(m[3]) {
+	case "first":
+		res.NullOrder = table.NullsFirst
+	case "last":
+		res.NullOrder = table.NullsLast
+	}
+
+	return res, nil
+}
+
+// SetSortOrder sets the default sort order of a table (see ParseSortOrder). A sort order
+// already defined in the table metadata is reused.
+func SetSortOrder(ctx context.Context, tableLocation string, s string) (*table.SortOrder, error) {
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		md      = t.Metadata()
+		current = md.SortOrder()
+	)
+
+	order, err := ParseSortOrder(t.Schema(), s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sameSortFields(order, current) {
+		return &current, nil
+	}
+
+	var (
+		updates []table.Update
+		maxID   int
+		found   bool
+	)
+
+	for _, o := range md.SortOrders() {
+		maxID = max(maxID, o.OrderID)
+
+		if sameSortFields(order, o) {
+			order, found = o, true
+		}
+	}
+
+	if found {
+		updates = append(updates, table.NewSetDefaultSortOrderUpdate(order.OrderID))
+	} else {
+		order.OrderID = maxID + 1
+
+		if len(order.Fields) == 0 {
+			order.OrderID = table.UnsortedSortOrderID
+		}
+
+		updates = append(updates, table.NewAddSortOrderUpdate(&order, false), table.NewSetDefaultSortOrderUpdate(order.OrderID))
+	}
+
+	var requirements = []table.Requirement{
+		table.AssertTableUUID(md.TableUUID()),
+		table.AssertDefaultSortOrderID(current.OrderID),
+	}
+
+	if _, _, err := cat.CommitTable(ctx, t, requirements, updates); err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func sameSortFields(a table.SortOrder, b table.SortOrder) bool {
+	return slices.EqualFunc(a.Fields, b.Fields, func(x, y table.SortField) bool {
+		return x.SourceID == y.SourceID &&
+			x.Transform.Equals(y.Transform) &&
+			x.Direction == y.Direction &&
+			x.NullOrder == y.NullOrder
+	})
+}
@@ -0,0 +1,120 @@
+package iceberg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSortOrder(t *testing.T) {
+	var sch = iceberg.NewSchema(
+		0,
+		iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64},
+		iceberg.NestedField{ID: 2, Name: "ts", Type: iceberg.PrimitiveTypes.Timestamp},
+		iceberg.NestedField{ID: 3, Name: "name", Type: iceberg.PrimitiveTypes.String},
+	)
+
+	order, err := ParseSortOrder(sch, "id ASC NULLS LAST, day(ts) desc, truncate(4, name) nulls first, name")
+	require.NoError(t, err)
+	require.Equal(t, table.SortOrder{OrderID: 1, Fields: []table.SortField{
+		{SourceID: 1, Transform: iceberg.IdentityTransform{}, Direction: table.SortASC, NullOrder: table.NullsLast},
+		{SourceID: 2, Transform: iceberg.DayTransform{}, Direction: table.SortDESC, NullOrder: table.NullsLast},
+		{SourceID: 3, Transform: iceberg.TruncateTransform{Width: 4}, Direction: table.SortASC, NullOrder: table.NullsFirst},
+		{SourceID: 3, Transform: iceberg.IdentityTransform{}, Direction: table.SortASC, NullOrder: table.NullsFirst},
+	}}, order)
+
+	order, err = ParseSortOrder(sch, "")
+	require.NoError(t, err)
+	require.Equal(t, table.UnsortedSortOrderID, order.OrderID)
+	require.Empty(t, order.Fields)
+
+	for _, s := range []string{"missing", "id sideways", "id as x", "month(name)", "id nulls"} {
+		_, err := ParseSortOrder(sch, s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestSetSortOrder(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1}, []string{"a"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{SortBy: "id DESC"}))
+
+	var load = func() *table.Table {
+		cat, err := NewVersionHintCatalog("file://" + dir)
+		require.NoError(t, err)
+
+		tbl, err := cat.LoadTable(ctx, nil, nil)
+		require.NoError(t, err)
+
+		return tbl
+	}
+
+	require.Equal(t, 1, load().SortOrder().OrderID)
+	require.Equal(t, table.SortDESC, load().SortOrder().Fields[0].Direction)
+
+	order, err := SetSortOrder(ctx, "file://"+dir, "category, id")
+	require.NoError(t, err)
+	require.Equal(t, 2, order.OrderID)
+	require.Equal(t, *order, load().SortOrder())
+	require.Len(t, load().Metadata().SortOrders(), 2)
+
+	// a previous sort order is reused
+	order, err = SetSortOrder(ctx, "file://"+dir, "id desc nulls last")
+	require.NoError(t, err)
+	require.Equal(t, 1, order.OrderID)
+	require.Equal(t, 1, load().SortOrder().OrderID)
+
+	order, err = SetSortOrder(ctx, "file://"+dir, "")
+	require.NoError(t, err)
+	require.Equal(t, table.UnsortedSortOrderID, order.OrderID)
+	require.Empty(t, load().SortOrder().Fields)
+	require.Len(t, load().Metadata().SortOrders(), 3)
+}
+
+func TestSortFiles(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{5, 1, 7, 3}, []string{"a", "b", "c", "d"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{2, 8, 4, 6}, []string{"e", "f", "g", "h"})
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet", "2.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	_, err := SortFiles(ctx, "file://"+dir, SortFilesConfig{})
+	require.ErrorContains(t, err, "table has no sort order")
+
+	_, err = SetSortOrder(ctx, "file://"+dir, "id")
+	require.NoError(t, err)
+
+	summary, err := FieldRangeSummary(ctx, "file://"+dir, "id", FieldBoundValuesConfig{})
+	require.NoError(t, err)
+	require.Len(t, summary.Overlaps, 1)
+
+	info := lo.Must(os.Stat(filepath.Join(dir, "data", "1.parquet")))
+
+	res, err := SortFiles(ctx, "file://"+dir, SortFilesConfig{TargetFileSizeBytes: info.Size()})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.ElementsMatch(t, []string{"1.parquet", "2.parquet"}, res[0].InputFiles)
+	require.Len(t, res[0].OutputFiles, 2)
+
+	summary, err = FieldRangeSummary(ctx, "file://"+dir, "id", FieldBoundValuesConfig{})
+	require.NoError(t, err)
+	require.Equal(t, 2, summary.FileCount)
+	require.Empty(t, summary.Overlaps)
+	require.Empty(t, summary.Gaps)
+	require.Equal(t, int64(1), summary.Lower)
+	require.Equal(t, int64(8), summary.Upper)
+}
@@ -0,0 +1,196 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/compute"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/google/uuid"
+)
+
+// sortIndices returns the indices of the rows of the record in the given sort order.
+// The sort is stable. Sort fields must be top-level columns of the record, which is
+// expected to match the schema.
+func sortIndices(rec arrow.Record, sch *iceberg.Schema, order table.SortOrder) (arrow.Array, error) {
+	keys, err := recordSortKeys(rec, sch, order)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var indices = make([]int64, rec.NumRows())
+
+	for i := range indices {
+		indices[i] = int64(i)
+	}
+
+	slices.SortStableFunc(indices, func(a, b int64) int {
+		for i, sf := range order.Fields {
+			if c := compareSortKeys(keys[i][a], keys[i][b], sf); c != 0 {
+				return c
+			}
+		}
+
+		return 0
+	})
+
+	var bldr = array.NewInt64Builder(memory.DefaultAllocator)
+	defer bldr.Release()
+
+	bldr.AppendValues(indices, nil)
+	return bldr.NewArray(), nil
+}
+
+// recordSortKeys returns the keys of the rows of the record for each field of the sort order.
+func recordSortKeys(rec arrow.Record, sch *iceberg.Schema, order table.SortOrder) ([][]iceberg.Literal, error) {
+	var (
+		keys = make([][]iceberg.Literal, len(order.Fields))
+		err  error
+	)
+
+	for i, sf := range order.Fields {
+		field, found := sch.FindFieldByID(sf.SourceID)
+
+		if !found {
+			return nil, fmt.Errorf("sort field %d not found", sf.SourceID)
+		}
+
+		var cols = rec.Schema().FieldIndices(field.Name)
+
+		if len(cols) != 1 {
+			return nil, fmt.Errorf("sort field %s is not a top-level column", field.Name)
+		}
+
+		if keys[i], err = sortKeys(rec.Column(cols[0]), field.Type, sf.Transform); err != nil {
+			return nil, fmt.Errorf("sort field %s: %w", field.Name, err)
+		}
+	}
+
+	return keys, nil
+}
+
+func compareSortKeys(a iceberg.Literal, b iceberg.Literal, sf table.SortField) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil && sf.NullOrder == table.NullsFirst, b == nil && sf.NullOrder == table.NullsLast:
+		return -1
+	case a == nil, b == nil:
+		return 1
+	}
+
+	var c = compareLiterals(a, b)
+
+	if sf.Direction == table.SortDESC {
+		return -c
+	}
+
+	return c
+}
+
+// sortKeys returns the transformed values of a column, nil for nulls.
+func sortKeys(arr arrow.Array, typ iceberg.Type, transform iceberg.Transform) ([]iceberg.Literal, error) {
+	var res = make([]iceberg.Literal, arr.Len())
+
+	for i := range res {
+		if arr.IsNull(i) {
+			continue
+		}
+
+		lit, err := arrowValueLiteral(arr, i, typ)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, identity := transform.(iceberg.IdentityTransform); !identity {
+			lit = transform.Apply(validLiteral(lit)).Val
+		}
+
+		res[i] = lit
+	}
+
+	return res, nil
+}
+
+// arrowValueLiteral converts the value at index i of a column projected with
+// table.ToRequestedSchema to a literal of the given type.
+func arrowValueLiteral(arr arrow.Array, i int, typ iceberg.Type) (iceberg.Literal, error) {
+	var lit iceberg.Literal
+
+	switch arr := arr.(type) {
+	case *array.Boolean:
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.Int32:
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.Int64:
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.Float32:
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.Float64:
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.Date32:
+		lit = iceberg.NewLiteral(iceberg.Date(arr.Value(i)))
+	case *array.Time64:
+		lit = iceberg.NewLiteral(iceberg.Time(arr.Value(i)))
+	case *array.Timestamp:
+		lit = iceberg.NewLiteral(iceberg.Timestamp(arr.Value(i)))
+	case *array.String:
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.LargeString:
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.Binary:
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.LargeBinary:
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.FixedSizeBinary:
+		if _, ok := typ.(iceberg.UUIDType); ok {
+			id, err := uuid.FromBytes(arr.Value(i))
+
+			if err != nil {
+				return nil, err
+			}
+
+			return iceberg.NewLiteral(id), nil
+		}
+
+		lit = iceberg.NewLiteral(arr.Value(i))
+	case *array.Decimal128:
+		lit = iceberg.NewLiteral(iceberg.Decimal{Val: arr.Value(i), Scale: int(arr.DataType().(*arrow.Decimal128Type).Scale)})
+	case array.ExtensionArray:
+		return arrowValueLiteral(arr.Storage(), i, typ)
+	default:
+		return nil, fmt.Errorf("cannot sort values of type %s", arr.DataType())
+	}
+
+	return lit.To(typ)
+}
+
+// takeRecord returns the rows of the record at the given indices.
+func takeRecord(ctx context.Context, rec arrow.Record, indices arrow.Array) (arrow.Record, error) {
+	var cols = make([]arrow.Array, rec.NumCols())
+
+	defer func() {
+		for _, col := range cols {
+			if col != nil {
+				col.Release()
+			}
+		}
+	}()
+
+	for i := range cols {
+		var err error
+
+		if cols[i], err = compute.TakeArray(ctx, rec.Column(i), indices); err != nil {
+			return nil, err
+		}
+	}
+
+	return array.NewRecord(rec.Schema(), cols, int64(indices.Len())), nil
+}
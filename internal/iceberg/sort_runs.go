@@ -0,0 +1,337 @@
+package iceberg
+
+import (
+	"container/heap"
+	"context"
+	"io"
+	"os"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/util"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+)
+
+// sortRunBatchRows is the number of rows of the batches written to and read from sorted
+// runs, and of the batches produced when merging them.
+const sortRunBatchRows = 64 * 1024
+
+// sortRecords passes the records produced by read to write, sorted in the given order.
+// Records are buffered until they reach runSize bytes in memory, then sorted and spilled
+// to a temporary Arrow IPC file. The spilled runs are finally merged, so that memory use
+// is bounded by about twice runSize, plus a batch per run while merging. The sort is
+// stable.
+func sortRecords(
+	ctx context.Context,
+	arrowSch *arrow.Schema,
+	sch *iceberg.Schema,
+	order table.SortOrder,
+	runSize int64,
+	read func(fn func(arrow.Record) error) error,
+	write func(arrow.Record) error,
+) error {
+	var (
+		buf     []arrow.Record
+		bufSize int64
+		runs    []*os.File
+	)
+
+	defer func() {
+		for _, rec := range buf {
+			rec.Release()
+		}
+
+		for _, f := range runs {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	var sortBuffer = func() (arrow.Record, error) {
+		rec, err := concatRecords(arrowSch, buf)
+
+		for _, rec := range buf {
+			rec.Release()
+		}
+
+		buf, bufSize = nil, 0
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer rec.Release()
+
+		indices, err := sortIndices(rec, sch, order)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer indices.Release()
+
+		return takeRecord(ctx, rec, indices)
+	}
+
+	var spill = func() error {
+		rec, err := sortBuffer()
+
+		if err != nil {
+			return err
+		}
+
+		defer rec.Release()
+
+		f, err := spillSortRun(arrowSch, rec)
+
+		if err != nil {
+			return err
+		}
+
+		runs = append(runs, f)
+		return nil
+	}
+
+	err := read(func(rec arrow.Record) error {
+		rec.Retain()
+		buf = append(buf, rec)
+		bufSize += util.TotalRecordSize(rec)
+
+		if bufSize < runSize {
+			return nil
+		}
+
+		return spill()
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// the rows fit in memory
+	if len(runs) == 0 {
+		rec, err := sortBuffer()
+
+		if err != nil {
+			return err
+		}
+
+		defer rec.Release()
+
+		return write(rec)
+	}
+
+	if len(buf) > 0 {
+		if err := spill(); err != nil {
+			return err
+		}
+	}
+
+	return mergeSortRuns(arrowSch, sch, order, runs, write)
+}
+
+// spillSortRun writes a sorted record to a temporary file, rewound to be read back.
+func spillSortRun(arrowSch *arrow.Schema, rec arrow.Record) (*os.File, error) {
+	f, err := os.CreateTemp("", "icepq-sort-*.arrow")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var w = ipc.NewWriter(f, ipc.WithSchema(arrowSch))
+
+	err = func() error {
+		for start := int64(0); start < rec.NumRows(); start += sortRunBatchRows {
+			var slice = rec.NewSlice(start, min(start+sortRunBatchRows, rec.NumRows()))
+			err := w.Write(slice)
+			slice.Release()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			return err
+		}
+
+		_, err := f.Seek(0, io.SeekStart)
+		return err
+	}()
+
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// sortRun is a sorted run read back batch by batch, positioned on its current row.
+type sortRun struct {
+	index int
+	rdr   *ipc.Reader
+	rec   arrow.Record
+	keys  [][]iceberg.Literal
+	pos   int
+}
+
+// next moves to the next row of the run, and reports whether there is one.
+func (r *sortRun) next(sch *iceberg.Schema, order table.SortOrder) (bool, error) {
+	r.pos++
+
+	for r.rec == nil || r.pos >= int(r.rec.NumRows()) {
+		if !r.rdr.Next() {
+			return false, r.rdr.Err()
+		}
+
+		keys, err := recordSortKeys(r.rdr.Record(), sch, order)
+
+		if err != nil {
+			return false, err
+		}
+
+		r.rec, r.keys, r.pos = r.rdr.Record(), keys, 0
+	}
+
+	return true, nil
+}
+
+// sortRunHeap orders runs by their current row, then by run index so that the merge is
+// stable.
+type sortRunHeap struct {
+	runs  []*sortRun
+	order table.SortOrder
+}
+
+func (h *sortRunHeap) Len() int { return len(h.runs) }
+
+func (h *sortRunHeap) Less(i, j int) bool {
+	var a, b = h.runs[i], h.runs[j]
+
+	for k, sf := range h.order.Fields {
+		if c := compareSortKeys(a.keys[k][a.pos], b.keys[k][b.pos], sf); c != 0 {
+			return c < 0
+		}
+	}
+
+	return a.index < b.index
+}
+
+func (h *sortRunHeap) Swap(i, j int) { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+
+func (h *sortRunHeap) Push(x any) { h.runs = append(h.runs, x.(*sortRun)) }
+
+func (h *sortRunHeap) Pop() any {
+	var r = h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return r
+}
+
+// mergeSortRuns merges sorted runs and passes the rows to write in batches of about
+// sortRunBatchRows rows. Consecutive rows of a run batch are passed as a single slice.
+func mergeSortRuns(arrowSch *arrow.Schema, sch *iceberg.Schema, order table.SortOrder, files []*os.File, write func(arrow.Record) error) error {
+	var (
+		h       = sortRunHeap{order: order}
+		out     []arrow.Record
+		outRows int64
+		pending arrow.Record
+		start   int
+		end     int
+	)
+
+	defer func() {
+		for _, rec := range out {
+			rec.Release()
+		}
+
+		if pending != nil {
+			pending.Release()
+		}
+	}()
+
+	for i, f := range files {
+		rdr, err := ipc.NewReader(f, ipc.WithSchema(arrowSch))
+
+		if err != nil {
+			return err
+		}
+
+		defer rdr.Release()
+
+		var r = sortRun{index: i, rdr: rdr, pos: -1}
+
+		ok, err := r.next(sch, order)
+
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			h.runs = append(h.runs, &r)
+		}
+	}
+
+	heap.Init(&h)
+
+	var flush = func() error {
+		if pending != nil {
+			out = append(out, pending.NewSlice(int64(start), int64(end)))
+			outRows += int64(end - start)
+			pending.Release()
+			pending = nil
+		}
+
+		if outRows < sortRunBatchRows && h.Len() > 0 {
+			return nil
+		}
+
+		rec, err := concatRecords(arrowSch, out)
+
+		for _, rec := range out {
+			rec.Release()
+		}
+
+		out, outRows = nil, 0
+
+		if err != nil {
+			return err
+		}
+
+		defer rec.Release()
+
+		return write(rec)
+	}
+
+	for h.Len() > 0 {
+		var r = h.runs[0]
+
+		if pending != r.rec || end != r.pos {
+			if err := flush(); err != nil {
+				return err
+			}
+
+			// the batch is released by the reader once the run moves to the next one
+			r.rec.Retain()
+			pending, start, end = r.rec, r.pos, r.pos
+		}
+
+		end++
+
+		ok, err := r.next(sch, order)
+
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+
+	return flush()
+}
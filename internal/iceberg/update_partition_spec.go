@@ -0,0 +1,186 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+)
+
+type UpdatePartitionSpecConfig struct {
+	// Add is a comma-separated list of partition fields (see ParsePartitionFieldExprs).
+	Add    string
+	Remove []string
+	Rename map[string]string
+}
+
+// UpdatePartitionSpec evolves the default partition spec of a table. Existing data files
+// keep the spec they were written with; only files added afterwards use the new spec.
+func UpdatePartitionSpec(ctx context.Context, tableLocation string, conf UpdatePartitionSpecConfig) (*iceberg.PartitionSpec, error) {
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		md      = t.Metadata()
+		sch     = t.Schema()
+		current = md.PartitionSpec()
+		fields  = slices.Collect(current.Fields())
+	)
+
+	for _, name := range conf.Remove {
+		var i = slices.IndexFunc(fields, func(f iceberg.PartitionField) bool { return f.Name == name })
+
+		if i < 0 {
+			return nil, fmt.Errorf("partition field %s not found", name)
+		}
+
+		// v1 specs cannot drop fields, which are replaced by a void transform instead
+		if md.Version() == 1 {
+			fields[i].Transform = iceberg.VoidTransform{}
+		} else {
+			fields = slices.Delete(fields, i, i+1)
+		}
+	}
+
+	for name, newName := range conf.Rename {
+		var i = slices.IndexFunc(fields, func(f iceberg.PartitionField) bool { return f.Name == name })
+
+		if i < 0 {
+			return nil, fmt.Errorf("partition field %s not found", name)
+		}
+
+		fields[i].Name = newName
+	}
+
+	exprs, err := ParsePartitionFieldExprs(conf.Add)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var lastFieldID = iceberg.PartitionDataIDStart - 1
+
+	if id := md.LastPartitionSpecID(); id != nil {
+		lastFieldID = *id
+	}
+
+	for _, expr := range exprs {
+		field, err := expr.PartitionField(sch, 0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.ContainsFunc(fields, func(f iceberg.PartitionField) bool { return samePartitionTransform(f, field) }) {
+			return nil, fmt.Errorf("table is already partitioned by %s(%s)", field.Transform, expr.SourceName)
+		}
+
+		// a field removed from a previous spec keeps its ID when added back
+		if previous, found := previousPartitionField(md, field); found {
+			field.FieldID = previous.FieldID
+
+			if len(expr.Name) == 0 {
+				field.Name = previous.Name
+			}
+		} else {
+			lastFieldID++
+			field.FieldID = lastFieldID
+		}
+
+		fields = append(fields, field)
+	}
+
+	if err := validatePartitionFields(sch, fields); err != nil {
+		return nil, err
+	}
+
+	if spec := iceberg.NewPartitionSpecID(current.ID(), fields...); spec.Equals(current) {
+		return &current, nil
+	}
+
+	var (
+		newSpec *iceberg.PartitionSpec
+		updates []table.Update
+		maxID   int
+	)
+
+	for _, spec := range md.PartitionSpecs() {
+		maxID = max(maxID, spec.ID())
+
+		if candidate := iceberg.NewPartitionSpecID(spec.ID(), fields...); candidate.Equals(spec) {
+			newSpec = &candidate
+		}
+	}
+
+	if newSpec != nil {
+		updates = append(updates, table.NewSetDefaultSpecUpdate(newSpec.ID()))
+	} else {
+		var spec = iceberg.NewPartitionSpecID(maxID+1, fields...)
+		newSpec = &spec
+		updates = append(updates, table.NewAddPartitionSpecUpdate(newSpec, false), table.NewSetDefaultSpecUpdate(-1))
+	}
+
+	var requirements = []table.Requirement{
+		table.AssertTableUUID(md.TableUUID()),
+		table.AssertDefaultSpecID(current.ID()),
+	}
+
+	if id := md.LastPartitionSpecID(); id != nil {
+		requirements = append(requirements, table.AssertLastAssignedPartitionID(*id))
+	}
+
+	if _, _, err := cat.CommitTable(ctx, t, requirements, updates); err != nil {
+		return nil, err
+	}
+
+	return newSpec, nil
+}
+
+func samePartitionTransform(a iceberg.PartitionField, b iceberg.PartitionField) bool {
+	return a.SourceID == b.SourceID && a.Transform.Equals(b.Transform)
+}
+
+func previousPartitionField(md table.Metadata, field iceberg.PartitionField) (iceberg.PartitionField, bool) {
+	for _, spec := range md.PartitionSpecs() {
+		for f := range spec.Fields() {
+			if samePartitionTransform(f, field) {
+				return f, true
+			}
+		}
+	}
+
+	return iceberg.PartitionField{}, false
+}
+
+// validatePartitionFields checks that partition field names are unique and do not shadow
+// a schema column, unless they are the identity partition of that column.
+func validatePartitionFields(sch *iceberg.Schema, fields []iceberg.PartitionField) error {
+	if err := validatePartitionFieldNames(iceberg.NewPartitionSpec(fields...)); err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		col, found := sch.FindFieldByName(f.Name)
+
+		if !found {
+			continue
+		}
+
+		if _, identity := f.Transform.(iceberg.IdentityTransform); !identity || col.ID != f.SourceID {
+			return fmt.Errorf("partition field name %s conflicts with a schema column", f.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package iceberg
+
+import (
+	"context"
+	"testing"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatePartitionSpec(t *testing.T) {
+	var (
+		ctx      = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir      = t.TempDir()
+		location = "file://" + dir
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1, 2}, []string{"a", "b"})
+	writeTestParquetFile(t, dir, "category=a/2.parquet", []int64{3}, []string{"a"})
+
+	require.NoError(t, CreateOrAddFiles(ctx, location, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	spec, err := UpdatePartitionSpec(ctx, location, UpdatePartitionSpecConfig{Add: "category, bucket(4, id)"})
+	require.NoError(t, err)
+	require.Equal(t, 1, spec.ID())
+	require.Equal(t, []string{"category", "id_bucket"}, partitionFieldNames(spec))
+
+	_, err = UpdatePartitionSpec(ctx, location, UpdatePartitionSpecConfig{Add: "category"})
+	require.Error(t, err)
+
+	spec, err = UpdatePartitionSpec(ctx, location, UpdatePartitionSpecConfig{
+		Remove: []string{"id_bucket"},
+		Rename: map[string]string{"category": "cat"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, spec.ID())
+	require.Equal(t, []string{"cat"}, partitionFieldNames(spec))
+	require.Equal(t, 1000, spec.Field(0).FieldID)
+
+	require.NoError(t, CreateOrAddFiles(ctx, location, []string{"category=a/2.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	cat, err := NewVersionHintCatalog(location)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+
+	mans, err := tbl.CurrentSnapshot().Manifests(iceio.NewObjectStoreIO(objstr.FromContext(ctx)))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int32{0, 2}, []int32{mans[0].PartitionSpecID(), mans[1].PartitionSpecID()})
+
+	// going back to the initial layout reuses the initial spec
+	spec, err = UpdatePartitionSpec(ctx, location, UpdatePartitionSpecConfig{Remove: []string{"cat"}})
+	require.NoError(t, err)
+	require.Equal(t, 0, spec.ID())
+}
+
+func partitionFieldNames(spec *iceberg.PartitionSpec) []string {
+	var res []string
+
+	for f := range spec.Fields() {
+		res = append(res, f.Name)
+	}
+
+	return res
+}
@@ -0,0 +1,484 @@
+package iceberg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+)
+
+// SchemaChange is a change applied to the current schema of a table by UpdateSchema.
+type SchemaChange func(u *schemaUpdate) error
+
+type schemaUpdate struct {
+	md           table.Metadata
+	io           *iceio.ObjectStoreIO
+	fields       []iceberg.NestedField
+	identifiers  []int
+	lastColumnID int
+}
+
+// UpdateSchema applies the changes to the current schema of a table and commits the
+// resulting schema. Columns are addressed by their dot-separated path, nested columns
+// being reachable through struct columns only.
+func UpdateSchema(ctx context.Context, tableLocation string, changes ...SchemaChange) (*iceberg.Schema, error) {
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		md      = t.Metadata()
+		current = md.CurrentSchema()
+		u       = &schemaUpdate{
+			md:           md,
+			io:           iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx)),
+			fields:       current.Fields(),
+			identifiers:  current.IdentifierFieldIDs,
+			lastColumnID: md.LastColumnID(),
+		}
+	)
+
+	for _, change := range changes {
+		if err := change(u); err != nil {
+			return nil, err
+		}
+	}
+
+	var maxID = lo.Max(lo.Map(md.Schemas(), func(sch *iceberg.Schema, _ int) int { return sch.ID }))
+	var sch = iceberg.NewSchemaWithIdentifiers(maxID+1, u.identifiers, u.fields...)
+
+	if sch.Equals(current) {
+		return current, nil
+	}
+
+	var spec = md.PartitionSpec()
+
+	if err := validatePartitionFields(sch, slices.Collect(spec.Fields())); err != nil {
+		return nil, err
+	}
+
+	var (
+		requirements = []table.Requirement{
+			table.AssertTableUUID(md.TableUUID()),
+			table.AssertCurrentSchemaID(current.ID),
+			table.AssertLastAssignedFieldID(md.LastColumnID()),
+		}
+		updates []table.Update
+	)
+
+	var droppedLast = sch.HighestFieldID() < md.LastColumnID()
+
+	if !droppedLast {
+		updates = append(updates, table.NewAddSchemaUpdate(sch), table.NewSetCurrentSchemaUpdate(-1))
+	}
+
+	if nm := t.NameMapping(); nm != nil {
+		js, err := json.Marshal(mergeNameMapping(sch.NameMapping(), nm))
+
+		if err != nil {
+			return nil, err
+		}
+
+		updates = append(updates, table.NewSetPropertiesUpdate(iceberg.Properties{table.DefaultNameMappingKey: string(js)}))
+	}
+
+	if !droppedLast {
+		newMd, _, err := cat.CommitTable(ctx, t, requirements, updates)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return newMd.CurrentSchema(), nil
+	}
+
+	newMd, err := updatedMetadata(t, requirements, updates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if newMd, err = withDroppedColumnsSchema(newMd, sch); err != nil {
+		return nil, err
+	}
+
+	if newMd, _, err = cat.commitMetadata(ctx, t, newMd); err != nil {
+		return nil, err
+	}
+
+	return newMd.CurrentSchema(), nil
+}
+
+// AddColumn adds an optional column. The type is either an Iceberg primitive type name
+// (e.g. long, decimal(10, 2), timestamptz) or the JSON representation of a nested type.
+func AddColumn(path string, typ string, doc string) SchemaChange {
+	return func(u *schemaUpdate) error {
+		parent, name := splitColumnPath(path)
+
+		t, err := parseIcebergType(typ)
+
+		if err != nil {
+			return err
+		}
+
+		sch, err := iceberg.AssignFreshSchemaIDs(
+			iceberg.NewSchema(0, iceberg.NestedField{Name: name, Type: t, Doc: doc}),
+			func() int { u.lastColumnID++; return u.lastColumnID },
+		)
+
+		if err != nil {
+			return err
+		}
+
+		return u.editStruct(parent, func(fields []iceberg.NestedField) ([]iceberg.NestedField, error) {
+			if slices.ContainsFunc(fields, func(f iceberg.NestedField) bool { return f.Name == name }) {
+				return nil, fmt.Errorf("column %s already exists", path)
+			}
+
+			return append(slices.Clone(fields), sch.Field(0)), nil
+		})
+	}
+}
+
+// DropColumn removes a column, which must not be an identifier field, a source of the
+// default sort order, or a source of the default partition spec or of a spec still used
+// by the live files of a branch or tag. Older specs only used by files that are no longer
+// live can lose their source: the partition values of these files are kept as they are.
+func DropColumn(path string) SchemaChange {
+	return func(u *schemaUpdate) error {
+		parent, name := splitColumnPath(path)
+
+		return u.editStruct(parent, func(fields []iceberg.NestedField) ([]iceberg.NestedField, error) {
+			var i = slices.IndexFunc(fields, func(f iceberg.NestedField) bool { return f.Name == name })
+
+			if i < 0 {
+				return nil, fmt.Errorf("column %s not found", path)
+			}
+
+			ids, err := iceberg.IndexByID(iceberg.NewSchema(0, fields[i]))
+
+			if err != nil {
+				return nil, err
+			}
+
+			var (
+				spec      = u.md.PartitionSpec()
+				sortOrder = u.md.SortOrder()
+			)
+
+			liveSpecs, err := u.liveSpecs()
+
+			if err != nil {
+				return nil, err
+			}
+
+			for id := range ids {
+				switch {
+				case slices.Contains(u.identifiers, id):
+					return nil, fmt.Errorf("cannot drop column %s: it is an identifier field", path)
+				case len(spec.FieldsBySourceID(id)) > 0:
+					return nil, fmt.Errorf("cannot drop column %s: it is used by the partition spec", path)
+				case slices.ContainsFunc(sortOrder.Fields, func(f table.SortField) bool { return f.SourceID == id }):
+					return nil, fmt.Errorf("cannot drop column %s: it is used by the sort order", path)
+				}
+
+				for _, s := range liveSpecs {
+					if len(s.FieldsBySourceID(id)) > 0 {
+						return nil, fmt.Errorf("cannot drop column %s: it is used by partition spec %d of live data files", path, s.ID())
+					}
+				}
+			}
+
+			return slices.Delete(slices.Clone(fields), i, i+1), nil
+		})
+	}
+}
+
+// RenameColumn renames a column. Data files keep resolving the column by field ID, or
+// by its former name through the name mapping for files written without field IDs.
+func RenameColumn(path string, newName string) SchemaChange {
+	return func(u *schemaUpdate) error {
+		if len(newName) == 0 || strings.Contains(newName, ".") {
+			return fmt.Errorf("invalid column name: %q", newName)
+		}
+
+		return u.editColumn(path, func(fields []iceberg.NestedField, i int) error {
+			if slices.ContainsFunc(fields, func(f iceberg.NestedField) bool { return f.Name == newName }) {
+				return fmt.Errorf("column %s already exists", newName)
+			}
+
+			fields[i].Name = newName
+			return nil
+		})
+	}
+}
+
+// MakeColumnOptional turns a required column into an optional one.
+func MakeColumnOptional(path string) SchemaChange {
+	return func(u *schemaUpdate) error {
+		return u.editColumn(path, func(fields []iceberg.NestedField, i int) error {
+			if slices.Contains(u.identifiers, fields[i].ID) {
+				return fmt.Errorf("cannot make column %s optional: it is an identifier field", path)
+			}
+
+			fields[i].Required = false
+			return nil
+		})
+	}
+}
+
+// UpdateColumnDoc sets the documentation of a column.
+func UpdateColumnDoc(path string, doc string) SchemaChange {
+	return func(u *schemaUpdate) error {
+		return u.editColumn(path, func(fields []iceberg.NestedField, i int) error {
+			fields[i].Doc = doc
+			return nil
+		})
+	}
+}
+
+type ColumnPosition string
+
+const (
+	ColumnPositionFirst  ColumnPosition = "first"
+	ColumnPositionBefore ColumnPosition = "before"
+	ColumnPositionAfter  ColumnPosition = "after"
+)
+
+// MoveColumn moves a column first, or before or after a sibling column of the same struct.
+func MoveColumn(path string, pos ColumnPosition, ref string) SchemaChange {
+	return func(u *schemaUpdate) error {
+		parent, name := splitColumnPath(path)
+
+		return u.editStruct(parent, func(fields []iceberg.NestedField) ([]iceberg.NestedField, error) {
+			var i = slices.IndexFunc(fields, func(f iceberg.NestedField) bool { return f.Name == name })
+
+			if i < 0 {
+				return nil, fmt.Errorf("column %s not found", path)
+			}
+
+			var (
+				field = fields[i]
+				res   = slices.Delete(slices.Clone(fields), i, i+1)
+			)
+
+			if pos == ColumnPositionFirst {
+				return slices.Insert(res, 0, field), nil
+			}
+
+			var j = slices.IndexFunc(res, func(f iceberg.NestedField) bool { return f.Name == ref })
+
+			if ref == name || j < 0 {
+				return nil, fmt.Errorf("cannot move column %s %s %s: no such sibling column", path, pos, ref)
+			}
+
+			switch pos {
+			case ColumnPositionBefore:
+				return slices.Insert(res, j, field), nil
+			case ColumnPositionAfter:
+				return slices.Insert(res, j+1, field), nil
+			default:
+				return nil, fmt.Errorf("invalid column position: %s", pos)
+			}
+		})
+	}
+}
+
+// liveSpecs returns the partition specs of the manifests holding added or existing files
+// in the snapshots of the branches and tags of the table.
+func (u *schemaUpdate) liveSpecs() ([]iceberg.PartitionSpec, error) {
+	var ids = make(map[int]bool)
+
+	for _, ref := range u.md.Refs() {
+		var snap = u.md.SnapshotByID(ref.SnapshotID)
+
+		if snap == nil {
+			continue
+		}
+
+		mans, err := snap.Manifests(u.io)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, man := range mans {
+			if man.HasAddedFiles() || man.HasExistingFiles() {
+				ids[int(man.PartitionSpecID())] = true
+			}
+		}
+	}
+
+	return lo.Filter(u.md.PartitionSpecs(), func(spec iceberg.PartitionSpec, _ int) bool { return ids[spec.ID()] }), nil
+}
+
+func (u *schemaUpdate) editColumn(path string, fn func(fields []iceberg.NestedField, i int) error) error {
+	parent, name := splitColumnPath(path)
+
+	return u.editStruct(parent, func(fields []iceberg.NestedField) ([]iceberg.NestedField, error) {
+		var i = slices.IndexFunc(fields, func(f iceberg.NestedField) bool { return f.Name == name })
+
+		if i < 0 {
+			return nil, fmt.Errorf("column %s not found", path)
+		}
+
+		var res = slices.Clone(fields)
+
+		if err := fn(res, i); err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	})
+}
+
+// editStruct replaces the fields of the struct found at the given path.
+func (u *schemaUpdate) editStruct(path []string, fn func([]iceberg.NestedField) ([]iceberg.NestedField, error)) error {
+	fields, err := editStructFields(u.fields, path, fn)
+
+	if err != nil {
+		return err
+	}
+
+	u.fields = fields
+	return nil
+}
+
+func editStructFields(
+	fields []iceberg.NestedField,
+	path []string,
+	fn func([]iceberg.NestedField) ([]iceberg.NestedField, error),
+) ([]iceberg.NestedField, error) {
+	if len(path) == 0 {
+		return fn(fields)
+	}
+
+	var i = slices.IndexFunc(fields, func(f iceberg.NestedField) bool { return f.Name == path[0] })
+
+	if i < 0 {
+		return nil, fmt.Errorf("column %s not found", path[0])
+	}
+
+	st, ok := fields[i].Type.(*iceberg.StructType)
+
+	if !ok {
+		return nil, fmt.Errorf("column %s is not a struct", path[0])
+	}
+
+	nested, err := editStructFields(st.FieldList, path[1:], fn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res = slices.Clone(fields)
+	res[i].Type = &iceberg.StructType{FieldList: nested}
+	return res, nil
+}
+
+func splitColumnPath(path string) ([]string, string) {
+	var parts = strings.Split(path, ".")
+	return parts[:len(parts)-1], parts[len(parts)-1]
+}
+
+func parseIcebergType(s string) (iceberg.Type, error) {
+	var typ = strings.TrimSpace(s)
+
+	if !strings.HasPrefix(typ, "{") {
+		js, err := json.Marshal(strings.ReplaceAll(typ, " ", ""))
+
+		if err != nil {
+			return nil, err
+		}
+
+		typ = string(js)
+	}
+
+	var field iceberg.NestedField
+
+	if err := json.Unmarshal([]byte(`{"id": 0, "name": "_", "required": false, "type": `+typ+`}`), &field); err != nil {
+		return nil, fmt.Errorf("invalid type %s: %w", s, err)
+	}
+
+	return field.Type, nil
+}
+
+// mergeNameMapping keeps the names of the previous mapping as aliases, so that files
+// written before a rename still resolve to the same field.
+func mergeNameMapping(nm iceberg.NameMapping, previous iceberg.NameMapping) iceberg.NameMapping {
+	var res = make(iceberg.NameMapping, 0, len(nm))
+
+	for _, f := range nm {
+		var prev, found = lo.Find(previous, func(p iceberg.MappedField) bool { return p.FieldID != nil && p.ID() == f.ID() })
+
+		if found {
+			f.Names = lo.Uniq(append(f.Names, prev.Names...))
+			f.Fields = mergeNameMapping(f.Fields, prev.Fields)
+		}
+
+		res = append(res, f)
+	}
+
+	return res
+}
+
+// withDroppedColumnsSchema returns the metadata with the schema added as current schema.
+// The highest field ID of the schema is lower than the last assigned column ID, which
+// happens when the column holding it is dropped. The spec only requires field IDs to never
+// be reassigned, and last-column-id must stay unchanged so that they are not. The builder
+// cannot be used: MetadataBuilder.AddSchema treats the highest field ID of the schema as
+// the new last column ID and rejects it when lower, and the builder has no other way to add
+// a schema. Metadata is otherwise only created by parsing it, so the schema is added to the
+// serialized metadata, every other field (last-column-id included) being kept as is.
+func withDroppedColumnsSchema(md table.Metadata, sch *iceberg.Schema) (table.Metadata, error) {
+	js, err := json.Marshal(md)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// fields are kept undecoded: snapshot IDs do not fit in the float64 of a generic decoding
+	var raw map[string]json.RawMessage
+
+	if err := json.Unmarshal(js, &raw); err != nil {
+		return nil, err
+	}
+
+	for k, v := range map[string]any{
+		"schemas":           append(md.Schemas(), sch),
+		"current-schema-id": sch.ID,
+		"last-updated-ms":   time.Now().UnixMilli(),
+	} {
+		if raw[k], err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if md.Version() == 1 {
+		if raw["schema"], err = json.Marshal(sch); err != nil {
+			return nil, err
+		}
+	}
+
+	if js, err = json.Marshal(raw); err != nil {
+		return nil, err
+	}
+
+	return table.ParseMetadataBytes(js)
+}
@@ -0,0 +1,158 @@
+package iceberg
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+)
+
+func schemaColumnNames(sch *iceberg.Schema) []string {
+	return lo.Map(sch.Fields(), func(f iceberg.NestedField, _ int) string { return f.Name })
+}
+
+func TestUpdateSchema(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+		loc = "file://" + dir
+	)
+
+	writeTestParquetFile(t, dir, "1.parquet", []int64{1}, []string{"a"})
+	require.NoError(t, CreateOrAddFiles(ctx, loc, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{PartitionBy: "category"}))
+
+	sch, err := UpdateSchema(ctx, loc, AddColumn("extra", "decimal(10, 2)", "some doc"), MoveColumn("extra", ColumnPositionFirst, ""))
+	require.NoError(t, err)
+	require.Equal(t, []string{"extra", "id", "category"}, schemaColumnNames(sch))
+
+	extra, _ := sch.FindFieldByName("extra")
+	require.Equal(t, 3, extra.ID)
+	require.Equal(t, "some doc", extra.Doc)
+	require.False(t, extra.Required)
+	require.Equal(t, iceberg.DecimalTypeOf(10, 2), extra.Type)
+
+	sch, err = UpdateSchema(ctx, loc, AddColumn("s", `{"type": "struct", "fields": [{"id": 1, "name": "a", "type": "int", "required": false}]}`, ""))
+	require.NoError(t, err)
+	sch, err = UpdateSchema(ctx, loc, AddColumn("s.b", "string", ""), MoveColumn("s.b", ColumnPositionBefore, "a"))
+	require.NoError(t, err)
+
+	b, found := sch.FindFieldByName("s.b")
+	require.True(t, found)
+	require.Equal(t, 6, b.ID)
+	require.Equal(t, []string{"b", "a"}, lo.Map(sch.Field(3).Type.(*iceberg.StructType).FieldList, func(f iceberg.NestedField, _ int) string { return f.Name }))
+
+	_, err = UpdateSchema(ctx, loc, DropColumn("category"))
+	require.ErrorContains(t, err, "used by the partition spec")
+	_, err = UpdateSchema(ctx, loc, RenameColumn("id", "extra"))
+	require.ErrorContains(t, err, "already exists")
+	_, err = UpdateSchema(ctx, loc, MoveColumn("id", ColumnPositionAfter, "missing"))
+	require.Error(t, err)
+
+	// dropping the columns holding the highest field IDs must not let them be reassigned
+	sch, err = UpdateSchema(ctx, loc, DropColumn("s"), RenameColumn("id", "key"), MakeColumnOptional("key"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"extra", "key", "category"}, schemaColumnNames(sch))
+
+	key, _ := sch.FindFieldByName("key")
+	require.Equal(t, 1, key.ID)
+	require.False(t, key.Required)
+
+	sch, err = UpdateSchema(ctx, loc, AddColumn("again", "string", ""))
+	require.NoError(t, err)
+
+	again, _ := sch.FindFieldByName("again")
+	require.Equal(t, 7, again.ID)
+
+	cat, err := NewVersionHintCatalog(loc)
+	require.NoError(t, err)
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 7, tbl.Metadata().LastColumnID())
+	require.Len(t, tbl.Metadata().Schemas(), 6)
+	require.True(t, tbl.Schema().Equals(sch))
+
+	// files written before the rename still resolve the column by its former name
+	mapped, found := lo.Find(tbl.NameMapping(), func(f iceberg.MappedField) bool { return f.ID() == 1 })
+	require.True(t, found)
+	require.ElementsMatch(t, []string{"key", "id"}, mapped.Names)
+}
+
+func TestDropPartitionSourceColumn(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+		loc = "file://" + dir
+	)
+
+	writeTestParquetFile(t, dir, "category=a/1.parquet", []int64{1}, []string{"a"})
+	writeTestParquetFile(t, dir, "2.parquet", []int64{1}, []string{"a"})
+	require.NoError(t, CreateOrAddFiles(ctx, loc, []string{"category=a/1.parquet"}, nil, CreateOrAddFilesConfig{PartitionBy: "category"}))
+
+	_, err := UpdatePartitionSpec(ctx, loc, UpdatePartitionSpecConfig{Remove: []string{"category"}})
+	require.NoError(t, err)
+
+	// the data file added with the former spec is still live
+	_, err = UpdateSchema(ctx, loc, DropColumn("category"))
+	require.ErrorContains(t, err, "used by partition spec 0 of live data files")
+
+	require.NoError(t, ReplaceFiles(ctx, loc, []string{"category=a/1.parquet"}, []string{"2.parquet"}, nil, ReplaceFilesConfig{}))
+
+	sch, err := UpdateSchema(ctx, loc, DropColumn("category"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"id"}, schemaColumnNames(sch))
+}
+
+func TestWithDroppedColumnsSchema(t *testing.T) {
+	var sch = iceberg.NewSchema(0,
+		iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64, Required: true},
+		iceberg.NestedField{ID: 2, Name: "extra", Type: iceberg.PrimitiveTypes.String},
+	)
+
+	md, err := table.NewMetadata(sch, iceberg.UnpartitionedSpec, table.UnsortedSortOrder, "file:///tmp/t", iceberg.Properties{"k": "v"})
+	require.NoError(t, err)
+
+	// snapshot IDs use the full int64 range, beyond the integers a float64 holds exactly
+	const snapshotID = 3503978541152550306
+
+	b, err := table.MetadataBuilderFromBase(md)
+	require.NoError(t, err)
+	_, err = b.AddSnapshot(&table.Snapshot{SnapshotID: snapshotID, SequenceNumber: 1, TimestampMs: md.LastUpdatedMillis()})
+	require.NoError(t, err)
+	_, err = b.SetSnapshotRef(table.MainBranch, snapshotID, table.BranchRef)
+	require.NoError(t, err)
+	md, err = b.Build()
+	require.NoError(t, err)
+
+	var dropped = iceberg.NewSchema(1, sch.Field(0))
+
+	newMd, err := withDroppedColumnsSchema(md, dropped)
+	require.NoError(t, err)
+
+	// field ID 2 must never be reassigned
+	require.Equal(t, 2, newMd.LastColumnID())
+	require.True(t, newMd.CurrentSchema().Equals(dropped))
+	require.Equal(t, 1, newMd.CurrentSchema().ID)
+	require.Len(t, newMd.Schemas(), 2)
+	require.True(t, newMd.Schemas()[0].Equals(sch))
+	require.Equal(t, md.TableUUID(), newMd.TableUUID())
+	require.Equal(t, md.Location(), newMd.Location())
+	require.Equal(t, md.Properties(), newMd.Properties())
+	require.Equal(t, md.Version(), newMd.Version())
+	require.EqualValues(t, snapshotID, newMd.CurrentSnapshot().SnapshotID)
+	require.Equal(t, md.Snapshots(), newMd.Snapshots())
+	require.GreaterOrEqual(t, newMd.LastUpdatedMillis(), md.LastUpdatedMillis())
+
+	// the metadata survives a serialization round trip
+	js, err := json.Marshal(newMd)
+	require.NoError(t, err)
+
+	parsed, err := table.ParseMetadataBytes(js)
+	require.NoError(t, err)
+	require.True(t, parsed.Equals(newMd))
+}
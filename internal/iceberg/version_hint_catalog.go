@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"path/filepath"
 
@@ -12,11 +13,11 @@ import (
 	"github.com/agnosticeng/objstr"
 	objstrerrs "github.com/agnosticeng/objstr/errors"
 	"github.com/agnosticeng/objstr/utils"
-	osutils "github.com/agnosticeng/objstr/utils"
 	"github.com/apache/iceberg-go"
 	"github.com/apache/iceberg-go/catalog"
 	"github.com/apache/iceberg-go/io"
 	"github.com/apache/iceberg-go/table"
+	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/google/uuid"
 )
 
@@ -63,49 +64,19 @@ func (cat *VersionHintCatalog) CreateTable(
 		return nil, catalog.ErrTableAlreadyExists
 	}
 
-	b, err := table.NewMetadataBuilder()
-
-	if err != nil {
-		return nil, err
-	}
-
-	b, err = b.SetProperties(conf.Properties)
-
-	if err != nil {
-		return nil, err
-	}
-
-	b, err = b.SetUUID(uuid.Must(uuid.NewV7()))
-
-	if err != nil {
-		return nil, err
-	}
-
-	b, err = b.SetLoc(cat.tableLocation.String())
-
-	if err != nil {
-		return nil, err
-	}
-
-	b, err = b.AddSchema(schema)
-
-	if err != nil {
-		return nil, err
-	}
+	var spec = conf.PartitionSpec
 
-	b, err = b.AddPartitionSpec(iceberg.UnpartitionedSpec, true)
-
-	if err != nil {
-		return nil, err
+	if spec == nil {
+		spec = iceberg.UnpartitionedSpec
 	}
 
-	b, err = b.AddSortOrder(&table.UnsortedSortOrder, true)
+	var order = conf.SortOrder
 
-	if err != nil {
-		return nil, err
+	if len(order.Fields) == 0 {
+		order = table.UnsortedSortOrder
 	}
 
-	b, err = b.SetFormatVersion(2)
+	md, err := newTableMetadata(cat.tableLocation.String(), schema, spec, order, conf.Properties)
 
 	if err != nil {
 		return nil, err
@@ -116,25 +87,11 @@ func (cat *VersionHintCatalog) CreateTable(
 		mdLoc  = cat.tableLocation.JoinPath("metadata", mdName)
 	)
 
-	// don't know how to add proper log entries at commit time
-	// so I "disable" it
-	//
-	// b = b.AppendMetadataLog(table.MetadataLogEntry{
-	// 	MetadataFile: mdLoc.String(),
-	// 	TimestampMs:  time.Now().UnixMilli(),
-	// })
-
-	md, err := b.Build()
-
-	if err != nil {
-		return nil, err
-	}
-
 	if err := cat.writeMetadataFile(ctx, os, mdLoc, md); err != nil {
 		return nil, err
 	}
 
-	if err := cat.writeVersionHint(ctx, os, "", mdName); err != nil {
+	if err := cat.writeVersionHint(ctx, "", mdName); err != nil {
 		return nil, err
 	}
 
@@ -153,17 +110,21 @@ func (cat *VersionHintCatalog) LoadTable(ctx context.Context, identifier table.I
 	var (
 		os           = objstr.FromContextOrDefault(ctx)
 		osio         = iceio.NewObjectStoreIO(os)
-		content, err = osutils.ReadObject(ctx, os, cat.tableLocation.JoinPath("metadata", "version-hint.text"))
+		content, err = readVersionHint(ctx, VersionHintStoreFromContextOrDefault(ctx), cat.tableLocation.JoinPath("metadata", "version-hint.text"))
 	)
 
 	if errors.Is(err, objstrerrs.ErrObjectNotFound) {
 		return nil, catalog.ErrNoSuchTable
 	}
 
+	if err != nil {
+		return nil, err
+	}
+
 	return table.NewFromLocation(
 		ctx,
 		[]string{},
-		cat.tableLocation.JoinPath("metadata", string(content)).String(),
+		cat.tableLocation.JoinPath("metadata", content).String(),
 		func(ctx context.Context) (io.IO, error) {
 			return osio, nil
 		},
@@ -177,35 +138,52 @@ func (cat *VersionHintCatalog) CommitTable(
 	requirements []table.Requirement,
 	updates []table.Update,
 ) (table.Metadata, string, error) {
-	var (
-		os     = objstr.FromContextOrDefault(ctx)
-		b, err = table.MetadataBuilderFromBase(t.Metadata())
-	)
+	md, err := updatedMetadata(t, requirements, updates)
+
+	if err != nil {
+		return nil, "", err
+	}
 
+	return cat.commitMetadata(ctx, t, md)
+}
+
+// updatedMetadata validates the requirements against the metadata of the table, then
+// applies the updates to it and records the current metadata file in the metadata log.
+func updatedMetadata(t *table.Table, requirements []table.Requirement, updates []table.Update) (table.Metadata, error) {
 	for _, req := range requirements {
 		if err := req.Validate(t.Metadata()); err != nil {
-			return nil, "", err
+			return nil, err
 		}
 	}
 
+	b, err := table.MetadataBuilderFromBase(t.Metadata())
+
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
 	for _, update := range updates {
 		if err := update.Apply(b); err != nil {
-			return nil, "", err
+			return nil, err
 		}
 	}
 
-	md, err := b.Build()
+	b.AppendMetadataLog(table.MetadataLogEntry{
+		MetadataFile: t.MetadataLocation(),
+		TimestampMs:  t.Metadata().LastUpdatedMillis(),
+	})
 
-	if err != nil {
-		return nil, "", err
-	}
+	b.TrimMetadataLogs(t.Metadata().Properties().GetInt(table.MetadataPreviousVersionsMaxKey, table.MetadataPreviousVersionsMaxDefault))
 
+	return b.Build()
+}
+
+// commitMetadata writes the new metadata of the table and points the version hint to it.
+func (cat *VersionHintCatalog) commitMetadata(ctx context.Context, t *table.Table, md table.Metadata) (table.Metadata, string, error) {
 	var (
-		mdName = metadataFileName(md.CurrentSnapshot().SequenceNumber)
+		os     = objstr.FromContextOrDefault(ctx)
+		props  = t.Metadata().Properties()
+		mdName = metadataFileName(md.LastSequenceNumber())
 		mdLoc  = cat.tableLocation.JoinPath("metadata", mdName)
 	)
 
@@ -213,13 +191,49 @@ func (cat *VersionHintCatalog) CommitTable(
 		return nil, "", err
 	}
 
-	if err := cat.writeVersionHint(ctx, os, filepath.Base(t.MetadataLocation()), mdName); err != nil {
+	if err := cat.writeVersionHint(ctx, filepath.Base(t.MetadataLocation()), mdName); err != nil {
+		// the metadata file we just wrote will never be referenced
+		if errors.Is(err, ErrConsistencyViolation) {
+			_ = os.Delete(context.WithoutCancel(ctx), mdLoc)
+		}
+
 		return nil, "", err
 	}
 
+	if props.GetBool(table.MetadataDeleteAfterCommitEnabledKey, table.MetadataDeleteAfterCommitEnabledDefault) {
+		cat.deleteRemovedMetadataFiles(ctx, os, t.Metadata(), md)
+	}
+
 	return md, mdLoc.String(), nil
 }
 
+// deleteRemovedMetadataFiles removes the metadata files that were trimmed
+// from the metadata log by a commit.
+func (cat *VersionHintCatalog) deleteRemovedMetadataFiles(ctx context.Context, os *objstr.ObjectStore, base table.Metadata, md table.Metadata) {
+	var retained = mapset.NewThreadUnsafeSet[string]()
+
+	for entry := range md.PreviousFiles() {
+		retained.Add(entry.MetadataFile)
+	}
+
+	for entry := range base.PreviousFiles() {
+		if retained.Contains(entry.MetadataFile) {
+			continue
+		}
+
+		u, err := url.Parse(entry.MetadataFile)
+
+		if err != nil {
+			slog.Warn("failed to parse previous metadata file location", "location", entry.MetadataFile, "error", err)
+			continue
+		}
+
+		if err := os.Delete(ctx, u); err != nil && !errors.Is(err, objstrerrs.ErrObjectNotFound) {
+			slog.Warn("failed to delete previous metadata file", "location", entry.MetadataFile, "error", err)
+		}
+	}
+}
+
 func (cat *VersionHintCatalog) writeMetadataFile(ctx context.Context, os *objstr.ObjectStore, location *url.URL, md table.Metadata) error {
 	js, err := json.Marshal(md)
 
@@ -235,44 +249,89 @@ func (cat *VersionHintCatalog) writeMetadataFile(ctx context.Context, os *objstr
 	)
 }
 
-// We should be using If-Match here to enforce atomic swap
-// but our current S3-like provider does not supports it, so
-// we fallback to a method that allows a small window of inconsistency.
 func (cat *VersionHintCatalog) writeVersionHint(
 	ctx context.Context,
-	os *objstr.ObjectStore,
 	expectedContent,
 	newContent string,
 ) error {
-	var versionHintLocation = cat.tableLocation.JoinPath("metadata", "version-hint.text")
+	return VersionHintStoreFromContextOrDefault(ctx).Swap(
+		ctx,
+		cat.tableLocation.JoinPath("metadata", "version-hint.text"),
+		expectedContent,
+		newContent,
+	)
+}
 
-	if len(expectedContent) != 0 {
-		actualContent, err := osutils.ReadObject(ctx, os, versionHintLocation)
+func newTableMetadata(
+	location string,
+	schema *iceberg.Schema,
+	spec *iceberg.PartitionSpec,
+	order table.SortOrder,
+	props iceberg.Properties,
+) (table.Metadata, error) {
+	b, err := table.NewMetadataBuilder()
 
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		if string(actualContent) != expectedContent {
-			return ErrConsistencyViolation
-		}
+	b, err = b.SetProperties(props)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return osutils.CreateObject(ctx, os, versionHintLocation, []byte(newContent))
-}
+	b, err = b.SetUUID(uuid.Must(uuid.NewV7()))
 
-func metadataFileName(sequenceNumber int64) string {
-	return fmt.Sprintf("%012d-%s.metadata.json", sequenceNumber, uuid.Must(uuid.NewV7()))
-}
+	if err != nil {
+		return nil, err
+	}
 
-func DoCommit(f func() error) error {
-	for {
-		var err = f()
+	b, err = b.SetLoc(location)
 
-		if err == ErrConsistencyViolation {
-			continue
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		return err
+	b, err = b.AddSchema(schema)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = b.SetCurrentSchemaID(-1)
+
+	if err != nil {
+		return nil, err
 	}
+
+	b, err = b.AddPartitionSpec(spec, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = b.AddSortOrder(&order, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = b.SetDefaultSortOrderID(order.OrderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = b.SetFormatVersion(2)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Build()
+}
+
+func metadataFileName(sequenceNumber int64) string {
+	return fmt.Sprintf("%012d-%s.metadata.json", sequenceNumber, uuid.Must(uuid.NewV7()))
 }
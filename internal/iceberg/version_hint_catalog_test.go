@@ -0,0 +1,90 @@
+package iceberg
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	objstrerrs "github.com/agnosticeng/objstr/errors"
+	osutils "github.com/agnosticeng/objstr/utils"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/catalog"
+	"github.com/apache/iceberg-go/table"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTable(t *testing.T, props iceberg.Properties) (context.Context, *VersionHintCatalog, *table.Table) {
+	var (
+		os  = objstr.MustNewObjectStore(context.Background(), objstr.Config{DefaultBackend: "memory"})
+		ctx = objstr.NewContext(context.Background(), os)
+	)
+
+	cat, err := NewVersionHintCatalog("memory://bucket/table")
+	require.NoError(t, err)
+
+	tbl, err := cat.CreateTable(
+		ctx,
+		nil,
+		iceberg.NewSchema(0, iceberg.NestedField{ID: 1, Name: "id", Type: iceberg.PrimitiveTypes.Int64, Required: true}),
+		catalog.WithProperties(props),
+	)
+	require.NoError(t, err)
+
+	return ctx, cat, tbl
+}
+
+func TestCommitTableMetadataLog(t *testing.T) {
+	ctx, cat, tbl := newTestTable(t, iceberg.Properties{
+		table.MetadataPreviousVersionsMaxKey:      "2",
+		table.MetadataDeleteAfterCommitEnabledKey: "true",
+	})
+
+	var locations = []string{tbl.MetadataLocation()}
+
+	for i := range 4 {
+		var tx = tbl.NewTransaction()
+		require.NoError(t, tx.SetProperties(iceberg.Properties{"counter": strconv.Itoa(i)}))
+
+		var err error
+		tbl, err = tx.Commit(ctx)
+		require.NoError(t, err)
+
+		locations = append(locations, tbl.MetadataLocation())
+	}
+
+	tbl, err := cat.LoadTable(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, locations[4], tbl.MetadataLocation())
+
+	var previous = slices.Collect(tbl.Metadata().PreviousFiles())
+	require.Len(t, previous, 2)
+	require.Equal(t, locations[2], previous[0].MetadataFile)
+	require.Equal(t, locations[3], previous[1].MetadataFile)
+
+	for i, loc := range locations {
+		u, err := url.Parse(loc)
+		require.NoError(t, err)
+
+		_, err = objstr.FromContext(ctx).ReadMetadata(ctx, u)
+
+		if i < 2 {
+			require.ErrorIs(t, err, objstrerrs.ErrObjectNotFound)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestLoadTableEmptyVersionHint(t *testing.T) {
+	ctx, cat, _ := newTestTable(t, nil)
+
+	u, err := url.Parse("memory://bucket/table/metadata/version-hint.text")
+	require.NoError(t, err)
+	require.NoError(t, osutils.CreateObject(ctx, objstr.FromContext(ctx), u, nil))
+
+	_, err = cat.LoadTable(ctx, nil, nil)
+	require.ErrorContains(t, err, "version hint memory://bucket/table/metadata/version-hint.text is empty")
+}
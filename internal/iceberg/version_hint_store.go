@@ -0,0 +1,311 @@
+package iceberg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	objstrerrs "github.com/agnosticeng/objstr/errors"
+	osutils "github.com/agnosticeng/objstr/utils"
+	"github.com/google/uuid"
+)
+
+var ErrLockTimeout = errors.New("timed out waiting for version hint lock")
+
+const versionHintReadAttempts = 5
+
+// VersionHintStore reads and swaps the content of a version-hint.text file.
+type VersionHintStore interface {
+	Read(ctx context.Context, u *url.URL) (string, error)
+	// Swap replaces the content of the version hint with newContent if and only if
+	// it currently equals expectedContent. An empty expectedContent means the version
+	// hint must not exist yet. ErrConsistencyViolation is returned when the
+	// precondition does not hold.
+	Swap(ctx context.Context, u *url.URL, expectedContent string, newContent string) error
+}
+
+// readVersionHint reads the version hint, retrying while it is empty: object stores
+// without atomic overwrites (e.g. the memory backend) expose an empty object while it is
+// being rewritten. A hint still empty after the retries is reported as an error.
+func readVersionHint(ctx context.Context, store VersionHintStore, u *url.URL) (string, error) {
+	for attempt := 1; ; attempt++ {
+		content, err := store.Read(ctx, u)
+
+		if err != nil || len(content) != 0 {
+			return content, err
+		}
+
+		if attempt == versionHintReadAttempts {
+			return "", fmt.Errorf("version hint %s is empty", u)
+		}
+
+		if err := sleepContext(ctx, time.Millisecond*10*time.Duration(attempt)); err != nil {
+			return "", err
+		}
+	}
+}
+
+type versionHintStoreContextKey struct{}
+
+func NewVersionHintStoreContext(ctx context.Context, store VersionHintStore) context.Context {
+	return context.WithValue(ctx, versionHintStoreContextKey{}, store)
+}
+
+func VersionHintStoreFromContextOrDefault(ctx context.Context) VersionHintStore {
+	store, ok := ctx.Value(versionHintStoreContextKey{}).(VersionHintStore)
+
+	if !ok {
+		return NewLockVersionHintStore(LockVersionHintStoreConfig{})
+	}
+
+	return store
+}
+
+type VersionHintStoreConfig struct {
+	Kind string
+	Lock LockVersionHintStoreConfig
+	S3   iceio.S3ConditionalBackendConfig
+}
+
+func NewVersionHintStore(ctx context.Context, conf VersionHintStoreConfig) (VersionHintStore, error) {
+	switch conf.Kind {
+	case "", "lock":
+		return NewLockVersionHintStore(conf.Lock), nil
+	case "s3":
+		be, err := iceio.NewS3ConditionalBackend(ctx, conf.S3)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewConditionalVersionHintStore(be), nil
+	default:
+		return nil, fmt.Errorf("unknown version hint store kind: %s", conf.Kind)
+	}
+}
+
+// ConditionalVersionHintStore performs an atomic compare-and-swap by writing the
+// version hint with an If-Match (or If-None-Match) precondition on its ETag.
+type ConditionalVersionHintStore struct {
+	backend iceio.ConditionalBackend
+}
+
+func NewConditionalVersionHintStore(backend iceio.ConditionalBackend) *ConditionalVersionHintStore {
+	return &ConditionalVersionHintStore{backend: backend}
+}
+
+func (store *ConditionalVersionHintStore) Read(ctx context.Context, u *url.URL) (string, error) {
+	content, _, err := store.backend.Get(ctx, u)
+	return string(content), err
+}
+
+func (store *ConditionalVersionHintStore) Swap(ctx context.Context, u *url.URL, expectedContent string, newContent string) error {
+	var etag string
+
+	if len(expectedContent) != 0 {
+		actualContent, actualEtag, err := store.backend.Get(ctx, u)
+
+		if errors.Is(err, objstrerrs.ErrObjectNotFound) {
+			return ErrConsistencyViolation
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if string(actualContent) != expectedContent {
+			return ErrConsistencyViolation
+		}
+
+		etag = actualEtag
+	}
+
+	err := store.backend.PutIfMatch(ctx, u, []byte(newContent), etag)
+
+	if errors.Is(err, iceio.ErrPreconditionFailed) {
+		return ErrConsistencyViolation
+	}
+
+	return err
+}
+
+type LockVersionHintStoreConfig struct {
+	LeaseDuration  time.Duration
+	AcquireTimeout time.Duration
+	SettleDelay    time.Duration
+}
+
+// LockVersionHintStore is a fallback for object stores without conditional writes.
+// Writers serialize on a lock object stored next to the version hint. The lock
+// carries a lease so that a crashed writer cannot block the table forever.
+// Since acquiring the lock is itself not atomic, the owner re-reads the lock after
+// SettleDelay to detect a concurrent acquisition: this narrows the race window
+// but cannot close it entirely.
+type LockVersionHintStore struct {
+	conf LockVersionHintStoreConfig
+}
+
+type versionHintLock struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewLockVersionHintStore(conf LockVersionHintStoreConfig) *LockVersionHintStore {
+	if conf.LeaseDuration == 0 {
+		conf.LeaseDuration = time.Second * 30
+	}
+
+	if conf.AcquireTimeout == 0 {
+		conf.AcquireTimeout = time.Minute
+	}
+
+	if conf.SettleDelay == 0 {
+		conf.SettleDelay = time.Millisecond * 200
+	}
+
+	return &LockVersionHintStore{conf: conf}
+}
+
+func (store *LockVersionHintStore) Read(ctx context.Context, u *url.URL) (string, error) {
+	content, err := osutils.ReadObject(ctx, objstr.FromContextOrDefault(ctx), u)
+	return string(content), err
+}
+
+func (store *LockVersionHintStore) Swap(ctx context.Context, u *url.URL, expectedContent string, newContent string) error {
+	var (
+		os      = objstr.FromContextOrDefault(ctx)
+		lockLoc = lockLocation(u)
+	)
+
+	owner, err := store.acquire(ctx, os, lockLoc)
+
+	if err != nil {
+		return err
+	}
+
+	defer store.release(context.WithoutCancel(ctx), os, lockLoc, owner)
+
+	actualContent, err := osutils.ReadObject(ctx, os, u)
+
+	switch {
+	case errors.Is(err, objstrerrs.ErrObjectNotFound):
+		if len(expectedContent) != 0 {
+			return ErrConsistencyViolation
+		}
+	case err != nil:
+		return err
+	default:
+		if string(actualContent) != expectedContent {
+			return ErrConsistencyViolation
+		}
+	}
+
+	return osutils.CreateObject(ctx, os, u, []byte(newContent))
+}
+
+func (store *LockVersionHintStore) acquire(ctx context.Context, os *objstr.ObjectStore, lockLoc *url.URL) (string, error) {
+	var (
+		owner    = uuid.Must(uuid.NewV7()).String()
+		deadline = time.Now().Add(store.conf.AcquireTimeout)
+	)
+
+	for {
+		lock, err := readLock(ctx, os, lockLoc)
+
+		if err != nil {
+			return "", err
+		}
+
+		if lock == nil || time.Now().After(lock.ExpiresAt) {
+			if err := writeLock(ctx, os, lockLoc, versionHintLock{
+				Owner:     owner,
+				ExpiresAt: time.Now().Add(store.conf.LeaseDuration),
+			}); err != nil {
+				return "", err
+			}
+
+			if err := sleepContext(ctx, store.conf.SettleDelay); err != nil {
+				return "", err
+			}
+
+			lock, err = readLock(ctx, os, lockLoc)
+
+			if err != nil {
+				return "", err
+			}
+
+			if lock != nil && lock.Owner == owner {
+				return owner, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return "", ErrLockTimeout
+		}
+
+		if err := sleepContext(ctx, store.conf.SettleDelay); err != nil {
+			return "", err
+		}
+	}
+}
+
+func (store *LockVersionHintStore) release(ctx context.Context, os *objstr.ObjectStore, lockLoc *url.URL, owner string) {
+	lock, err := readLock(ctx, os, lockLoc)
+
+	if err != nil || lock == nil || lock.Owner != owner {
+		return
+	}
+
+	_ = os.Delete(ctx, lockLoc)
+}
+
+func lockLocation(u *url.URL) *url.URL {
+	var res = *u
+	res.Path = res.Path + ".lock"
+	return &res
+}
+
+func readLock(ctx context.Context, os *objstr.ObjectStore, u *url.URL) (*versionHintLock, error) {
+	content, err := osutils.ReadObject(ctx, os, u)
+
+	if errors.Is(err, objstrerrs.ErrObjectNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var lock versionHintLock
+
+	if err := json.Unmarshal(content, &lock); err != nil {
+		// a partially written lock is treated as expired
+		return &versionHintLock{}, nil
+	}
+
+	return &lock, nil
+}
+
+func writeLock(ctx context.Context, os *objstr.ObjectStore, u *url.URL, lock versionHintLock) error {
+	js, err := json.Marshal(lock)
+
+	if err != nil {
+		return err
+	}
+
+	return osutils.CreateObject(ctx, os, u, js)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,149 @@
+package iceberg
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	objstrerrs "github.com/agnosticeng/objstr/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryConditionalBackend is a local stand-in for an object store enforcing
+// If-Match / If-None-Match preconditions.
+type memoryConditionalBackend struct {
+	mu      sync.Mutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data []byte
+	etag string
+}
+
+func newMemoryConditionalBackend() *memoryConditionalBackend {
+	return &memoryConditionalBackend{objects: make(map[string]memoryObject)}
+}
+
+func (be *memoryConditionalBackend) Get(ctx context.Context, u *url.URL) ([]byte, string, error) {
+	be.mu.Lock()
+	defer be.mu.Unlock()
+
+	obj, found := be.objects[u.String()]
+
+	if !found {
+		return nil, "", objstrerrs.ErrObjectNotFound
+	}
+
+	return obj.data, obj.etag, nil
+}
+
+func (be *memoryConditionalBackend) PutIfMatch(ctx context.Context, u *url.URL, data []byte, etag string) error {
+	be.mu.Lock()
+	defer be.mu.Unlock()
+
+	obj, found := be.objects[u.String()]
+
+	if (len(etag) == 0 && found) || (len(etag) > 0 && (!found || obj.etag != etag)) {
+		return iceio.ErrPreconditionFailed
+	}
+
+	be.objects[u.String()] = memoryObject{data: data, etag: uuid.NewString()}
+	return nil
+}
+
+// concurrentIncrements runs n writers that each increment a counter stored
+// in the version hint, retrying on consistency violations.
+func concurrentIncrements(t *testing.T, ctx context.Context, store VersionHintStore, u *url.URL, n int) {
+	var wg sync.WaitGroup
+
+	for range n {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				current, err := readVersionHint(ctx, store, u)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				i, err := strconv.Atoi(current)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				err = store.Swap(ctx, u, current, strconv.Itoa(i+1))
+
+				if errors.Is(err, ErrConsistencyViolation) {
+					continue
+				}
+
+				assert.NoError(t, err)
+				return
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestConditionalVersionHintStore(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = NewConditionalVersionHintStore(newMemoryConditionalBackend())
+		u, _  = url.Parse("memory://bucket/table/metadata/version-hint.text")
+	)
+
+	require.NoError(t, store.Swap(ctx, u, "", "0"))
+	require.ErrorIs(t, store.Swap(ctx, u, "", "0"), ErrConsistencyViolation)
+	require.ErrorIs(t, store.Swap(ctx, u, "1", "2"), ErrConsistencyViolation)
+
+	concurrentIncrements(t, ctx, store, u, 50)
+
+	content, err := store.Read(ctx, u)
+	require.NoError(t, err)
+	require.Equal(t, "50", content)
+}
+
+func TestLockVersionHintStore(t *testing.T) {
+	var (
+		ctx   = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{DefaultBackend: "memory"}))
+		store = NewLockVersionHintStore(LockVersionHintStoreConfig{SettleDelay: time.Millisecond * 20})
+		u, _  = url.Parse("memory://bucket/table/metadata/version-hint.text")
+	)
+
+	require.NoError(t, store.Swap(ctx, u, "", "0"))
+	require.ErrorIs(t, store.Swap(ctx, u, "", "0"), ErrConsistencyViolation)
+
+	concurrentIncrements(t, ctx, store, u, 10)
+
+	content, err := store.Read(ctx, u)
+	require.NoError(t, err)
+	require.Equal(t, "10", content)
+}
+
+func TestLockVersionHintStoreExpiredLease(t *testing.T) {
+	var (
+		os    = objstr.MustNewObjectStore(context.Background(), objstr.Config{DefaultBackend: "memory"})
+		ctx   = objstr.NewContext(context.Background(), os)
+		store = NewLockVersionHintStore(LockVersionHintStoreConfig{SettleDelay: time.Millisecond})
+		u, _  = url.Parse("memory://bucket/table/metadata/version-hint.text")
+	)
+
+	require.NoError(t, writeLock(ctx, os, lockLocation(u), versionHintLock{
+		Owner:     "crashed-writer",
+		ExpiresAt: time.Now().Add(-time.Second),
+	}))
+
+	require.NoError(t, store.Swap(ctx, u, "", "0"))
+}
@@ -0,0 +1,208 @@
+package iceberg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	iceio "github.com/agnosticeng/icepq/internal/io"
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go"
+	"github.com/apache/iceberg-go/table"
+)
+
+const (
+	// WapIdKey is the snapshot summary property holding the wap.id of a staged snapshot.
+	WapIdKey = "wap.id"
+	// PublishedWapIdKey is the snapshot summary property holding the wap.id of the staged
+	// snapshot a cherry-picked snapshot was published from.
+	PublishedWapIdKey = "published-wap-id"
+	// SourceSnapshotIdKey is the snapshot summary property holding the ID of the staged
+	// snapshot a cherry-picked snapshot was published from.
+	SourceSnapshotIdKey = "source-snapshot-id"
+)
+
+type PublishConfig struct {
+	// WapId selects the staged snapshot to publish by its wap.id.
+	WapId string
+	// SnapshotId selects the staged snapshot to publish when WapId is not set.
+	SnapshotId int64
+}
+
+// Publish makes a staged snapshot (see CreateOrAddFilesConfig.WapId) current. When the
+// main branch did not move since the snapshot was staged, it is fast-forwarded to it.
+// Otherwise, the files appended by the staged snapshot are cherry-picked: they are
+// committed again on top of the current snapshot.
+func Publish(ctx context.Context, tableLocation string, conf PublishConfig) (*CurrentSnapshotChange, error) {
+	cat, err := NewVersionHintCatalog(tableLocation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := cat.LoadTable(ctx, nil, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var md = t.Metadata()
+
+	staged, err := stagedSnapshot(md, conf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		current = md.CurrentSnapshot()
+		wapId   = snapshotSummaryProperty(staged, WapIdKey)
+		change  = CurrentSnapshotChange{}
+	)
+
+	if current != nil {
+		change.PreviousSnapshotId = &current.SnapshotID
+
+		if isAncestorOf(md, staged.SnapshotID, current.SnapshotID) {
+			return nil, fmt.Errorf("snapshot %d is already published", staged.SnapshotID)
+		}
+
+		for snap := current; snap != nil && len(wapId) > 0; snap = parentSnapshot(md, snap) {
+			if snapshotSummaryProperty(snap, PublishedWapIdKey) == wapId {
+				return nil, fmt.Errorf("wap id %s is already published by snapshot %d", wapId, snap.SnapshotID)
+			}
+		}
+	}
+
+	var basedOnCurrent = staged.ParentSnapshotID == nil && current == nil ||
+		staged.ParentSnapshotID != nil && current != nil && *staged.ParentSnapshotID == current.SnapshotID
+
+	if basedOnCurrent {
+		var (
+			requirements = []table.Requirement{
+				table.AssertTableUUID(md.TableUUID()),
+				table.AssertRefSnapshotID(table.MainBranch, change.PreviousSnapshotId),
+			}
+			updates = []table.Update{
+				setBranchHeadUpdate(md, table.MainBranch, staged.SnapshotID),
+			}
+		)
+
+		if _, _, err := cat.CommitTable(ctx, t, requirements, updates); err != nil {
+			return nil, err
+		}
+
+		change.SnapshotId = staged.SnapshotID
+		return &change, nil
+	}
+
+	if staged.Summary == nil || staged.Summary.Operation != table.OpAppend {
+		return nil, &CommitConflictError{
+			Operation: "publish",
+			Reason:    fmt.Sprintf("snapshot %d is not based on the current snapshot and only appends can be cherry-picked", staged.SnapshotID),
+		}
+	}
+
+	added, err := snapshotAddedDataFiles(iceio.NewObjectStoreIO(objstr.FromContextOrDefault(ctx)), staged)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, df := range added {
+		if int(df.SpecID()) != md.DefaultPartitionSpec() {
+			return nil, &CommitConflictError{
+				Operation: "publish",
+				Reason:    fmt.Sprintf("data file %s was added with partition spec %d, the table partition spec is now %d", df.FilePath(), df.SpecID(), md.DefaultPartitionSpec()),
+			}
+		}
+	}
+
+	var props = iceberg.Properties{SourceSnapshotIdKey: strconv.FormatInt(staged.SnapshotID, 10)}
+
+	if len(wapId) > 0 {
+		props[PublishedWapIdKey] = wapId
+	}
+
+	committed, err := CommitSnapshot(ctx, cat, t, SnapshotUpdate{Operation: table.OpAppend, Added: added, Properties: props})
+
+	if err != nil {
+		return nil, err
+	}
+
+	change.SnapshotId = committed.CurrentSnapshot().SnapshotID
+	return &change, nil
+}
+
+func stagedSnapshot(md table.Metadata, conf PublishConfig) (*table.Snapshot, error) {
+	if len(conf.WapId) == 0 {
+		var snap = md.SnapshotByID(conf.SnapshotId)
+
+		if snap == nil {
+			return nil, fmt.Errorf("snapshot %d not found", conf.SnapshotId)
+		}
+
+		return snap, nil
+	}
+
+	switch staged := wapSnapshots(md, conf.WapId); len(staged) {
+	case 0:
+		return nil, fmt.Errorf("wap id %s not found", conf.WapId)
+	case 1:
+		return staged[0], nil
+	default:
+		return nil, fmt.Errorf("wap id %s is used by %d snapshots", conf.WapId, len(staged))
+	}
+}
+
+// wapSnapshots returns the snapshots staged with the given wap.id.
+func wapSnapshots(md table.Metadata, wapId string) []*table.Snapshot {
+	var res []*table.Snapshot
+
+	for _, snap := range md.Snapshots() {
+		if snapshotSummaryProperty(&snap, WapIdKey) == wapId {
+			res = append(res, md.SnapshotByID(snap.SnapshotID))
+		}
+	}
+
+	return res
+}
+
+func snapshotSummaryProperty(snap *table.Snapshot, key string) string {
+	if snap.Summary == nil {
+		return ""
+	}
+
+	return snap.Summary.Properties[key]
+}
+
+// snapshotAddedDataFiles returns the data files added by a snapshot.
+func snapshotAddedDataFiles(io *iceio.ObjectStoreIO, snap *table.Snapshot) ([]iceberg.DataFile, error) {
+	mans, err := snap.Manifests(io)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res []iceberg.DataFile
+
+	for _, man := range mans {
+		if man.ManifestContent() != iceberg.ManifestContentData || man.SnapshotID() != snap.SnapshotID {
+			continue
+		}
+
+		entries, err := man.FetchEntries(io, true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.Status() == iceberg.EntryStatusADDED && entry.SnapshotID() == snap.SnapshotID {
+				res = append(res, entry.DataFile())
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package iceberg
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/agnosticeng/objstr"
+	"github.com/apache/iceberg-go/table"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublish(t *testing.T) {
+	var (
+		ctx = objstr.NewContext(context.Background(), objstr.MustNewObjectStore(context.Background(), objstr.Config{}))
+		dir = t.TempDir()
+	)
+
+	var load = func() *table.Table {
+		cat, err := NewVersionHintCatalog("file://" + dir)
+		require.NoError(t, err)
+
+		tbl, err := cat.LoadTable(ctx, nil, nil)
+		require.NoError(t, err)
+
+		return tbl
+	}
+
+	var staged = func(wapId string) int64 {
+		var snaps = wapSnapshots(load().Metadata(), wapId)
+		require.Len(t, snaps, 1)
+		return snaps[0].SnapshotID
+	}
+
+	for _, name := range []string{"1.parquet", "2.parquet", "3.parquet", "4.parquet"} {
+		writeTestParquetFile(t, dir, name, []int64{1}, []string{"a"})
+	}
+
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"1.parquet"}, nil, CreateOrAddFilesConfig{}))
+
+	var initial = load().CurrentSnapshot().SnapshotID
+
+	// staged snapshots are not current
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"2.parquet"}, nil, CreateOrAddFilesConfig{WapId: "w1"}))
+	require.Equal(t, initial, load().CurrentSnapshot().SnapshotID)
+	require.Len(t, currentTestDataFiles(t, ctx, dir), 1)
+
+	err := CreateOrAddFiles(ctx, "file://"+dir, []string{"3.parquet"}, nil, CreateOrAddFilesConfig{WapId: "w1"})
+	require.ErrorContains(t, err, "wap id w1 is already used")
+
+	// a branch can point to a staged snapshot to audit it
+	change, err := CreateBranch(ctx, "file://"+dir, "audit", RefConfig{WapId: "w1"})
+	require.NoError(t, err)
+	require.Equal(t, staged("w1"), *change.SnapshotId)
+
+	published, err := Publish(ctx, "file://"+dir, PublishConfig{WapId: "w1"})
+	require.NoError(t, err)
+	require.Equal(t, initial, *published.PreviousSnapshotId)
+	require.Equal(t, staged("w1"), published.SnapshotId)
+	require.Equal(t, staged("w1"), load().CurrentSnapshot().SnapshotID)
+	require.Len(t, currentTestDataFiles(t, ctx, dir), 2)
+
+	_, err = Publish(ctx, "file://"+dir, PublishConfig{WapId: "w1"})
+	require.ErrorContains(t, err, "is already published")
+
+	// the second snapshot staged on the same parent is cherry-picked
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"3.parquet"}, nil, CreateOrAddFilesConfig{WapId: "w2"}))
+	require.NoError(t, CreateOrAddFiles(ctx, "file://"+dir, []string{"4.parquet"}, nil, CreateOrAddFilesConfig{WapId: "w3"}))
+
+	published, err = Publish(ctx, "file://"+dir, PublishConfig{WapId: "w2"})
+	require.NoError(t, err)
+	require.Equal(t, staged("w2"), published.SnapshotId)
+
+	published, err = Publish(ctx, "file://"+dir, PublishConfig{SnapshotId: staged("w3")})
+	require.NoError(t, err)
+	require.NotEqual(t, staged("w3"), published.SnapshotId)
+	require.Len(t, currentTestDataFiles(t, ctx, dir), 4)
+
+	var summary = load().CurrentSnapshot().Summary
+	require.Equal(t, "w3", summary.Properties[PublishedWapIdKey])
+	require.Equal(t, strconv.FormatInt(staged("w3"), 10), summary.Properties[SourceSnapshotIdKey])
+	require.Equal(t, staged("w2"), *load().CurrentSnapshot().ParentSnapshotID)
+
+	_, err = Publish(ctx, "file://"+dir, PublishConfig{WapId: "w3"})
+	require.ErrorContains(t, err, "wap id w3 is already published by snapshot")
+
+	_, err = Publish(ctx, "file://"+dir, PublishConfig{WapId: "missing"})
+	require.ErrorContains(t, err, "wap id missing not found")
+}
@@ -0,0 +1,19 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ConditionalBackend is implemented by object stores that can enforce
+// preconditions on writes by comparing the ETag of the stored object.
+type ConditionalBackend interface {
+	// Get returns the content of the object along with its current ETag.
+	Get(ctx context.Context, u *url.URL) ([]byte, string, error)
+	// PutIfMatch writes the object only if its current ETag is etag.
+	// An empty etag means the object must not exist yet.
+	PutIfMatch(ctx context.Context, u *url.URL, data []byte, etag string) error
+}
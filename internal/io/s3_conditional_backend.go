@@ -0,0 +1,170 @@
+package io
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	objstrerrs "github.com/agnosticeng/objstr/errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+var _ ConditionalBackend = &S3ConditionalBackend{}
+
+type S3ConditionalBackendConfig struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Endpoint        string
+	Region          string
+	ForcePathStyle  bool
+}
+
+// S3ConditionalBackend relies on S3 conditional writes (If-Match / If-None-Match).
+// It honours the same AWS_* environment variables as the objstr S3 backend.
+type S3ConditionalBackend struct {
+	client *s3.Client
+}
+
+func NewS3ConditionalBackend(ctx context.Context, conf S3ConditionalBackendConfig) (*S3ConditionalBackend, error) {
+	if v := os.Getenv("AWS_ACCESS_KEY_ID"); len(conf.AccessKeyId) == 0 && len(v) > 0 {
+		conf.AccessKeyId = v
+	}
+
+	if v := os.Getenv("AWS_SECRET_ACCESS_KEY"); len(conf.SecretAccessKey) == 0 && len(v) > 0 {
+		conf.SecretAccessKey = v
+	}
+
+	if v := os.Getenv("AWS_SESSION_TOKEN"); len(conf.SessionToken) == 0 && len(v) > 0 {
+		conf.SessionToken = v
+	}
+
+	if v := os.Getenv("AWS_S3_ENDPOINT"); len(conf.Endpoint) == 0 && len(v) > 0 {
+		conf.Endpoint = v
+	}
+
+	if v := os.Getenv("AWS_REGION"); len(conf.Region) == 0 && len(v) > 0 {
+		conf.Region = v
+	}
+
+	if v := os.Getenv("AWS_S3_FORCE_PATH_STLE"); len(v) > 0 {
+		if b, err := strconv.ParseBool(v); err == nil {
+			conf.ForcePathStyle = b
+		}
+	}
+
+	var opts []func(*config.LoadOptions) error
+
+	if len(conf.AccessKeyId) > 0 {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(conf.AccessKeyId, conf.SecretAccessKey, conf.SessionToken),
+		))
+	}
+
+	if len(conf.Region) > 0 {
+		opts = append(opts, config.WithRegion(conf.Region))
+	}
+
+	awsConf, err := config.LoadDefaultConfig(ctx, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3ConditionalBackend{
+		client: s3.NewFromConfig(awsConf, func(o *s3.Options) {
+			if len(conf.Endpoint) > 0 {
+				o.BaseEndpoint = aws.String(conf.Endpoint)
+			}
+
+			o.UsePathStyle = conf.ForcePathStyle
+		}),
+	}, nil
+}
+
+func (be *S3ConditionalBackend) Get(ctx context.Context, u *url.URL) ([]byte, string, error) {
+	bucket, key, err := bucketAndKey(u)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	out, err := be.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	var nsk *types.NoSuchKey
+
+	if errors.As(err, &nsk) {
+		return nil, "", objstrerrs.ErrObjectNotFound
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, aws.ToString(out.ETag), nil
+}
+
+func (be *S3ConditionalBackend) PutIfMatch(ctx context.Context, u *url.URL, data []byte, etag string) error {
+	bucket, key, err := bucketAndKey(u)
+
+	if err != nil {
+		return err
+	}
+
+	var input = s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	}
+
+	if len(etag) == 0 {
+		input.IfNoneMatch = aws.String("*")
+	} else {
+		input.IfMatch = aws.String(etag)
+	}
+
+	_, err = be.client.PutObject(ctx, &input)
+
+	var respErr *smithyhttp.ResponseError
+
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		// 409 is returned when a concurrent conditional write is in flight
+		case http.StatusPreconditionFailed, http.StatusConflict:
+			return ErrPreconditionFailed
+		}
+	}
+
+	return err
+}
+
+func bucketAndKey(u *url.URL) (string, string, error) {
+	if len(u.Host) == 0 {
+		return "", "", fmt.Errorf("bucket must be specified")
+	}
+
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}